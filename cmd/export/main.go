@@ -0,0 +1,59 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/delonce/socialnetwork/internal/config"
+	"github.com/delonce/socialnetwork/internal/service/messages"
+	"github.com/delonce/socialnetwork/pkg/logging"
+)
+
+func main() {
+	from := flag.String("from", "", "username of the first dialog participant")
+	to := flag.String("to", "", "username of the second dialog participant")
+	format := flag.String("format", messages.EXPORT_JSON, "export format: json, html or txt")
+	timeZone := flag.String("tz", "Local", "time zone for message timestamps, e.g. Europe/Moscow")
+	output := flag.String("out", "", "output file, <from>_<to>.<format> by default")
+	flag.Parse()
+
+	if *from == "" || *to == "" || !messages.IsExportFormat(*format) {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	logger := logging.GetLogger()
+	webConfig := config.GetConfig(logger)
+
+	location, err := time.LoadLocation(*timeZone)
+
+	if err != nil {
+		logger.Fatalf("Unknown time zone %s", *timeZone)
+	}
+
+	if *output == "" {
+		*output = fmt.Sprintf("%s_%s.%s", *from, *to, *format)
+	}
+
+	file, err := os.Create(*output)
+
+	if err != nil {
+		logger.Fatalf("Failed to create output file, %v", err)
+	}
+
+	defer file.Close()
+
+	msgViewer, err := messages.NewMessageViewer(logger, webConfig)
+
+	if err != nil {
+		logger.Fatalf("Failed to create message viewer, %v", err)
+	}
+
+	if err = msgViewer.ExportDialog(file, *from, *to, *format, location); err != nil {
+		logger.Fatalf("Failed to export dialog %s - %s, %v", *from, *to, err)
+	}
+
+	logger.Infof("Dialog %s - %s exported to %s", *from, *to, *output)
+}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"sync"
+	"time"
 
 	"github.com/delonce/socialnetwork/pkg/logging"
 
@@ -28,6 +29,27 @@ type Config struct {
 		Password string `yaml:"password"`
 		Login    string `yaml:"login"`
 	} `yaml:"database"`
+
+	Scheduler struct {
+		Interval time.Duration `yaml:"interval" env-default:"10s"`
+		Lease    time.Duration `yaml:"lease" env-default:"1m"`
+	} `yaml:"scheduler"`
+
+	Friends struct {
+		RequestTTL          time.Duration `yaml:"requestTTL" env-default:"720h"`
+		ExpiryInterval      time.Duration `yaml:"expiryInterval" env-default:"1h"`
+		MaxOutgoingRequests int64         `yaml:"maxOutgoingRequests" env-default:"50"`
+	} `yaml:"friends"`
+
+	Feed struct {
+		PageSize int64  `yaml:"pageSize" env-default:"20"`
+		Timeline string `yaml:"timeline" env-default:"fanout"`
+	} `yaml:"feed"`
+
+	Events struct {
+		ReminderBefore   time.Duration `yaml:"reminderBefore" env-default:"1h"`
+		ReminderInterval time.Duration `yaml:"reminderInterval" env-default:"1m"`
+	} `yaml:"events"`
 }
 
 var instance *Config
@@ -2,18 +2,30 @@ package database
 
 import (
 	"context"
+	"errors"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// ErrNotFound is returned by updates and deletes which matched no object
+var ErrNotFound = errors.New("Object not found")
+
 type DBStorage interface {
 	CreateObject(ctx context.Context, model interface{}, key string) (string, error)
 	FindObjects(ctx context.Context, filter bson.M, key string, findOpt ...*options.FindOptions) (*mongo.Cursor, error)
 	FindOneObject(ctx context.Context, filter bson.M, key string) (*mongo.SingleResult, error)
+	FindOneAndUpdate(ctx context.Context, filter bson.M, model interface{}, key string) (*mongo.SingleResult, error)
 	Update(ctx context.Context, filter bson.M, model interface{}, key string) error
+	UpdateMany(ctx context.Context, filter bson.M, model interface{}, key string) error
+	Upsert(ctx context.Context, filter bson.M, model interface{}, key string) error
+	InsertIfAbsent(ctx context.Context, filter bson.M, model interface{}, key string) (bool, error)
+	Increment(ctx context.Context, filter bson.M, counters bson.M, key string) error
 	Delete(ctx context.Context, filter bson.M, key string) error
+	DeleteMany(ctx context.Context, filter bson.M, key string) error
 	CountObjects(ctx context.Context, filter bson.M, key string) (int64, error)
 	Aggregate(ctx context.Context, pipeline interface{}, key string) (*mongo.Cursor, error)
+	EnsureUniqueIndex(ctx context.Context, fields []string, key string) error
+	EnsureTTLIndex(ctx context.Context, field string, key string) error
 }
@@ -48,7 +48,7 @@ func (db *mongoDB) FindOneObject(ctx context.Context, filter bson.M, key string)
 
 	if result.Err() != nil {
 		if errors.Is(result.Err(), mongo.ErrNoDocuments) {
-			return nil, fmt.Errorf("Object not found")
+			return nil, fmt.Errorf("Object not found, %w", result.Err())
 		}
 
 		db.logger.Errorf("Failed to find object with error: %v", result.Err())
@@ -58,6 +58,24 @@ func (db *mongoDB) FindOneObject(ctx context.Context, filter bson.M, key string)
 	return result, nil
 }
 
+func (db *mongoDB) FindOneAndUpdate(ctx context.Context, filter bson.M, model interface{}, key string) (*mongo.SingleResult, error) {
+	update := bson.D{{Key: "$set", Value: model}}
+	updateOpts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	result := db.colls[key].FindOneAndUpdate(ctx, filter, update, updateOpts)
+
+	if result.Err() != nil {
+		if errors.Is(result.Err(), mongo.ErrNoDocuments) {
+			return nil, fmt.Errorf("Object not found, %w", result.Err())
+		}
+
+		db.logger.Errorf("Failed to find and update object with error: %v", result.Err())
+		return nil, result.Err()
+	}
+
+	return result, nil
+}
+
 func (db *mongoDB) FindObjects(ctx context.Context, filter bson.M, key string, findOpt ...*options.FindOptions) (*mongo.Cursor, error) {
 	result, err := db.colls[key].Find(ctx, filter, findOpt...)
 
@@ -94,13 +112,78 @@ func (db *mongoDB) Update(ctx context.Context, filter bson.M, model interface{},
 	}
 
 	if result.MatchedCount == 0 {
-		return fmt.Errorf("Object not found")
+		return database.ErrNotFound
 	}
 
 	db.logger.Infof("Model %s with filter %s updated", model, filter)
 	return nil
 }
 
+func (db *mongoDB) UpdateMany(ctx context.Context, filter bson.M, model interface{}, key string) error {
+	update := bson.D{{Key: "$set", Value: model}}
+	result, err := db.colls[key].UpdateMany(ctx, filter, update)
+
+	if err != nil {
+		db.logger.Errorf("Failed to execute update many query, error: %v", err)
+		return err
+	}
+
+	db.logger.Infof("Model %s with filter %s updated %d objects", model, filter, result.ModifiedCount)
+	return nil
+}
+
+func (db *mongoDB) Upsert(ctx context.Context, filter bson.M, model interface{}, key string) error {
+	update := bson.D{{Key: "$set", Value: model}}
+	updateOpts := options.Update().SetUpsert(true)
+
+	_, err := db.colls[key].UpdateOne(ctx, filter, update, updateOpts)
+
+	if err != nil {
+		db.logger.Errorf("Failed to execute upsert query, error: %v", err)
+		return err
+	}
+
+	db.logger.Infof("Model %s with filter %s upserted", model, filter)
+	return nil
+}
+
+// InsertIfAbsent inserts the model only when nothing matches the filter and
+// reports whether it was inserted, a duplicate key counts as already present
+func (db *mongoDB) InsertIfAbsent(ctx context.Context, filter bson.M, model interface{}, key string) (bool, error) {
+	update := bson.D{{Key: "$setOnInsert", Value: model}}
+	updateOpts := options.Update().SetUpsert(true)
+
+	result, err := db.colls[key].UpdateOne(ctx, filter, update, updateOpts)
+
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+
+	if err != nil {
+		db.logger.Errorf("Failed to execute insert if absent query, error: %v", err)
+		return false, err
+	}
+
+	return result.UpsertedCount == 1, nil
+}
+
+// Increment changes counters with $inc in one atomic update
+func (db *mongoDB) Increment(ctx context.Context, filter bson.M, counters bson.M, key string) error {
+	update := bson.D{{Key: "$inc", Value: counters}}
+	result, err := db.colls[key].UpdateOne(ctx, filter, update)
+
+	if err != nil {
+		db.logger.Errorf("Failed to execute increment query, error: %v", err)
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return database.ErrNotFound
+	}
+
+	return nil
+}
+
 func (db *mongoDB) Delete(ctx context.Context, filter bson.M, key string) error {
 	result, err := db.colls[key].DeleteOne(ctx, filter)
 
@@ -110,9 +193,21 @@ func (db *mongoDB) Delete(ctx context.Context, filter bson.M, key string) error
 	}
 
 	if result.DeletedCount == 0 {
-		return fmt.Errorf("Object not found")
+		return database.ErrNotFound
+	}
+
+	return nil
+}
+
+func (db *mongoDB) DeleteMany(ctx context.Context, filter bson.M, key string) error {
+	result, err := db.colls[key].DeleteMany(ctx, filter)
+
+	if err != nil {
+		db.logger.Errorf("Failed to delete many %s with error: %v", filter, err)
+		return err
 	}
 
+	db.logger.Infof("Deleted %d objects with filter %s", result.DeletedCount, filter)
 	return nil
 }
 
@@ -120,13 +215,45 @@ func (db *mongoDB) Aggregate(ctx context.Context, pipeline interface{}, key stri
 	result, err := db.colls[key].Aggregate(ctx, pipeline)
 
 	if err != nil {
-		if errors.Is(result.Err(), mongo.ErrNoDocuments) {
-			return nil, fmt.Errorf("Object not found")
-		}
-
-		db.logger.Errorf("Failed to find object with error: %v", result.Err())
-		return nil, result.Err()
+		db.logger.Errorf("Failed to execute aggregation with error: %v", err)
+		return nil, err
 	}
 
 	return result, nil
 }
+
+func (db *mongoDB) EnsureUniqueIndex(ctx context.Context, fields []string, key string) error {
+	indexKeys := bson.D{}
+
+	for _, field := range fields {
+		indexKeys = append(indexKeys, bson.E{Key: field, Value: 1})
+	}
+
+	index := mongo.IndexModel{
+		Keys:    indexKeys,
+		Options: options.Index().SetUnique(true),
+	}
+
+	if _, err := db.colls[key].Indexes().CreateOne(ctx, index); err != nil {
+		db.logger.Errorf("Failed to create unique index %v, error: %v", fields, err)
+		return err
+	}
+
+	return nil
+}
+
+// EnsureTTLIndex makes mongo remove documents once the time in the field
+// has passed, the removal runs about once a minute
+func (db *mongoDB) EnsureTTLIndex(ctx context.Context, field string, key string) error {
+	index := mongo.IndexModel{
+		Keys:    bson.D{{Key: field, Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	}
+
+	if _, err := db.colls[key].Indexes().CreateOne(ctx, index); err != nil {
+		db.logger.Errorf("Failed to create ttl index on %s, error: %v", field, err)
+		return err
+	}
+
+	return nil
+}
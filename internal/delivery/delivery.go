@@ -44,10 +44,63 @@ func (devHandler *deliveryHandler) Register() {
 	devHandler.Router.GET(handlers.LOGOUT_URL, devHandler.CheckAuth(devHandler.Logout))
 
 	devHandler.Router.GET(handlers.OTHER_PAGE_URL, devHandler.CheckAuth(devHandler.GetOtherPage))
+
+	devHandler.Router.GET(handlers.FEED_URL, devHandler.CheckAuth(devHandler.GetFeedPage))
+
+	devHandler.Router.POST(handlers.POSTS_URL, devHandler.CheckAuth(devHandler.CreatePost))
+	devHandler.Router.POST(handlers.DELETE_POST_URL, devHandler.CheckAuth(devHandler.DeletePost))
+
+	devHandler.Router.POST(handlers.STORIES_URL, devHandler.CheckAuth(devHandler.CreateStory))
+	devHandler.Router.GET(handlers.STORY_URL, devHandler.CheckAuth(devHandler.GetStoryPage))
+	devHandler.Router.GET(handlers.STORY_IMAGE_URL, devHandler.CheckAuth(devHandler.GetStoryImage))
+	devHandler.Router.POST(handlers.DELETE_STORY_URL, devHandler.CheckAuth(devHandler.DeleteStory))
+
+	devHandler.Router.GET(handlers.GROUPS_URL, devHandler.CheckAuth(devHandler.GetGroupsPage))
+	devHandler.Router.POST(handlers.GROUPS_URL, devHandler.CheckAuth(devHandler.CreateGroup))
+	devHandler.Router.GET(handlers.GROUP_URL, devHandler.CheckAuth(devHandler.GetGroupPage))
+	devHandler.Router.POST(handlers.EDIT_GROUP_URL, devHandler.CheckAuth(devHandler.EditGroup))
+	devHandler.Router.POST(handlers.DELETE_GROUP_URL, devHandler.CheckAuth(devHandler.DeleteGroup))
+	devHandler.Router.POST(handlers.JOIN_GROUP_URL, devHandler.CheckAuth(devHandler.JoinGroup))
+	devHandler.Router.POST(handlers.LEAVE_GROUP_URL, devHandler.CheckAuth(devHandler.LeaveGroup))
+	devHandler.Router.POST(handlers.GROUP_POSTS_URL, devHandler.CheckAuth(devHandler.AddGroupPost))
+	devHandler.Router.POST(handlers.DELETE_GROUP_POST_URL, devHandler.CheckAuth(devHandler.DeleteGroupPost))
+	devHandler.Router.POST(handlers.APPROVE_GROUP_MEMBER_URL, devHandler.CheckAuth(devHandler.ApproveGroupMember))
+	devHandler.Router.POST(handlers.REJECT_GROUP_MEMBER_URL, devHandler.CheckAuth(devHandler.RejectGroupMember))
+	devHandler.Router.POST(handlers.REMOVE_GROUP_MEMBER_URL, devHandler.CheckAuth(devHandler.RemoveGroupMember))
+	devHandler.Router.POST(handlers.GROUP_MEMBER_ROLE_URL, devHandler.CheckAuth(devHandler.SetGroupMemberRole))
+
+	devHandler.Router.GET(handlers.EVENTS_URL, devHandler.CheckAuth(devHandler.GetEventsPage))
+	devHandler.Router.POST(handlers.EVENTS_URL, devHandler.CheckAuth(devHandler.CreateEvent))
+	devHandler.Router.GET(handlers.EVENT_URL, devHandler.CheckAuth(devHandler.GetEventPage))
+	devHandler.Router.POST(handlers.DELETE_EVENT_URL, devHandler.CheckAuth(devHandler.DeleteEvent))
+	devHandler.Router.POST(handlers.EVENT_RSVP_URL, devHandler.CheckAuth(devHandler.AnswerEvent))
+	devHandler.Router.POST(handlers.EVENT_INVITE_URL, devHandler.CheckAuth(devHandler.InviteToEvent))
+	devHandler.Router.GET(handlers.EVENT_ICS_URL, devHandler.CheckAuth(devHandler.ExportEvent))
+	devHandler.Router.POST(handlers.DISMISS_REMINDER_URL, devHandler.CheckAuth(devHandler.DismissEventReminder))
+
+	devHandler.Router.POST(handlers.GUESTBOOK_URL, devHandler.CheckAuth(devHandler.AddGuestbookEntry))
+	devHandler.Router.POST(handlers.DELETE_GUESTBOOK_URL, devHandler.CheckAuth(devHandler.DeleteGuestbookEntry))
+	devHandler.Router.POST(handlers.HIDE_GUESTBOOK_URL, devHandler.CheckAuth(devHandler.HideGuestbookEntry))
+	devHandler.Router.POST(handlers.UNHIDE_GUESTBOOK_URL, devHandler.CheckAuth(devHandler.UnhideGuestbookEntry))
+	devHandler.Router.POST(handlers.LIKE_GUESTBOOK_URL, devHandler.CheckAuth(devHandler.LikeGuestbookEntry))
+
 	devHandler.Router.GET(handlers.SEND_REQUEST_URL, devHandler.CheckAuth(devHandler.SendFriendRequest))
+	devHandler.Router.POST(handlers.SEND_REQUEST_URL, devHandler.CheckAuth(devHandler.SendFriendRequestWithMessage))
 
 	devHandler.Router.GET(handlers.FRIEND_REQUESTS_URL, devHandler.CheckAuth(devHandler.GetFriendRequestsPage))
 	devHandler.Router.GET(handlers.MY_FRIENDS_URL, devHandler.CheckAuth(devHandler.GetMyFriends))
+	devHandler.Router.GET(handlers.OUTGOING_URL, devHandler.CheckAuth(devHandler.GetOutgoingRequestsPage))
+
+	devHandler.Router.GET(handlers.IMPORT_CONTACTS_URL, devHandler.CheckAuth(devHandler.GetImportContactsPage))
+	devHandler.Router.POST(handlers.IMPORT_CONTACTS_URL, devHandler.CheckAuth(devHandler.ImportContacts))
+	devHandler.Router.POST(handlers.CONTACTS_REQUEST_URL, devHandler.CheckAuth(devHandler.SendContactRequests))
+
+	devHandler.Router.GET(handlers.FRIEND_LISTS_URL, devHandler.CheckAuth(devHandler.GetFriendListsPage))
+	devHandler.Router.POST(handlers.FRIEND_LISTS_URL, devHandler.CheckAuth(devHandler.CreateFriendList))
+	devHandler.Router.POST(handlers.RENAME_LIST_URL, devHandler.CheckAuth(devHandler.RenameFriendList))
+	devHandler.Router.POST(handlers.DELETE_LIST_URL, devHandler.CheckAuth(devHandler.DeleteFriendList))
+	devHandler.Router.POST(handlers.LIST_MEMBERS_URL, devHandler.CheckAuth(devHandler.AddFriendToList))
+	devHandler.Router.POST(handlers.REMOVE_LIST_MEMBER_URL, devHandler.CheckAuth(devHandler.RemoveFriendFromList))
 
 	devHandler.Router.GET(handlers.CANCEL_REQUEST_URL, devHandler.CheckAuth(devHandler.CancelFriendRequest))
 	devHandler.Router.GET(handlers.REJECT_REQUEST_URL, devHandler.CheckAuth(devHandler.RejectFriendRequest))
@@ -55,9 +108,62 @@ func (devHandler *deliveryHandler) Register() {
 	devHandler.Router.GET(handlers.ACCEPT_FRIEND_URL, devHandler.CheckAuth(devHandler.AcceptFriend))
 	devHandler.Router.GET(handlers.DENY_FRIEND_URL, devHandler.CheckAuth(devHandler.DenyFriend))
 
+	devHandler.Router.GET(handlers.BLOCK_USER_URL, devHandler.CheckAuth(devHandler.BlockUser))
+	devHandler.Router.GET(handlers.UNBLOCK_USER_URL, devHandler.CheckAuth(devHandler.UnblockUser))
+	devHandler.Router.GET(handlers.BLOCKED_USERS_URL, devHandler.CheckAuth(devHandler.GetBlockedUsersPage))
+
+	devHandler.Router.GET(handlers.FOLLOW_USER_URL, devHandler.CheckAuth(devHandler.FollowUser))
+	devHandler.Router.GET(handlers.UNFOLLOW_USER_URL, devHandler.CheckAuth(devHandler.UnfollowUser))
+	devHandler.Router.GET(handlers.FOLLOWERS_URL, devHandler.CheckAuth(devHandler.GetFollowersPage))
+	devHandler.Router.GET(handlers.FOLLOWING_URL, devHandler.CheckAuth(devHandler.GetFollowingPage))
+
+	devHandler.Router.GET(handlers.FOLLOWER_REQUESTS_URL, devHandler.CheckAuth(devHandler.GetFollowerRequestsPage))
+	devHandler.Router.GET(handlers.APPROVE_FOLLOWER_URL, devHandler.CheckAuth(devHandler.ApproveFollower))
+	devHandler.Router.GET(handlers.REJECT_FOLLOWER_URL, devHandler.CheckAuth(devHandler.RejectFollower))
+	devHandler.Router.POST(handlers.PRIVACY_URL, devHandler.CheckAuth(devHandler.SetAccountPrivacy))
+
+	devHandler.Router.GET(handlers.VISIBILITY_URL, devHandler.CheckAuth(devHandler.GetVisibilityPage))
+	devHandler.Router.POST(handlers.VISIBILITY_URL, devHandler.CheckAuth(devHandler.SetVisibility))
+	devHandler.Router.POST(handlers.USERNAME_SETTINGS_URL, devHandler.CheckAuth(devHandler.ChangeUsername))
+	devHandler.Router.GET(handlers.PROFILE_SETTINGS_URL, devHandler.CheckAuth(devHandler.GetProfilePage))
+	devHandler.Router.POST(handlers.PROFILE_SETTINGS_URL, devHandler.CheckAuth(devHandler.UpdateProfile))
+
+	devHandler.Router.PUT(handlers.API_USERNAME_URL, devHandler.CheckAPIAuth(devHandler.ChangeUsernameAPI))
+
+	devHandler.Router.GET(handlers.API_FEED_URL, devHandler.CheckAPIAuth(devHandler.GetFeedAPI))
+
+	devHandler.Router.GET(handlers.API_WALL_URL, devHandler.CheckAPIAuth(devHandler.GetWallAPI))
+	devHandler.Router.POST(handlers.API_POSTS_URL, devHandler.CheckAPIAuth(devHandler.CreatePostAPI))
+	devHandler.Router.DELETE(handlers.API_POST_URL, devHandler.CheckAPIAuth(devHandler.DeletePostAPI))
+
+	devHandler.Router.POST(handlers.API_IMPORT_CONTACTS_URL, devHandler.CheckAPIAuth(devHandler.ImportContactsAPI))
+
+	devHandler.Router.GET(handlers.API_OUTGOING_URL, devHandler.CheckAPIAuth(devHandler.GetOutgoingRequestsAPI))
+	devHandler.Router.POST(handlers.API_OUTGOING_REQUEST_URL, devHandler.CheckAPIAuth(devHandler.SendFriendRequestAPI))
+	devHandler.Router.DELETE(handlers.API_OUTGOING_REQUEST_URL, devHandler.CheckAPIAuth(devHandler.CancelFriendRequestAPI))
+
+	devHandler.Router.GET(handlers.API_BLOCKS_URL, devHandler.CheckAPIAuth(devHandler.GetBlockedUsersAPI))
+	devHandler.Router.POST(handlers.API_BLOCK_USER_URL, devHandler.CheckAPIAuth(devHandler.BlockUserAPI))
+	devHandler.Router.DELETE(handlers.API_BLOCK_USER_URL, devHandler.CheckAPIAuth(devHandler.UnblockUserAPI))
+
 	devHandler.Router.GET(handlers.MESSAGE_URL, devHandler.CheckAuth(devHandler.GetMyMessages))
 	devHandler.Router.GET(handlers.DIALOG_URL, devHandler.CheckAuth(devHandler.GetMessagePage))
 	devHandler.Router.POST(handlers.DIALOG_URL, devHandler.CheckAuth(devHandler.SendNewMessage))
+	devHandler.Router.POST(handlers.REACTION_URL, devHandler.CheckAuth(devHandler.ReactToMessage))
+	devHandler.Router.POST(handlers.POLL_URL, devHandler.CheckAuth(devHandler.SendPoll))
+	devHandler.Router.POST(handlers.VOTE_URL, devHandler.CheckAuth(devHandler.VotePoll))
+	devHandler.Router.GET(handlers.EXPORT_URL, devHandler.CheckAuth(devHandler.ExportDialog))
+
+	devHandler.Router.POST(handlers.PIN_DIALOG_URL, devHandler.CheckAuth(devHandler.PinDialog))
+	devHandler.Router.POST(handlers.UNPIN_DIALOG_URL, devHandler.CheckAuth(devHandler.UnpinDialog))
+	devHandler.Router.POST(handlers.ARCHIVE_DIALOG_URL, devHandler.CheckAuth(devHandler.ArchiveDialog))
+	devHandler.Router.POST(handlers.UNARCHIVE_DIALOG_URL, devHandler.CheckAuth(devHandler.UnarchiveDialog))
+	devHandler.Router.POST(handlers.MUTE_DIALOG_URL, devHandler.CheckAuth(devHandler.MuteDialog))
+	devHandler.Router.POST(handlers.UNMUTE_DIALOG_URL, devHandler.CheckAuth(devHandler.UnmuteDialog))
+
+	devHandler.Router.GET(handlers.SCHEDULED_URL, devHandler.CheckAuth(devHandler.GetScheduledPage))
+	devHandler.Router.POST(handlers.EDIT_SCHEDULED_URL, devHandler.CheckAuth(devHandler.EditScheduledMessage))
+	devHandler.Router.POST(handlers.CANCEL_SCHEDULED_URL, devHandler.CheckAuth(devHandler.CancelScheduledMessage))
 
 	devHandler.Router.ServeFiles(handlers.STATIC_FILE_URL, http.Dir(handlers.STATIC_FILE_PATH))
 
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/delonce/socialnetwork/internal/service/access"
+	"github.com/delonce/socialnetwork/internal/service/friends"
+)
+
+// authorize asks the access policy and sends the user home when the action
+// is denied, the caller must stop on false
+func (handler *NetworkHandler) authorize(w http.ResponseWriter, r *http.Request, subject string, action string, resource string) bool {
+	policy, err := access.NewPolicy(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Error when creating access policy, %v", err)
+		http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
+		return false
+	}
+
+	if err = policy.Authorize(subject, action, resource); err != nil {
+		handler.HandlerLogger.Infof("Access of %s to %s of %s denied, %v", subject, action, resource, err)
+		http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
+		return false
+	}
+
+	return true
+}
+
+func (handler *NetworkHandler) authorizeAPI(w http.ResponseWriter, subject string, action string, resource string) bool {
+	policy, err := access.NewPolicy(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Error when creating access policy, %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "Something wrong")
+		return false
+	}
+
+	if err = policy.Authorize(subject, action, resource); err != nil {
+		writeJSONError(w, accessErrorStatus(err), err.Error())
+		return false
+	}
+
+	return true
+}
+
+func accessErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, access.ErrSelfAction):
+		return http.StatusBadRequest
+	case errors.Is(err, access.ErrNotOwner), errors.Is(err, friends.ErrBlocked),
+		errors.Is(err, friends.ErrNotFriends):
+		return http.StatusForbidden
+	}
+
+	return http.StatusInternalServerError
+}
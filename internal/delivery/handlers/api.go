@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/delonce/socialnetwork/internal/service"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+func (handler *NetworkHandler) CheckAPIAuth(next httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+		r, ok := handler.authorizeRequest(w, r)
+
+		if !ok {
+			writeJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		next(w, r, params)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+
+	json.NewEncoder(w).Encode(body)
+}
+
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+func (handler *NetworkHandler) getAPICurrentUser(w http.ResponseWriter, r *http.Request) *service.User {
+	userID := fmt.Sprintf("%v", r.Context().Value(userIDKey))
+
+	directory, err := handler.getUserDirectory(r)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Error when creating user directory, %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "Something wrong")
+		return nil
+	}
+
+	currentUser, err := directory.GetUserByID(userID)
+
+	if err != nil {
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized")
+		return nil
+	}
+
+	return currentUser
+}
@@ -2,10 +2,12 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 
 	"github.com/delonce/socialnetwork/internal/service"
+	"github.com/delonce/socialnetwork/internal/service/friends"
 	"github.com/delonce/socialnetwork/internal/service/user"
 
 	"github.com/julienschmidt/httprouter"
@@ -111,7 +113,7 @@ func (handler *NetworkHandler) GetLoginPage(w http.ResponseWriter, r *http.Reque
 
 		userID := session.GetTokenPair().Refresh.UserID
 
-		ctx := context.WithValue(context.Background(), userIDKey, userID)
+		ctx := newRequestContext(userID)
 		r = r.WithContext(ctx)
 
 		http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
@@ -130,22 +132,31 @@ func (handler *NetworkHandler) GetLoginPage(w http.ResponseWriter, r *http.Reque
 
 func (handler *NetworkHandler) CheckAuth(next httprouter.Handle) httprouter.Handle {
 	return func(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
-		ok, session := handler.checkUserSession(w, r)
+		r, ok := handler.authorizeRequest(w, r)
 
 		if !ok {
 			http.Redirect(w, r, LOGIN_URL, http.StatusSeeOther)
 			return
 		}
 
-		setTokenCookie(w, session)
-
-		userID := session.GetTokenPair().Refresh.UserID
+		next(w, r, params)
+	}
+}
 
-		ctx := context.WithValue(context.Background(), userIDKey, userID)
-		r = r.WithContext(ctx)
+func (handler *NetworkHandler) authorizeRequest(w http.ResponseWriter, r *http.Request) (*http.Request, bool) {
+	ok, session := handler.checkUserSession(w, r)
 
-		next(w, r, params)
+	if !ok {
+		return r, false
 	}
+
+	setTokenCookie(w, session)
+
+	userID := session.GetTokenPair().Refresh.UserID
+
+	ctx := newRequestContext(userID)
+
+	return r.WithContext(ctx), true
 }
 
 func (handler *NetworkHandler) checkUserSession(w http.ResponseWriter, r *http.Request) (bool, user.Session) {
@@ -220,7 +231,7 @@ func clearCookie(w http.ResponseWriter) {
 func (handler *NetworkHandler) getCurrentUser(w http.ResponseWriter, r *http.Request) *service.User {
 	userID := fmt.Sprintf("%v", r.Context().Value(userIDKey))
 
-	authService, err := user.NewAuthService(handler.HandlerLogger, handler.HandlerConfig)
+	directory, err := handler.getUserDirectory(r)
 
 	if err != nil {
 		fmt.Println(err)
@@ -228,7 +239,7 @@ func (handler *NetworkHandler) getCurrentUser(w http.ResponseWriter, r *http.Req
 		return nil
 	}
 
-	user, err := authService.GetUserByID(userID)
+	user, err := directory.GetUserByID(userID)
 
 	if err != nil {
 		fmt.Println(err)
@@ -238,3 +249,99 @@ func (handler *NetworkHandler) getCurrentUser(w http.ResponseWriter, r *http.Req
 
 	return user
 }
+
+// requestDirectory travels in the context of an authorized request, the user
+// directory is created on first use and dropped together with the request
+type requestDirectory struct {
+	directory user.Directory
+}
+
+func newRequestContext(userID string) context.Context {
+	ctx := context.WithValue(context.Background(), userIDKey, userID)
+
+	return context.WithValue(ctx, userDirectoryKey, &requestDirectory{})
+}
+
+func (handler *NetworkHandler) getUserDirectory(r *http.Request) (user.Directory, error) {
+	holder, ok := r.Context().Value(userDirectoryKey).(*requestDirectory)
+
+	if ok && holder.directory != nil {
+		return holder.directory, nil
+	}
+
+	directory, err := user.NewUserDirectory(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if ok {
+		holder.directory = directory
+	}
+
+	return directory, nil
+}
+
+func (handler *NetworkHandler) ChangeUsername(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	currentUser := handler.getCurrentUser(w, r)
+	authService, err := user.NewAuthService(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Can't create auth service, %v", err)
+		http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
+		return
+	}
+
+	if err = authService.ChangeUsername(currentUser.ID, r.FormValue("username")); err != nil {
+		handler.HandlerLogger.Errorf("Can't change username of %s, %v", currentUser.Username, err)
+	} else {
+		friends.DropSuggestions()
+	}
+
+	http.Redirect(w, r, VISIBILITY_URL, http.StatusSeeOther)
+}
+
+func (handler *NetworkHandler) ChangeUsernameAPI(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	currentUser := handler.getAPICurrentUser(w, r)
+
+	if currentUser == nil {
+		return
+	}
+
+	authService, err := user.NewAuthService(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Can't create auth service, %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "Something wrong")
+		return
+	}
+
+	if err = authService.ChangeUsername(currentUser.ID, r.FormValue("username")); err != nil {
+		writeJSONError(w, usernameErrorStatus(err), err.Error())
+		return
+	}
+
+	friends.DropSuggestions()
+
+	updatedUser, err := authService.GetUserByID(currentUser.ID)
+
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Something wrong")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"username": updatedUser.Username})
+}
+
+func usernameErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, user.ErrWrongUsername), errors.Is(err, user.ErrSameUsername):
+		return http.StatusBadRequest
+	case errors.Is(err, user.ErrUsernameTaken):
+		return http.StatusConflict
+	case errors.Is(err, user.ErrUserNotFound):
+		return http.StatusNotFound
+	}
+
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/delonce/socialnetwork/internal/service"
+	"github.com/delonce/socialnetwork/internal/service/contacts"
+	"github.com/delonce/socialnetwork/internal/service/friends"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+func (handler *NetworkHandler) GetImportContactsPage(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	IMPORT_CONTACTS_TEMPLATE.Execute(w, map[string]interface{}{})
+}
+
+func (handler *NetworkHandler) ImportContacts(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	currentUser := handler.getCurrentUser(w, r)
+	matches, err := handler.findContacts(w, r, currentUser.Username)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Can't import contacts of %s, %v", currentUser.Username, err)
+		IMPORT_CONTACTS_TEMPLATE.Execute(w, map[string]interface{}{"Error": contactsErrorText(err)})
+		return
+	}
+
+	usernames := []string{}
+	newUsernames := []string{}
+
+	for _, match := range matches {
+		usernames = append(usernames, match.Username)
+
+		if !match.IsFriend && !match.IsRequestSent {
+			newUsernames = append(newUsernames, match.Username)
+		}
+	}
+
+	directory, err := handler.getUserDirectory(r)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Error when creating user directory, %v", err)
+		http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
+		return
+	}
+
+	templateMap := map[string]interface{}{
+		"IsImported":   true,
+		"Matches":      matches,
+		"NewUsernames": newUsernames,
+		"Cards":        directory.GetCards(usernames),
+	}
+
+	IMPORT_CONTACTS_TEMPLATE.Execute(w, templateMap)
+}
+
+func (handler *NetworkHandler) SendContactRequests(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	currentUser := handler.getCurrentUser(w, r)
+	manager, err := friends.NewFriendManager(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Error when creating friend manager, %v", err)
+		http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
+		return
+	}
+
+	r.ParseForm()
+
+	for _, username := range r.Form["username"] {
+		if err = manager.SendFriendRequest(currentUser.Username, username); err != nil {
+			handler.HandlerLogger.Errorf("Can't send request from %s to %s, %v", currentUser.Username, username, err)
+		}
+	}
+
+	http.Redirect(w, r, OUTGOING_URL, http.StatusSeeOther)
+}
+
+func (handler *NetworkHandler) ImportContactsAPI(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	currentUser := handler.getAPICurrentUser(w, r)
+
+	if currentUser == nil {
+		return
+	}
+
+	matches, err := handler.findContacts(w, r, currentUser.Username)
+
+	if err != nil {
+		writeJSONError(w, contactsErrorStatus(err), err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"matches": matches})
+}
+
+// findContacts reads the uploaded file into memory only, nothing from it is
+// stored except requests the user sends afterwards
+func (handler *NetworkHandler) findContacts(w http.ResponseWriter, r *http.Request, username string) ([]service.ContactMatch, error) {
+	finder, err := contacts.NewContactFinder(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		return nil, err
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, 2*contacts.MaxFileSize)
+	uploaded, header, err := r.FormFile("contacts")
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer uploaded.Close()
+
+	file, err := io.ReadAll(io.LimitReader(uploaded, contacts.MaxFileSize+1))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return finder.FindContacts(username, file, contacts.DetectFormat(header.Filename, file))
+}
+
+func contactsErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, contacts.ErrFileTooLarge), errors.Is(err, contacts.ErrTooManyContacts):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, contacts.ErrUnknownFormat), errors.Is(err, contacts.ErrNoEmails),
+		errors.Is(err, http.ErrMissingFile):
+		return http.StatusBadRequest
+	}
+
+	return http.StatusInternalServerError
+}
+
+func contactsErrorText(err error) string {
+	switch {
+	case errors.Is(err, contacts.ErrFileTooLarge), errors.Is(err, contacts.ErrTooManyContacts):
+		return "Файл слишком большой"
+	case errors.Is(err, contacts.ErrNoEmails):
+		return "В файле не найдено ни одного email"
+	case errors.Is(err, http.ErrMissingFile):
+		return "Выберите файл с контактами"
+	}
+
+	return "Не удалось прочитать файл, загрузите vCard или CSV"
+}
@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/delonce/socialnetwork/internal/service/events"
+	"github.com/delonce/socialnetwork/internal/service/friends"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+func (handler *NetworkHandler) GetEventsPage(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	currentUser := handler.getCurrentUser(w, r)
+	eventView, err := events.NewEventViewer(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Error when creating event service, %v", err)
+		http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
+		return
+	}
+
+	templateMap := map[string]interface{}{
+		"Events": eventView.GetUserEvents(currentUser.Username),
+
+		"MaxTitleLength":       events.MaxTitleLength,
+		"MaxDescriptionLength": events.MaxDescriptionLength,
+		"MaxLocationLength":    events.MaxLocationLength,
+	}
+
+	EVENTS_TEMPLATE.Execute(w, templateMap)
+}
+
+func (handler *NetworkHandler) CreateEvent(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	currentUser := handler.getCurrentUser(w, r)
+	manager, err := events.NewEventManager(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Error when creating event manager, %v", err)
+		http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
+		return
+	}
+
+	location := formLocation(r)
+	startsAt, err := events.ParseEventTime(r.FormValue("startsat"), location)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Can't create event of %s, %v", currentUser.Username, err)
+		http.Redirect(w, r, EVENTS_URL, http.StatusSeeOther)
+		return
+	}
+
+	var endsAt *time.Time
+
+	if value := r.FormValue("endsat"); value != "" {
+		endTime, err := events.ParseEventTime(value, location)
+
+		if err != nil {
+			handler.HandlerLogger.Errorf("Can't create event of %s, %v", currentUser.Username, err)
+			http.Redirect(w, r, EVENTS_URL, http.StatusSeeOther)
+			return
+		}
+
+		endsAt = &endTime
+	}
+
+	eventID, err := manager.CreateEvent(currentUser.Username, r.FormValue("title"), r.FormValue("description"),
+		r.FormValue("location"), startsAt, endsAt)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Can't create event of %s, %v", currentUser.Username, err)
+		http.Redirect(w, r, EVENTS_URL, http.StatusSeeOther)
+		return
+	}
+
+	http.Redirect(w, r, path.Join(EVENTS_URL, eventID), http.StatusSeeOther)
+}
+
+func (handler *NetworkHandler) GetEventPage(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	currentUser := handler.getCurrentUser(w, r)
+	eventID := params.ByName(ID_URL_TEMPLATE)
+	eventView, err := events.NewEventViewer(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Error when creating event service, %v", err)
+		http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
+		return
+	}
+
+	eventPage, err := eventView.GetEvent(currentUser.Username, eventID)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Can't show event %s to %s, %v", eventID, currentUser.Username, err)
+		http.Redirect(w, r, EVENTS_URL, http.StatusSeeOther)
+		return
+	}
+
+	templateMap := map[string]interface{}{
+		"Event":   eventPage,
+		"Friends": []string{},
+	}
+
+	if eventPage.Event.IsCreator {
+		friendView, err := friends.NewFriendViewer(handler.HandlerLogger, handler.HandlerConfig)
+
+		if err != nil {
+			handler.HandlerLogger.Errorf("Error when creating friend service, %v", err)
+			http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
+			return
+		}
+
+		templateMap["Friends"] = friendView.GetUserFriends(currentUser.Username)
+	}
+
+	EVENT_TEMPLATE.Execute(w, templateMap)
+}
+
+func (handler *NetworkHandler) DeleteEvent(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	currentUser := handler.getCurrentUser(w, r)
+	manager, err := events.NewEventManager(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Error when creating event manager, %v", err)
+		http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
+		return
+	}
+
+	eventID := params.ByName(ID_URL_TEMPLATE)
+
+	if err = manager.DeleteEvent(currentUser.Username, eventID); err != nil {
+		handler.HandlerLogger.Errorf("Can't delete event %s, %v", eventID, err)
+	}
+
+	http.Redirect(w, r, EVENTS_URL, http.StatusSeeOther)
+}
+
+func (handler *NetworkHandler) AnswerEvent(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	handler.changeEvent(w, r, params, func(manager events.EventManager, username string, eventID string) error {
+		return manager.SetRSVP(username, eventID, r.FormValue("status"))
+	})
+}
+
+func (handler *NetworkHandler) InviteToEvent(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	handler.changeEvent(w, r, params, func(manager events.EventManager, username string, eventID string) error {
+		return manager.InviteFriend(username, eventID, r.FormValue("username"))
+	})
+}
+
+func (handler *NetworkHandler) DismissEventReminder(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	currentUser := handler.getCurrentUser(w, r)
+	manager, err := events.NewEventManager(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Error when creating event manager, %v", err)
+		http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
+		return
+	}
+
+	eventID := params.ByName(ID_URL_TEMPLATE)
+
+	if err = manager.DismissReminder(currentUser.Username, eventID); err != nil {
+		handler.HandlerLogger.Errorf("Can't dismiss reminder of event %s, %v", eventID, err)
+	}
+
+	http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
+}
+
+func (handler *NetworkHandler) ExportEvent(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	currentUser := handler.getCurrentUser(w, r)
+	eventID := params.ByName(ID_URL_TEMPLATE)
+	eventView, err := events.NewEventViewer(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Error when creating event service, %v", err)
+		http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
+		return
+	}
+
+	// the file is small, so it is built first to answer 404 for other events
+	calendar := bytes.Buffer{}
+
+	if err = eventView.ExportEvent(&calendar, currentUser.Username, eventID); err != nil {
+		handler.HandlerLogger.Errorf("Error when exporting event %s, %v", eventID, err)
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", events.ICS_CONTENT_TYPE)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "event_"+eventID+".ics"))
+	w.Write(calendar.Bytes())
+}
+
+func (handler *NetworkHandler) changeEvent(w http.ResponseWriter, r *http.Request, params httprouter.Params,
+	change func(events.EventManager, string, string) error) {
+
+	currentUser := handler.getCurrentUser(w, r)
+	manager, err := events.NewEventManager(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Error when creating event manager, %v", err)
+		http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
+		return
+	}
+
+	eventID := params.ByName(ID_URL_TEMPLATE)
+
+	if err = change(manager, currentUser.Username, eventID); err != nil {
+		handler.HandlerLogger.Errorf("Error when changing event %s by %s, %v", eventID, currentUser.Username, err)
+	}
+
+	http.Redirect(w, r, path.Join(EVENTS_URL, eventID), http.StatusSeeOther)
+}
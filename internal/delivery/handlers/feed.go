@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/delonce/socialnetwork/internal/service/feed"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+func (handler *NetworkHandler) GetFeedPage(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	currentUser := handler.getCurrentUser(w, r)
+	feedView, err := feed.NewFeedViewer(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Error when creating feed service, %v", err)
+		http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
+		return
+	}
+
+	feedPage, err := feedView.GetFeed(currentUser.Username, r.URL.Query().Get("cursor"))
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Can't get feed of %s, %v", currentUser.Username, err)
+		http.Redirect(w, r, FEED_URL, http.StatusSeeOther)
+		return
+	}
+
+	templateMap := map[string]interface{}{
+		"Feed":        feedPage,
+		"IsFirstPage": r.URL.Query().Get("cursor") == "",
+	}
+
+	FEED_TEMPLATE.Execute(w, templateMap)
+}
+
+func (handler *NetworkHandler) GetFeedAPI(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	currentUser := handler.getAPICurrentUser(w, r)
+
+	if currentUser == nil {
+		return
+	}
+
+	feedView, err := feed.NewFeedViewer(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Something wrong")
+		return
+	}
+
+	feedPage, err := feedView.GetFeed(currentUser.Username, r.URL.Query().Get("cursor"))
+
+	if errors.Is(err, feed.ErrWrongCursor) {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Something wrong")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, feedPage)
+}
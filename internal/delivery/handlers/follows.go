@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"net/http"
+	"path"
+
+	"github.com/delonce/socialnetwork/internal/service/access"
+	"github.com/delonce/socialnetwork/internal/service/follows"
+	"github.com/delonce/socialnetwork/internal/service/user"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+func (handler *NetworkHandler) FollowUser(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	manager, redirectUrl := newFollowManagerURLLink(w, r, *handler, params)
+
+	if manager == nil {
+		return
+	}
+
+	doFriendRequest(w, r, params, *handler, access.ActionFollow, redirectUrl, manager.Follow)
+}
+
+func (handler *NetworkHandler) UnfollowUser(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	manager, redirectUrl := newFollowManagerURLLink(w, r, *handler, params)
+
+	if manager == nil {
+		return
+	}
+
+	doFriendRequest(w, r, params, *handler, access.ActionChangeFriendship, redirectUrl, manager.Unfollow)
+}
+
+func (handler *NetworkHandler) ApproveFollower(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	manager, _ := newFollowManagerURLLink(w, r, *handler, params)
+
+	if manager == nil {
+		return
+	}
+
+	doFriendRequest(w, r, params, *handler, access.ActionChangeFriendship, FOLLOWER_REQUESTS_URL, manager.ApproveFollower)
+}
+
+func (handler *NetworkHandler) RejectFollower(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	manager, _ := newFollowManagerURLLink(w, r, *handler, params)
+
+	if manager == nil {
+		return
+	}
+
+	doFriendRequest(w, r, params, *handler, access.ActionChangeFriendship, FOLLOWER_REQUESTS_URL, manager.RejectFollower)
+}
+
+func (handler *NetworkHandler) GetFollowersPage(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	handler.getFollowListPage(w, r, params, "Подписчики", func(viewer follows.FollowViewer, username string) []string {
+		return viewer.GetFollowers(username)
+	})
+}
+
+func (handler *NetworkHandler) GetFollowingPage(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	handler.getFollowListPage(w, r, params, "Подписки", func(viewer follows.FollowViewer, username string) []string {
+		return viewer.GetFollowing(username)
+	})
+}
+
+func (handler *NetworkHandler) GetFollowerRequestsPage(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	currentUser := handler.getCurrentUser(w, r)
+	followView, err := follows.NewFollowViewer(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Error when creating follow service, %v", err)
+		return
+	}
+
+	templateMap := map[string]interface{}{
+		"FollowRequests": followView.GetPendingFollowers(currentUser.Username),
+		"IsPrivate":      currentUser.IsPrivate,
+	}
+
+	FOLLOWER_REQUESTS_TEMPLATE.Execute(w, templateMap)
+}
+
+func (handler *NetworkHandler) SetAccountPrivacy(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	currentUser := handler.getCurrentUser(w, r)
+	authService, err := user.NewAuthService(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Can't create auth service, %v", err)
+		http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
+		return
+	}
+
+	isPrivate := r.FormValue("private") == "on"
+
+	if err = authService.SetAccountPrivacy(currentUser.ID, isPrivate); err != nil {
+		handler.HandlerLogger.Errorf("Can't change account privacy, %v", err)
+	}
+
+	http.Redirect(w, r, FOLLOWER_REQUESTS_URL, http.StatusSeeOther)
+}
+
+func (handler *NetworkHandler) getFollowListPage(w http.ResponseWriter, r *http.Request, params httprouter.Params,
+	title string, getUsers func(follows.FollowViewer, string) []string) {
+
+	followView, err := follows.NewFollowViewer(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Error when creating follow service, %v", err)
+		http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
+		return
+	}
+
+	username := params.ByName(USERNAME_URL_TEMPLATE)
+	currentUser := handler.getCurrentUser(w, r)
+
+	if !handler.authorize(w, r, currentUser.Username, access.ActionViewProfile, username) {
+		return
+	}
+
+	templateMap := map[string]interface{}{
+		"Title":    title,
+		"Username": username,
+		"Users":    getUsers(followView, username),
+	}
+
+	FOLLOWS_TEMPLATE.Execute(w, templateMap)
+}
+
+func newFollowManagerURLLink(w http.ResponseWriter, r *http.Request, handler NetworkHandler, params httprouter.Params) (follows.FollowManager, string) {
+	manager, err := follows.NewFollowManager(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Error when creating follow manager, %v", err)
+		http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
+		return nil, ""
+	}
+
+	username := params.ByName(USERNAME_URL_TEMPLATE)
+	redirectUrl := path.Join(USERS_URL, username)
+
+	return manager, redirectUrl
+}
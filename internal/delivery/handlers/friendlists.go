@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/delonce/socialnetwork/internal/service/friendlists"
+	"github.com/delonce/socialnetwork/internal/service/friends"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+func (handler *NetworkHandler) GetFriendListsPage(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	currentUser := handler.getCurrentUser(w, r)
+	listView, err := friendlists.NewFriendListViewer(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Error when creating friend list service, %v", err)
+		http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
+		return
+	}
+
+	friendView, err := friends.NewFriendViewer(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Error when creating friend service, %v", err)
+		http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
+		return
+	}
+
+	templateMap := map[string]interface{}{
+		"Lists":   listView.GetLists(currentUser.Username),
+		"Friends": friendView.GetUserFriends(currentUser.Username),
+	}
+
+	FRIEND_LISTS_TEMPLATE.Execute(w, templateMap)
+}
+
+func (handler *NetworkHandler) CreateFriendList(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	handler.changeFriendList(w, r, params, func(manager friendlists.FriendListManager, owner string, listID string) error {
+		_, err := manager.CreateList(owner, r.FormValue("name"))
+		return err
+	})
+}
+
+func (handler *NetworkHandler) RenameFriendList(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	handler.changeFriendList(w, r, params, func(manager friendlists.FriendListManager, owner string, listID string) error {
+		return manager.RenameList(owner, listID, r.FormValue("name"))
+	})
+}
+
+func (handler *NetworkHandler) DeleteFriendList(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	handler.changeFriendList(w, r, params, func(manager friendlists.FriendListManager, owner string, listID string) error {
+		return manager.DeleteList(owner, listID)
+	})
+}
+
+func (handler *NetworkHandler) AddFriendToList(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	handler.changeFriendList(w, r, params, func(manager friendlists.FriendListManager, owner string, listID string) error {
+		return manager.AddToList(owner, listID, r.FormValue("username"))
+	})
+}
+
+func (handler *NetworkHandler) RemoveFriendFromList(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	handler.changeFriendList(w, r, params, func(manager friendlists.FriendListManager, owner string, listID string) error {
+		return manager.RemoveFromList(owner, listID, params.ByName(USERNAME_URL_TEMPLATE))
+	})
+}
+
+func (handler *NetworkHandler) GetVisibilityPage(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	currentUser := handler.getCurrentUser(w, r)
+	listView, err := friendlists.NewFriendListViewer(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Error when creating friend list service, %v", err)
+		http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
+		return
+	}
+
+	settings := listView.GetVisibility(currentUser.Username)
+
+	templateMap := map[string]interface{}{
+		"Username": currentUser.Username,
+		"Lists":    listView.GetLists(currentUser.Username),
+		"Fields": []map[string]string{
+			{"Name": friendlists.VisibilityEmail, "Title": "Email", "Current": settings.Email},
+			{"Name": friendlists.VisibilityFriends, "Title": "Список друзей", "Current": settings.Friends},
+		},
+	}
+
+	VISIBILITY_TEMPLATE.Execute(w, templateMap)
+}
+
+func (handler *NetworkHandler) SetVisibility(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	currentUser := handler.getCurrentUser(w, r)
+	manager, err := friendlists.NewFriendListManager(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Error when creating friend list manager, %v", err)
+		http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
+		return
+	}
+
+	for _, field := range []string{friendlists.VisibilityEmail, friendlists.VisibilityFriends} {
+		audience := r.FormValue(field)
+
+		if audience == "" {
+			continue
+		}
+
+		if err = manager.SetVisibility(currentUser.Username, field, audience); err != nil {
+			handler.HandlerLogger.Errorf("Error when changing visibility of %s, %v", field, err)
+		}
+	}
+
+	http.Redirect(w, r, VISIBILITY_URL, http.StatusSeeOther)
+}
+
+func (handler *NetworkHandler) changeFriendList(w http.ResponseWriter, r *http.Request, params httprouter.Params,
+	change func(friendlists.FriendListManager, string, string) error) {
+
+	currentUser := handler.getCurrentUser(w, r)
+	manager, err := friendlists.NewFriendListManager(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Error when creating friend list manager, %v", err)
+		http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
+		return
+	}
+
+	listID := params.ByName(ID_URL_TEMPLATE)
+
+	if err = change(manager, currentUser.Username, listID); err != nil {
+		handler.HandlerLogger.Errorf("Error when changing friend list %s, %v", listID, err)
+	}
+
+	http.Redirect(w, r, FRIEND_LISTS_URL, http.StatusSeeOther)
+}
@@ -1,10 +1,14 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"path"
 
+	"github.com/delonce/socialnetwork/internal/service/access"
+	"github.com/delonce/socialnetwork/internal/service/friendlists"
 	"github.com/delonce/socialnetwork/internal/service/friends"
+	"github.com/delonce/socialnetwork/internal/service/user"
 
 	"github.com/julienschmidt/httprouter"
 )
@@ -14,7 +18,7 @@ func (handler *NetworkHandler) GetFriendRequestsPage(w http.ResponseWriter, r *h
 	friendView, err := friends.NewFriendViewer(handler.HandlerLogger, handler.HandlerConfig)
 
 	if err != nil {
-		handler.HandlerLogger.Error("Error when creating friend service, %v", err)
+		handler.HandlerLogger.Errorf("Error when creating friend service, %v", err)
 		return
 	}
 
@@ -27,19 +31,65 @@ func (handler *NetworkHandler) GetFriendRequestsPage(w http.ResponseWriter, r *h
 	FRIEND_REQUESTS_TEMPLATE.Execute(w, templateMap)
 }
 
+func (handler *NetworkHandler) GetOutgoingRequestsPage(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	currentUser := handler.getCurrentUser(w, r)
+	friendView, err := friends.NewFriendViewer(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Error when creating friend service, %v", err)
+		return
+	}
+
+	templateMap := map[string]interface{}{
+		"OutgoingRequests": friendView.GetOutgoingRequests(currentUser.Username),
+		"MaxRequests":      handler.HandlerConfig.Friends.MaxOutgoingRequests,
+	}
+
+	OUTGOING_REQUESTS_TEMPLATE.Execute(w, templateMap)
+}
+
 func (handler *NetworkHandler) GetMyFriends(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
 	currentUser := handler.getCurrentUser(w, r)
 	friendView, err := friends.NewFriendViewer(handler.HandlerLogger, handler.HandlerConfig)
 
 	if err != nil {
-		handler.HandlerLogger.Error("Error when creating friend service, %v", err)
+		handler.HandlerLogger.Errorf("Error when creating friend service, %v", err)
+		return
+	}
+
+	listView, err := friendlists.NewFriendListViewer(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Error when creating friend list service, %v", err)
 		return
 	}
 
 	userFriends := friendView.GetUserFriends(currentUser.Username)
+	listID := r.URL.Query().Get("list")
+
+	if listID != "" {
+		list, err := listView.GetList(currentUser.Username, listID)
+
+		if err != nil {
+			http.Redirect(w, r, MY_FRIENDS_URL, http.StatusSeeOther)
+			return
+		}
+
+		userFriends = list.Members
+	}
+
+	directory, err := handler.getUserDirectory(r)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Error when creating user directory, %v", err)
+		return
+	}
 
 	templateMap := map[string]interface{}{
-		"Friends": userFriends,
+		"Friends":      userFriends,
+		"Cards":        directory.GetCards(userFriends),
+		"Lists":        listView.GetLists(currentUser.Username),
+		"SelectedList": listID,
 	}
 
 	FRIENDS_TEMPLATE.Execute(w, templateMap)
@@ -48,38 +98,197 @@ func (handler *NetworkHandler) GetMyFriends(w http.ResponseWriter, r *http.Reque
 func (handler *NetworkHandler) SendFriendRequest(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
 	manager, redirectUrl := newManagerURLLink(w, r, *handler, params)
 
-	doFriendRequest(w, r, params, *handler, redirectUrl, manager.SendFriendRequest)
+	doFriendRequest(w, r, params, *handler, access.ActionSendFriendRequest, redirectUrl, manager.SendFriendRequest)
+}
+
+func (handler *NetworkHandler) SendFriendRequestWithMessage(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	manager, redirectUrl := newManagerURLLink(w, r, *handler, params)
+	message := r.FormValue("message")
+
+	doFriendRequest(w, r, params, *handler, access.ActionSendFriendRequest, redirectUrl, func(from string, to string) error {
+		return manager.SendFriendRequestWithMessage(from, to, message)
+	})
 }
 
 func (handler *NetworkHandler) CancelFriendRequest(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
 	manager, redirectUrl := newManagerURLLink(w, r, *handler, params)
 
-	doFriendRequest(w, r, params, *handler, redirectUrl, manager.CancelRequest)
+	doFriendRequest(w, r, params, *handler, access.ActionChangeFriendship, redirectUrl, manager.CancelRequest)
 }
 
 func (handler *NetworkHandler) RejectFriendRequest(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
 	manager, redirectUrl := newManagerURLLink(w, r, *handler, params)
 
-	doFriendRequest(w, r, params, *handler, redirectUrl, manager.RejectRequest)
+	doFriendRequest(w, r, params, *handler, access.ActionChangeFriendship, redirectUrl, manager.RejectRequest)
 }
 
 func (handler *NetworkHandler) AcceptFriend(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
 	manager, redirectUrl := newManagerURLLink(w, r, *handler, params)
 
-	doFriendRequest(w, r, params, *handler, redirectUrl, manager.AcceptNewFriend)
+	doFriendRequest(w, r, params, *handler, access.ActionChangeFriendship, redirectUrl, manager.AcceptNewFriend)
 }
 
 func (handler *NetworkHandler) DenyFriend(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
 	manager, redirectUrl := newManagerURLLink(w, r, *handler, params)
 
-	doFriendRequest(w, r, params, *handler, redirectUrl, manager.DeleteFriend)
+	doFriendRequest(w, r, params, *handler, access.ActionChangeFriendship, redirectUrl, manager.DeleteFriend)
+}
+
+func (handler *NetworkHandler) BlockUser(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	manager, redirectUrl := newManagerURLLink(w, r, *handler, params)
+
+	doFriendRequest(w, r, params, *handler, access.ActionChangeFriendship, redirectUrl, manager.BlockUser)
+}
+
+func (handler *NetworkHandler) UnblockUser(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	manager, redirectUrl := newManagerURLLink(w, r, *handler, params)
+
+	doFriendRequest(w, r, params, *handler, access.ActionChangeFriendship, redirectUrl, manager.UnblockUser)
+}
+
+func (handler *NetworkHandler) GetBlockedUsersPage(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	currentUser := handler.getCurrentUser(w, r)
+	friendView, err := friends.NewFriendViewer(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Error when creating friend service, %v", err)
+		return
+	}
+
+	templateMap := map[string]interface{}{
+		"BlockedUsers": friendView.GetBlockedUsers(currentUser.Username),
+	}
+
+	BLOCKED_USERS_TEMPLATE.Execute(w, templateMap)
+}
+
+func (handler *NetworkHandler) GetBlockedUsersAPI(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	currentUser := handler.getAPICurrentUser(w, r)
+
+	if currentUser == nil {
+		return
+	}
+
+	friendView, err := friends.NewFriendViewer(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Error when creating friend service, %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "Something wrong")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, friendView.GetBlockedUsers(currentUser.Username))
+}
+
+func (handler *NetworkHandler) GetOutgoingRequestsAPI(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	currentUser := handler.getAPICurrentUser(w, r)
+
+	if currentUser == nil {
+		return
+	}
+
+	friendView, err := friends.NewFriendViewer(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Error when creating friend service, %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "Something wrong")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, friendView.GetOutgoingRequests(currentUser.Username))
+}
+
+func (handler *NetworkHandler) SendFriendRequestAPI(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	message := r.FormValue("message")
+
+	handler.doFriendAPIRequest(w, r, params, access.ActionSendFriendRequest, func(manager friends.FriendManager) func(string, string) error {
+		return func(from string, to string) error {
+			return manager.SendFriendRequestWithMessage(from, to, message)
+		}
+	})
+}
+
+func (handler *NetworkHandler) CancelFriendRequestAPI(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	handler.doFriendAPIRequest(w, r, params, access.ActionChangeFriendship, func(manager friends.FriendManager) func(string, string) error {
+		return manager.CancelRequest
+	})
+}
+
+func (handler *NetworkHandler) BlockUserAPI(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	handler.doFriendAPIRequest(w, r, params, access.ActionChangeFriendship, func(manager friends.FriendManager) func(string, string) error {
+		return manager.BlockUser
+	})
+}
+
+func (handler *NetworkHandler) UnblockUserAPI(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	handler.doFriendAPIRequest(w, r, params, access.ActionChangeFriendship, func(manager friends.FriendManager) func(string, string) error {
+		return manager.UnblockUser
+	})
+}
+
+func (handler *NetworkHandler) doFriendAPIRequest(w http.ResponseWriter, r *http.Request, params httprouter.Params,
+	action string, getReqFunc func(friends.FriendManager) func(string, string) error) {
+
+	currentUser := handler.getAPICurrentUser(w, r)
+
+	if currentUser == nil {
+		return
+	}
+
+	manager, err := friends.NewFriendManager(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Error when creating friend manager, %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "Something wrong")
+		return
+	}
+
+	otherUsername := params.ByName(USERNAME_URL_TEMPLATE)
+
+	if !handler.authorizeAPI(w, currentUser.Username, action, otherUsername) {
+		return
+	}
+
+	err = getReqFunc(manager)(currentUser.Username, otherUsername)
+
+	if err != nil {
+		writeJSONError(w, friendErrorStatus(err), err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func friendErrorStatus(err error) int {
+	var transitionErr *friends.TransitionError
+
+	switch {
+	case errors.Is(err, friends.ErrRequestNotFound), errors.Is(err, friends.ErrBlockNotFound),
+		errors.Is(err, user.ErrUserNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, friends.ErrBlocked), errors.Is(err, friends.ErrNotRequestSender),
+		errors.Is(err, friends.ErrNotRequestTarget):
+		return http.StatusForbidden
+	case errors.Is(err, friends.ErrSelfRequest), errors.Is(err, friends.ErrSelfBlock),
+		errors.Is(err, friends.ErrLongMessage):
+		return http.StatusBadRequest
+	case errors.Is(err, friends.ErrTooManyRequests):
+		return http.StatusTooManyRequests
+	case errors.As(err, &transitionErr), errors.Is(err, friends.ErrRequestExists),
+		errors.Is(err, friends.ErrIncomingRequest), errors.Is(err, friends.ErrAlreadyFriends),
+		errors.Is(err, friends.ErrNotFriends), errors.Is(err, friends.ErrAlreadyBlocked),
+		errors.Is(err, friends.ErrConcurrentChange):
+		return http.StatusConflict
+	}
+
+	return http.StatusInternalServerError
 }
 
 func newManagerURLLink(w http.ResponseWriter, r *http.Request, handler NetworkHandler, params httprouter.Params) (friends.FriendManager, string) {
 	manager, err := friends.NewFriendManager(handler.HandlerLogger, handler.HandlerConfig)
 
 	if err != nil {
-		handler.HandlerLogger.Error("Error when creating friend manager, %v", err)
+		handler.HandlerLogger.Errorf("Error when creating friend manager, %v", err)
 		http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
 		return nil, ""
 	}
@@ -91,20 +300,19 @@ func newManagerURLLink(w http.ResponseWriter, r *http.Request, handler NetworkHa
 }
 
 func doFriendRequest(w http.ResponseWriter, r *http.Request, params httprouter.Params, handler NetworkHandler,
-	nextUrl string, reqFunc func(string, string) error) {
+	action string, nextUrl string, reqFunc func(string, string) error) {
 
 	user := handler.getCurrentUser(w, r)
 	friendUsername := params.ByName(USERNAME_URL_TEMPLATE)
 
-	if user.Username == friendUsername {
-		http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
+	if !handler.authorize(w, r, user.Username, action, friendUsername) {
 		return
 	}
 
 	err := reqFunc(user.Username, friendUsername)
 
 	if err != nil {
-		handler.HandlerLogger.Error("Error when trying send friend request, %v", err)
+		handler.HandlerLogger.Errorf("Error when trying send friend request, %v", err)
 		http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
 		return
 	}
@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"net/http"
+	"path"
+	"strconv"
+
+	"github.com/delonce/socialnetwork/internal/service/groups"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+func (handler *NetworkHandler) GetGroupsPage(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	currentUser := handler.getCurrentUser(w, r)
+	groupView, err := groups.NewGroupViewer(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Error when creating group service, %v", err)
+		http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
+		return
+	}
+
+	query := r.URL.Query().Get("q")
+
+	templateMap := map[string]interface{}{
+		"MyGroups":    groupView.GetUserGroups(currentUser.Username),
+		"FoundGroups": groupView.FindGroups(currentUser.Username, query),
+		"Query":       query,
+
+		"MaxNameLength":        groups.MaxNameLength,
+		"MaxDescriptionLength": groups.MaxDescriptionLength,
+	}
+
+	GROUPS_TEMPLATE.Execute(w, templateMap)
+}
+
+func (handler *NetworkHandler) CreateGroup(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	currentUser := handler.getCurrentUser(w, r)
+	manager, err := groups.NewGroupManager(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Error when creating group manager, %v", err)
+		http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
+		return
+	}
+
+	groupID, err := manager.CreateGroup(currentUser.Username, r.FormValue("name"),
+		r.FormValue("description"), r.FormValue("join"))
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Can't create group of %s, %v", currentUser.Username, err)
+		http.Redirect(w, r, GROUPS_URL, http.StatusSeeOther)
+		return
+	}
+
+	http.Redirect(w, r, path.Join(GROUPS_URL, groupID), http.StatusSeeOther)
+}
+
+func (handler *NetworkHandler) GetGroupPage(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	currentUser := handler.getCurrentUser(w, r)
+	groupID := params.ByName(ID_URL_TEMPLATE)
+	groupView, err := groups.NewGroupViewer(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Error when creating group service, %v", err)
+		http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
+		return
+	}
+
+	page, _ := strconv.ParseInt(r.URL.Query().Get("page"), 10, 64)
+	groupPage, err := groupView.GetGroup(currentUser.Username, groupID, page)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Can't show group %s to %s, %v", groupID, currentUser.Username, err)
+		http.Redirect(w, r, GROUPS_URL, http.StatusSeeOther)
+		return
+	}
+
+	templateMap := map[string]interface{}{
+		"Group":       groupPage,
+		"CurrentUser": currentUser.Username,
+
+		"MaxNameLength":        groups.MaxNameLength,
+		"MaxDescriptionLength": groups.MaxDescriptionLength,
+		"MaxPostLength":        groups.MaxPostLength,
+	}
+
+	GROUP_TEMPLATE.Execute(w, templateMap)
+}
+
+func (handler *NetworkHandler) EditGroup(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	handler.changeGroup(w, r, params, func(manager groups.GroupManager, username string, groupID string) error {
+		return manager.UpdateGroup(username, groupID, r.FormValue("name"), r.FormValue("description"), r.FormValue("join"))
+	})
+}
+
+func (handler *NetworkHandler) DeleteGroup(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	currentUser := handler.getCurrentUser(w, r)
+	manager, err := groups.NewGroupManager(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Error when creating group manager, %v", err)
+		http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
+		return
+	}
+
+	groupID := params.ByName(ID_URL_TEMPLATE)
+
+	if err = manager.DeleteGroup(currentUser.Username, groupID); err != nil {
+		handler.HandlerLogger.Errorf("Can't delete group %s, %v", groupID, err)
+		http.Redirect(w, r, path.Join(GROUPS_URL, groupID), http.StatusSeeOther)
+		return
+	}
+
+	http.Redirect(w, r, GROUPS_URL, http.StatusSeeOther)
+}
+
+func (handler *NetworkHandler) JoinGroup(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	handler.changeGroup(w, r, params, func(manager groups.GroupManager, username string, groupID string) error {
+		return manager.JoinGroup(username, groupID)
+	})
+}
+
+func (handler *NetworkHandler) LeaveGroup(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	handler.changeGroup(w, r, params, func(manager groups.GroupManager, username string, groupID string) error {
+		return manager.LeaveGroup(username, groupID)
+	})
+}
+
+func (handler *NetworkHandler) AddGroupPost(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	handler.changeGroup(w, r, params, func(manager groups.GroupManager, username string, groupID string) error {
+		_, err := manager.AddPost(username, groupID, r.FormValue("text"))
+		return err
+	})
+}
+
+func (handler *NetworkHandler) DeleteGroupPost(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	handler.changeGroup(w, r, params, func(manager groups.GroupManager, username string, groupID string) error {
+		return manager.DeletePost(username, groupID, r.FormValue("post"))
+	})
+}
+
+func (handler *NetworkHandler) ApproveGroupMember(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	handler.changeGroup(w, r, params, func(manager groups.GroupManager, username string, groupID string) error {
+		return manager.ApproveRequest(username, groupID, params.ByName(USERNAME_URL_TEMPLATE))
+	})
+}
+
+func (handler *NetworkHandler) RejectGroupMember(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	handler.changeGroup(w, r, params, func(manager groups.GroupManager, username string, groupID string) error {
+		return manager.RejectRequest(username, groupID, params.ByName(USERNAME_URL_TEMPLATE))
+	})
+}
+
+func (handler *NetworkHandler) RemoveGroupMember(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	handler.changeGroup(w, r, params, func(manager groups.GroupManager, username string, groupID string) error {
+		return manager.RemoveMember(username, groupID, params.ByName(USERNAME_URL_TEMPLATE))
+	})
+}
+
+func (handler *NetworkHandler) SetGroupMemberRole(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	handler.changeGroup(w, r, params, func(manager groups.GroupManager, username string, groupID string) error {
+		return manager.SetMemberRole(username, groupID, params.ByName(USERNAME_URL_TEMPLATE), r.FormValue("role"))
+	})
+}
+
+// changeGroup runs the change as the current user and returns to the group
+// page, the group service itself checks the rights of the user
+func (handler *NetworkHandler) changeGroup(w http.ResponseWriter, r *http.Request, params httprouter.Params,
+	change func(groups.GroupManager, string, string) error) {
+
+	currentUser := handler.getCurrentUser(w, r)
+	manager, err := groups.NewGroupManager(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Error when creating group manager, %v", err)
+		http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
+		return
+	}
+
+	groupID := params.ByName(ID_URL_TEMPLATE)
+
+	if err = change(manager, currentUser.Username, groupID); err != nil {
+		handler.HandlerLogger.Errorf("Error when changing group %s by %s, %v", groupID, currentUser.Username, err)
+	}
+
+	http.Redirect(w, r, path.Join(GROUPS_URL, groupID), http.StatusSeeOther)
+}
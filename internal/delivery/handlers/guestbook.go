@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"net/http"
+	"path"
+
+	"github.com/delonce/socialnetwork/internal/service/guestbook"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+func (handler *NetworkHandler) AddGuestbookEntry(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	currentUser := handler.getCurrentUser(w, r)
+	owner := params.ByName(USERNAME_URL_TEMPLATE)
+	manager, err := guestbook.NewGuestbookManager(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Error when creating guestbook manager, %v", err)
+		http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
+		return
+	}
+
+	if _, err = manager.AddEntry(owner, currentUser.Username, r.FormValue("text")); err != nil {
+		handler.HandlerLogger.Errorf("Can't add guestbook entry of %s to %s, %v", currentUser.Username, owner, err)
+	}
+
+	http.Redirect(w, r, guestbookRedirectURL(owner, currentUser.Username), http.StatusSeeOther)
+}
+
+func (handler *NetworkHandler) DeleteGuestbookEntry(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	currentUser := handler.getCurrentUser(w, r)
+	manager, err := guestbook.NewGuestbookManager(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Error when creating guestbook manager, %v", err)
+		http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
+		return
+	}
+
+	entryID := params.ByName(ID_URL_TEMPLATE)
+
+	// only the owner manages the guestbook, so the entry is looked up in the
+	// guestbook of the current user whatever owner is in the url
+	if err = manager.DeleteEntry(currentUser.Username, entryID); err != nil {
+		handler.HandlerLogger.Errorf("Can't delete guestbook entry %s, %v", entryID, err)
+	}
+
+	http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
+}
+
+func (handler *NetworkHandler) HideGuestbookEntry(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	handler.setGuestbookEntryHidden(w, r, params, true)
+}
+
+func (handler *NetworkHandler) UnhideGuestbookEntry(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	handler.setGuestbookEntryHidden(w, r, params, false)
+}
+
+func (handler *NetworkHandler) LikeGuestbookEntry(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	currentUser := handler.getCurrentUser(w, r)
+	owner := params.ByName(USERNAME_URL_TEMPLATE)
+	manager, err := guestbook.NewGuestbookManager(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Error when creating guestbook manager, %v", err)
+		http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
+		return
+	}
+
+	entryID := params.ByName(ID_URL_TEMPLATE)
+
+	if err = manager.ToggleLike(currentUser.Username, owner, entryID); err != nil {
+		handler.HandlerLogger.Errorf("Can't like guestbook entry %s, %v", entryID, err)
+	}
+
+	http.Redirect(w, r, guestbookRedirectURL(owner, currentUser.Username), http.StatusSeeOther)
+}
+
+func (handler *NetworkHandler) setGuestbookEntryHidden(w http.ResponseWriter, r *http.Request,
+	params httprouter.Params, isHidden bool) {
+
+	currentUser := handler.getCurrentUser(w, r)
+	manager, err := guestbook.NewGuestbookManager(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Error when creating guestbook manager, %v", err)
+		http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
+		return
+	}
+
+	entryID := params.ByName(ID_URL_TEMPLATE)
+
+	if err = manager.SetEntryHidden(currentUser.Username, entryID, isHidden); err != nil {
+		handler.HandlerLogger.Errorf("Can't change visibility of guestbook entry %s, %v", entryID, err)
+	}
+
+	http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
+}
+
+func guestbookRedirectURL(owner string, username string) string {
+	if owner == username {
+		return HOME_URL
+	}
+
+	return path.Join(USERS_URL, owner)
+}
@@ -2,10 +2,17 @@ package handlers
 
 import (
 	"net/http"
+	"strconv"
 
+	"github.com/delonce/socialnetwork/internal/service/access"
+	"github.com/delonce/socialnetwork/internal/service/events"
+	"github.com/delonce/socialnetwork/internal/service/follows"
+	"github.com/delonce/socialnetwork/internal/service/friendlists"
 	"github.com/delonce/socialnetwork/internal/service/friends"
+	"github.com/delonce/socialnetwork/internal/service/guestbook"
 	"github.com/delonce/socialnetwork/internal/service/messages"
-	"github.com/delonce/socialnetwork/internal/service/user"
+	"github.com/delonce/socialnetwork/internal/service/posts"
+	"github.com/delonce/socialnetwork/internal/service/stories"
 
 	"github.com/julienschmidt/httprouter"
 )
@@ -16,26 +23,84 @@ func (handler *NetworkHandler) GetHomePage(w http.ResponseWriter, r *http.Reques
 	friendView, err := friends.NewFriendViewer(handler.HandlerLogger, handler.HandlerConfig)
 
 	if err != nil {
-		handler.HandlerLogger.Error("Error when creating friend service, %v", err)
+		handler.HandlerLogger.Errorf("Error when creating friend service, %v", err)
 		return
 	}
 
 	msgView, err := messages.NewMessageViewer(handler.HandlerLogger, handler.HandlerConfig)
 
 	if err != nil {
-		handler.HandlerLogger.Error("Error when creating message service, %v", err)
+		handler.HandlerLogger.Errorf("Error when creating message service, %v", err)
 		return
 	}
 
+	followView, err := follows.NewFollowViewer(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Error when creating follow service, %v", err)
+		return
+	}
+
+	postView, err := posts.NewPostViewer(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Error when creating post service, %v", err)
+		return
+	}
+
+	guestbookView, err := guestbook.NewGuestbookViewer(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Error when creating guestbook service, %v", err)
+		return
+	}
+
+	storyView, err := stories.NewStoryViewer(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Error when creating story service, %v", err)
+		return
+	}
+
+	eventView, err := events.NewEventViewer(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Error when creating event service, %v", err)
+		return
+	}
+
+	listView, err := friendlists.NewFriendListViewer(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Error when creating friend list service, %v", err)
+		return
+	}
+
+	page, _ := strconv.ParseInt(r.URL.Query().Get("page"), 10, 64)
+	guestbookPage, _ := strconv.ParseInt(r.URL.Query().Get("gbpage"), 10, 64)
 	otherUsers := friendView.GetAllProbablyFriends(currentUser.Username)
 	friendRequests := friendView.GetAllFriendRequests(currentUser.Username)
 	msgAmount := msgView.CountNewMessages(currentUser.Username)
 
 	templateMap := map[string]interface{}{
 		"CurrentUser":       currentUser,
+		"Profile":           listView.GetProfile(currentUser, currentUser.Username),
 		"AllUsers":          otherUsers,
 		"FriendRequests":    friendRequests,
 		"AmountNewMessages": msgAmount,
+		"AmountFriends":     friendView.CountFriends(currentUser.Username),
+		"AmountFollowers":   followView.CountFollowers(currentUser.Username),
+		"AmountFollowing":   followView.CountFollowing(currentUser.Username),
+		"FollowRequests":    followView.GetPendingFollowers(currentUser.Username),
+		"Wall":              postView.GetWall(currentUser.Username, page),
+		"MaxPostLength":     posts.MaxPostLength,
+		"Guestbook":         guestbookView.GetGuestbook(currentUser.Username, currentUser.Username, guestbookPage),
+		"MaxEntryLength":    guestbook.MaxEntryLength,
+		"Stories":           storyView.GetStoryStrip(currentUser.Username),
+		"MaxStoryLength":    stories.MaxTextLength,
+		"Reminders":         eventView.GetReminders(currentUser.Username),
+		"ShowEmail":         true,
+		"ShowFriends":       true,
 		"IsCurrentUser":     true,
 	}
 
@@ -44,10 +109,10 @@ func (handler *NetworkHandler) GetHomePage(w http.ResponseWriter, r *http.Reques
 
 func (handler *NetworkHandler) GetOtherPage(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
 	username := params.ByName(USERNAME_URL_TEMPLATE)
-	authService, err := user.NewAuthService(handler.HandlerLogger, handler.HandlerConfig)
+	directory, err := handler.getUserDirectory(r)
 
 	if err != nil {
-		handler.HandlerLogger.Error("Can't create auth service, %v", err)
+		handler.HandlerLogger.Errorf("Can't create user directory, %v", err)
 		http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
 		return
 	}
@@ -55,15 +120,47 @@ func (handler *NetworkHandler) GetOtherPage(w http.ResponseWriter, r *http.Reque
 	friendView, err := friends.NewFriendViewer(handler.HandlerLogger, handler.HandlerConfig)
 
 	if err != nil {
-		handler.HandlerLogger.Error("Can't create friend view service, %v", err)
+		handler.HandlerLogger.Errorf("Can't create friend view service, %v", err)
+		http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
+		return
+	}
+
+	followView, err := follows.NewFollowViewer(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Can't create follow view service, %v", err)
 		http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
 		return
 	}
 
-	otherUser, err := authService.GetUserByName(username)
+	listView, err := friendlists.NewFriendListViewer(handler.HandlerLogger, handler.HandlerConfig)
 
 	if err != nil {
-		handler.HandlerLogger.Error("Can't find user, %v", err)
+		handler.HandlerLogger.Errorf("Can't create friend list service, %v", err)
+		http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
+		return
+	}
+
+	postView, err := posts.NewPostViewer(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Can't create post view service, %v", err)
+		http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
+		return
+	}
+
+	guestbookView, err := guestbook.NewGuestbookViewer(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Can't create guestbook view service, %v", err)
+		http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
+		return
+	}
+
+	otherUser, err := directory.GetUserByName(username)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Can't find user, %v", err)
 		http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
 		return
 	}
@@ -75,11 +172,33 @@ func (handler *NetworkHandler) GetOtherPage(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	if !handler.authorize(w, r, currentUser.Username, access.ActionViewProfile, otherUser.Username) {
+		return
+	}
+
+	visibility := listView.GetVisibility(otherUser.Username)
+	page, _ := strconv.ParseInt(r.URL.Query().Get("page"), 10, 64)
+	guestbookPage, _ := strconv.ParseInt(r.URL.Query().Get("gbpage"), 10, 64)
+
 	templateMap := map[string]interface{}{
 		"CurrentUser":          otherUser,
+		"Profile":              listView.GetProfile(otherUser, currentUser.Username),
+		"ShowEmail":            listView.CanView(otherUser.Username, currentUser.Username, visibility.Email),
+		"ShowFriends":          listView.CanView(otherUser.Username, currentUser.Username, visibility.Friends),
+		"IsBlocked":            friendView.CheckBlock(currentUser.Username, otherUser.Username),
+		"AmountFriends":        friendView.CountFriends(otherUser.Username),
+		"AmountFollowers":      followView.CountFollowers(otherUser.Username),
+		"AmountFollowing":      followView.CountFollowing(otherUser.Username),
+		"IsFollowing":          followView.IsFollowing(currentUser.Username, otherUser.Username),
+		"IsFollowPending":      followView.IsFollowPending(currentUser.Username, otherUser.Username),
+		"MutualFriends":        friendView.GetMutualFriends(currentUser.Username, otherUser.Username),
+		"SeparationDegree":     friendView.GetSeparationDegree(currentUser.Username, otherUser.Username),
 		"IsFriends":            friendView.CheckFriend(currentUser.Username, otherUser.Username),
 		"IsReqToPersonExist":   friendView.CheckRequest(currentUser.Username, otherUser.Username),
 		"IsReqFromPersonExist": friendView.CheckRequest(otherUser.Username, currentUser.Username),
+		"Wall":                 postView.GetWall(otherUser.Username, page),
+		"Guestbook":            guestbookView.GetGuestbook(otherUser.Username, currentUser.Username, guestbookPage),
+		"MaxEntryLength":       guestbook.MaxEntryLength,
 	}
 
 	HOMEPAGE_TEMPLATE.Execute(w, templateMap)
@@ -1,10 +1,15 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
+	"net/url"
 	"path"
+	"strconv"
+	"strings"
+	"time"
 
-	"github.com/delonce/socialnetwork/internal/service/friends"
+	"github.com/delonce/socialnetwork/internal/service/access"
 	"github.com/delonce/socialnetwork/internal/service/messages"
 	"github.com/julienschmidt/httprouter"
 )
@@ -13,7 +18,9 @@ func (handler *NetworkHandler) GetMessagePage(w http.ResponseWriter, r *http.Req
 	currentUser := handler.getCurrentUser(w, r)
 	friendUsername := params.ByName(USERNAME_URL_TEMPLATE)
 
-	handler.checkConnection(w, r, currentUser.Username, friendUsername)
+	if !handler.authorize(w, r, currentUser.Username, access.ActionViewDialog, friendUsername) {
+		return
+	}
 
 	msgService, err := messages.NewMessageManager(handler.HandlerLogger, handler.HandlerConfig)
 
@@ -33,10 +40,27 @@ func (handler *NetworkHandler) GetMessagePage(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	allDialog := msgViewer.GetOneDialog(currentUser.Username, friendUsername)
+	page, _ := strconv.ParseInt(r.URL.Query().Get("page"), 10, 64)
+	jumpMessageID := r.URL.Query().Get("message")
+
+	if jumpMessageID != "" {
+		page, err = msgViewer.FindMessagePage(currentUser.Username, friendUsername, jumpMessageID)
+
+		if err != nil {
+			handler.HandlerLogger.Errorf("Can't find message %s in dialog, %v", jumpMessageID, err)
+		}
+	}
+
+	dialogPage := msgViewer.GetDialogPage(currentUser.Username, friendUsername, page)
 
 	templateMap := map[string]interface{}{
-		"AllDialog": allDialog,
+		"AllDialog":     dialogPage.Messages,
+		"DialogPage":    dialogPage,
+		"Friend":        friendUsername,
+		"Reactions":     messages.AllowedReactions,
+		"JumpMessageID": jumpMessageID,
+
+		"MaxPollQuestionLength": messages.MaxPollQuestionLength,
 	}
 
 	SEND_MESSAGE_TEMPLATE.Execute(w, templateMap)
@@ -52,11 +76,27 @@ func (handler *NetworkHandler) GetMyMessages(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	userDialogs := msgViewer.GetAllDialogs(handler.HandlerConfig, currentUser.Username)
+	isArchived := r.URL.Query().Get("archived") != ""
+	userDialogs := msgViewer.GetAllDialogs(handler.HandlerConfig, currentUser.Username, isArchived)
+	dialogFriends := []string{}
+
+	for _, dialog := range userDialogs {
+		dialogFriends = append(dialogFriends, dialog.Friend)
+	}
+
+	directory, err := handler.getUserDirectory(r)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Error when creating user directory, %v", err)
+		http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
+		return
+	}
 
 	templateMap := map[string]interface{}{
 		"CurrentUser": currentUser.Username,
 		"Dialogs":     userDialogs,
+		"Cards":       directory.GetCards(dialogFriends),
+		"IsArchived":  isArchived,
 	}
 
 	ALL_MESSAGES_TEMPLATE.Execute(w, templateMap)
@@ -66,8 +106,12 @@ func (handler *NetworkHandler) SendNewMessage(w http.ResponseWriter, r *http.Req
 	msgSender := handler.getCurrentUser(w, r)
 	msgReciever := params.ByName(USERNAME_URL_TEMPLATE)
 	textMessage := r.FormValue("message")
+	replyTo := r.FormValue("replyto")
+	sendAt := r.FormValue("sendat")
 
-	handler.checkConnection(w, r, msgSender.Username, msgReciever)
+	if !handler.authorize(w, r, msgSender.Username, access.ActionSendMessage, msgReciever) {
+		return
+	}
 
 	msgService, err := messages.NewMessageManager(handler.HandlerLogger, handler.HandlerConfig)
 
@@ -77,7 +121,12 @@ func (handler *NetworkHandler) SendNewMessage(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	err = msgService.SendMessage(msgSender.Username, msgReciever, textMessage)
+	if sendAt != "" {
+		handler.scheduleNewMessage(w, r, msgService, msgSender.Username, msgReciever, textMessage, replyTo, sendAt)
+		return
+	}
+
+	err = msgService.SendMessage(msgSender.Username, msgReciever, textMessage, replyTo)
 
 	if err != nil {
 		handler.HandlerLogger.Errorf("Error when sending message, %v", err)
@@ -90,17 +139,315 @@ func (handler *NetworkHandler) SendNewMessage(w http.ResponseWriter, r *http.Req
 	http.Redirect(w, r, redirectUrl, http.StatusSeeOther)
 }
 
-func (handler *NetworkHandler) checkConnection(w http.ResponseWriter, r *http.Request, currentUsername string, friendUsername string) {
-	friendView, err := friends.NewFriendViewer(handler.HandlerLogger, handler.HandlerConfig)
+func (handler *NetworkHandler) scheduleNewMessage(w http.ResponseWriter, r *http.Request, msgService messages.MessageManager,
+	from string, to string, textMessage string, replyTo string, sendAt string) {
+
+	sendTime, err := messages.ParseSendTime(sendAt, formLocation(r))
+
+	if err == nil {
+		err = msgService.ScheduleMessage(from, to, textMessage, replyTo, sendTime)
+	}
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Error when scheduling message, %v", err)
+		http.Redirect(w, r, path.Join(MESSAGE_URL, to), http.StatusSeeOther)
+		return
+	}
+
+	http.Redirect(w, r, SCHEDULED_URL, http.StatusSeeOther)
+}
+
+func (handler *NetworkHandler) GetScheduledPage(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	currentUser := handler.getCurrentUser(w, r)
+	msgViewer, err := messages.NewMessageViewer(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Error when creating msgViewer, %v", err)
+		http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
+		return
+	}
+
+	templateMap := map[string]interface{}{
+		"Scheduled": msgViewer.GetScheduledMessages(currentUser.Username),
+	}
+
+	SCHEDULED_TEMPLATE.Execute(w, templateMap)
+}
+
+func (handler *NetworkHandler) EditScheduledMessage(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	currentUser := handler.getCurrentUser(w, r)
+	messageID := params.ByName(ID_URL_TEMPLATE)
+
+	msgService, err := messages.NewMessageManager(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Error when creating msgService, %v", err)
+		http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
+		return
+	}
+
+	sendTime, err := messages.ParseSendTime(r.FormValue("sendat"), formLocation(r))
+
+	if err == nil {
+		err = msgService.EditScheduledMessage(currentUser.Username, messageID, r.FormValue("message"), sendTime)
+	}
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Error when editing scheduled message %s, %v", messageID, err)
+	}
+
+	http.Redirect(w, r, SCHEDULED_URL, http.StatusSeeOther)
+}
+
+func (handler *NetworkHandler) CancelScheduledMessage(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	currentUser := handler.getCurrentUser(w, r)
+	messageID := params.ByName(ID_URL_TEMPLATE)
+
+	msgService, err := messages.NewMessageManager(handler.HandlerLogger, handler.HandlerConfig)
 
 	if err != nil {
-		handler.HandlerLogger.Errorf("Error when creating friend manager, %v", err)
+		handler.HandlerLogger.Errorf("Error when creating msgService, %v", err)
 		http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
 		return
 	}
 
-	if friendView.CheckFriend(currentUsername, friendUsername) == false {
+	err = msgService.CancelScheduledMessage(currentUser.Username, messageID)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Error when cancelling scheduled message %s, %v", messageID, err)
+	}
+
+	http.Redirect(w, r, SCHEDULED_URL, http.StatusSeeOther)
+}
+
+// formLocation returns the time zone the browser sent with the form, forms
+// sent without it keep using the zone of the server
+func formLocation(r *http.Request) *time.Location {
+	timeZone := r.FormValue("tz")
+
+	if timeZone == "" {
+		return time.Local
+	}
+
+	location, err := time.LoadLocation(timeZone)
+
+	if err != nil {
+		return time.Local
+	}
+
+	return location
+}
+
+func (handler *NetworkHandler) ExportDialog(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	currentUser := handler.getCurrentUser(w, r)
+	friendUsername := params.ByName(USERNAME_URL_TEMPLATE)
+
+	if !handler.authorize(w, r, currentUser.Username, access.ActionViewDialog, friendUsername) {
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+
+	if format == "" {
+		format = messages.EXPORT_JSON
+	}
+
+	if !messages.IsExportFormat(format) {
+		http.Error(w, "Unknown export format", http.StatusBadRequest)
+		return
+	}
+
+	msgViewer, err := messages.NewMessageViewer(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Error when creating msgViewer, %v", err)
 		http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
 		return
 	}
+
+	fileName := fmt.Sprintf("%s_%s.%s", currentUser.Username, friendUsername, format)
+
+	w.Header().Set("Content-Type", messages.ExportContentType(format))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+
+	err = msgViewer.ExportDialog(w, currentUser.Username, friendUsername, format, formLocation(r))
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Error when exporting dialog %s - %s, %v", currentUser.Username, friendUsername, err)
+	}
+}
+
+func (handler *NetworkHandler) PinDialog(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	handler.changeDialogSettings(w, r, params, func(manager messages.MessageManager, user string, friend string) error {
+		return manager.SetDialogPinned(user, friend, true)
+	})
+}
+
+func (handler *NetworkHandler) UnpinDialog(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	handler.changeDialogSettings(w, r, params, func(manager messages.MessageManager, user string, friend string) error {
+		return manager.SetDialogPinned(user, friend, false)
+	})
+}
+
+func (handler *NetworkHandler) ArchiveDialog(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	handler.changeDialogSettings(w, r, params, func(manager messages.MessageManager, user string, friend string) error {
+		return manager.SetDialogArchived(user, friend, true)
+	})
+}
+
+func (handler *NetworkHandler) UnarchiveDialog(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	handler.changeDialogSettings(w, r, params, func(manager messages.MessageManager, user string, friend string) error {
+		return manager.SetDialogArchived(user, friend, false)
+	})
+}
+
+func (handler *NetworkHandler) MuteDialog(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	handler.changeDialogSettings(w, r, params, func(manager messages.MessageManager, user string, friend string) error {
+		return manager.SetDialogMuted(user, friend, true)
+	})
+}
+
+func (handler *NetworkHandler) UnmuteDialog(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	handler.changeDialogSettings(w, r, params, func(manager messages.MessageManager, user string, friend string) error {
+		return manager.SetDialogMuted(user, friend, false)
+	})
+}
+
+func (handler *NetworkHandler) changeDialogSettings(w http.ResponseWriter, r *http.Request, params httprouter.Params,
+	setFunc func(messages.MessageManager, string, string) error) {
+
+	currentUser := handler.getCurrentUser(w, r)
+	friendUsername := params.ByName(USERNAME_URL_TEMPLATE)
+
+	if !handler.authorize(w, r, currentUser.Username, access.ActionViewDialog, friendUsername) {
+		return
+	}
+
+	msgService, err := messages.NewMessageManager(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Error when creating msgService, %v", err)
+		http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
+		return
+	}
+
+	err = setFunc(msgService, currentUser.Username, friendUsername)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Error when changing dialog settings with %s, %v", friendUsername, err)
+	}
+
+	http.Redirect(w, r, MESSAGE_URL, http.StatusSeeOther)
+}
+
+func (handler *NetworkHandler) ReactToMessage(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	currentUser := handler.getCurrentUser(w, r)
+	friendUsername := params.ByName(USERNAME_URL_TEMPLATE)
+	messageID := r.FormValue("message")
+	emoji := r.FormValue("emoji")
+
+	if !handler.authorize(w, r, currentUser.Username, access.ActionReactMessage, friendUsername) {
+		return
+	}
+
+	msgService, err := messages.NewMessageManager(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Error when creating msgService, %v", err)
+		http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
+		return
+	}
+
+	err = msgService.ToggleReaction(currentUser.Username, friendUsername, messageID, emoji)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Error when reacting to message, %v", err)
+		http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
+		return
+	}
+
+	redirectUrl := path.Join(MESSAGE_URL, friendUsername)
+
+	http.Redirect(w, r, redirectUrl, http.StatusSeeOther)
+}
+
+func (handler *NetworkHandler) SendPoll(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	currentUser := handler.getCurrentUser(w, r)
+	friendUsername := params.ByName(USERNAME_URL_TEMPLATE)
+
+	if !handler.authorize(w, r, currentUser.Username, access.ActionSendMessage, friendUsername) {
+		return
+	}
+
+	msgService, err := messages.NewMessageManager(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Error when creating msgService, %v", err)
+		http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
+		return
+	}
+
+	var closesAt *time.Time
+
+	if value := r.FormValue("closesat"); value != "" {
+		closeTime, err := messages.ParseSendTime(value, formLocation(r))
+
+		if err != nil {
+			handler.HandlerLogger.Errorf("Error when sending poll, %v", err)
+			http.Redirect(w, r, path.Join(MESSAGE_URL, friendUsername), http.StatusSeeOther)
+			return
+		}
+
+		closesAt = &closeTime
+	}
+
+	options := strings.Split(r.FormValue("options"), "\n")
+	isMultiple := r.FormValue("multiple") != ""
+
+	err = msgService.SendPoll(currentUser.Username, friendUsername, r.FormValue("question"), options, isMultiple, closesAt)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Error when sending poll, %v", err)
+	}
+
+	http.Redirect(w, r, path.Join(MESSAGE_URL, friendUsername), http.StatusSeeOther)
+}
+
+func (handler *NetworkHandler) VotePoll(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	currentUser := handler.getCurrentUser(w, r)
+	friendUsername := params.ByName(USERNAME_URL_TEMPLATE)
+	messageID := r.FormValue("message")
+
+	if !handler.authorize(w, r, currentUser.Username, access.ActionVotePoll, friendUsername) {
+		return
+	}
+
+	msgService, err := messages.NewMessageManager(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Error when creating msgService, %v", err)
+		http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
+		return
+	}
+
+	options := []int{}
+
+	for _, value := range r.Form["option"] {
+		option, err := strconv.Atoi(value)
+
+		if err != nil {
+			options = nil
+			break
+		}
+
+		options = append(options, option)
+	}
+
+	if err = msgService.VotePoll(currentUser.Username, friendUsername, messageID, options); err != nil {
+		handler.HandlerLogger.Errorf("Error when voting in poll %s, %v", messageID, err)
+	}
+
+	redirectUrl := path.Join(MESSAGE_URL, friendUsername) + "?message=" + url.QueryEscape(messageID)
+
+	http.Redirect(w, r, redirectUrl, http.StatusSeeOther)
 }
@@ -4,6 +4,7 @@ import "path"
 
 const (
 	USERNAME_URL_TEMPLATE = "username"
+	ID_URL_TEMPLATE       = "id"
 )
 
 var (
@@ -17,14 +18,66 @@ var (
 	USERS_URL             = "/users"
 	FRIENDS_URL           = "/friends"
 	MESSAGE_URL           = "/messages"
+	SCHEDULED_URL         = "/scheduled"
+	SETTINGS_URL          = "/settings"
+	FEED_URL              = "/feed"
+	STORIES_URL           = "/stories"
+	GROUPS_URL            = "/groups"
+	EVENTS_URL            = "/events"
+	API_URL               = "/api"
 	ANY_USERNAME_TEMPLATE = ":" + USERNAME_URL_TEMPLATE
+	ANY_ID_TEMPLATE       = ":" + ID_URL_TEMPLATE
 
 	LOGOUT_URL = path.Join(HOME_URL, "logout")
 
 	OTHER_PAGE_URL = path.Join(USERS_URL, ANY_USERNAME_TEMPLATE)
 
+	POSTS_URL       = path.Join(HOME_URL, "posts")
+	DELETE_POST_URL = path.Join(POSTS_URL, ANY_ID_TEMPLATE, "delete")
+
+	STORY_URL        = path.Join(STORIES_URL, ANY_ID_TEMPLATE)
+	STORY_IMAGE_URL  = path.Join(STORY_URL, "image")
+	DELETE_STORY_URL = path.Join(STORY_URL, "delete")
+
+	GROUP_URL             = path.Join(GROUPS_URL, ANY_ID_TEMPLATE)
+	EDIT_GROUP_URL        = path.Join(GROUP_URL, "edit")
+	DELETE_GROUP_URL      = path.Join(GROUP_URL, "delete")
+	JOIN_GROUP_URL        = path.Join(GROUP_URL, "join")
+	LEAVE_GROUP_URL       = path.Join(GROUP_URL, "leave")
+	GROUP_POSTS_URL       = path.Join(GROUP_URL, "posts")
+	DELETE_GROUP_POST_URL = path.Join(GROUP_POSTS_URL, "delete")
+
+	GROUP_MEMBER_URL         = path.Join(GROUP_URL, "members", ANY_USERNAME_TEMPLATE)
+	APPROVE_GROUP_MEMBER_URL = path.Join(GROUP_MEMBER_URL, "approve")
+	REJECT_GROUP_MEMBER_URL  = path.Join(GROUP_MEMBER_URL, "reject")
+	REMOVE_GROUP_MEMBER_URL  = path.Join(GROUP_MEMBER_URL, "remove")
+	GROUP_MEMBER_ROLE_URL    = path.Join(GROUP_MEMBER_URL, "role")
+
+	EVENT_URL            = path.Join(EVENTS_URL, ANY_ID_TEMPLATE)
+	DELETE_EVENT_URL     = path.Join(EVENT_URL, "delete")
+	EVENT_RSVP_URL       = path.Join(EVENT_URL, "rsvp")
+	EVENT_INVITE_URL     = path.Join(EVENT_URL, "invite")
+	EVENT_ICS_URL        = path.Join(EVENT_URL, "ics")
+	DISMISS_REMINDER_URL = path.Join(EVENT_URL, "dismiss")
+
+	GUESTBOOK_URL        = path.Join(OTHER_PAGE_URL, "guestbook")
+	DELETE_GUESTBOOK_URL = path.Join(GUESTBOOK_URL, ANY_ID_TEMPLATE, "delete")
+	HIDE_GUESTBOOK_URL   = path.Join(GUESTBOOK_URL, ANY_ID_TEMPLATE, "hide")
+	UNHIDE_GUESTBOOK_URL = path.Join(GUESTBOOK_URL, ANY_ID_TEMPLATE, "unhide")
+	LIKE_GUESTBOOK_URL   = path.Join(GUESTBOOK_URL, ANY_ID_TEMPLATE, "like")
+
 	FRIEND_REQUESTS_URL = path.Join(FRIENDS_URL, "requests")
 	MY_FRIENDS_URL      = path.Join(FRIENDS_URL, "myfriends")
+	OUTGOING_URL        = path.Join(FRIENDS_URL, "outgoing")
+
+	IMPORT_CONTACTS_URL  = path.Join(FRIENDS_URL, "import")
+	CONTACTS_REQUEST_URL = path.Join(IMPORT_CONTACTS_URL, "requests")
+
+	FRIEND_LISTS_URL       = path.Join(FRIENDS_URL, "lists")
+	RENAME_LIST_URL        = path.Join(FRIEND_LISTS_URL, ANY_ID_TEMPLATE, "rename")
+	DELETE_LIST_URL        = path.Join(FRIEND_LISTS_URL, ANY_ID_TEMPLATE, "delete")
+	LIST_MEMBERS_URL       = path.Join(FRIEND_LISTS_URL, ANY_ID_TEMPLATE, "members")
+	REMOVE_LIST_MEMBER_URL = path.Join(LIST_MEMBERS_URL, ANY_USERNAME_TEMPLATE, "remove")
 
 	SEND_REQUEST_URL   = path.Join(USERS_URL, ANY_USERNAME_TEMPLATE, "sendrequest")
 	CANCEL_REQUEST_URL = path.Join(USERS_URL, ANY_USERNAME_TEMPLATE, "cancelrequest")
@@ -33,5 +86,53 @@ var (
 	ACCEPT_FRIEND_URL = path.Join(USERS_URL, ANY_USERNAME_TEMPLATE, "addfriend")
 	DENY_FRIEND_URL   = path.Join(USERS_URL, ANY_USERNAME_TEMPLATE, "denyfriend")
 
-	DIALOG_URL = path.Join(MESSAGE_URL, ANY_USERNAME_TEMPLATE)
+	BLOCK_USER_URL   = path.Join(USERS_URL, ANY_USERNAME_TEMPLATE, "block")
+	UNBLOCK_USER_URL = path.Join(USERS_URL, ANY_USERNAME_TEMPLATE, "unblock")
+
+	BLOCKED_USERS_URL = path.Join(SETTINGS_URL, "blocked")
+
+	FOLLOW_USER_URL   = path.Join(USERS_URL, ANY_USERNAME_TEMPLATE, "follow")
+	UNFOLLOW_USER_URL = path.Join(USERS_URL, ANY_USERNAME_TEMPLATE, "unfollow")
+	FOLLOWERS_URL     = path.Join(USERS_URL, ANY_USERNAME_TEMPLATE, "followers")
+	FOLLOWING_URL     = path.Join(USERS_URL, ANY_USERNAME_TEMPLATE, "following")
+
+	FOLLOWER_REQUESTS_URL = path.Join(SETTINGS_URL, "followers")
+	APPROVE_FOLLOWER_URL  = path.Join(FOLLOWER_REQUESTS_URL, ANY_USERNAME_TEMPLATE, "approve")
+	REJECT_FOLLOWER_URL   = path.Join(FOLLOWER_REQUESTS_URL, ANY_USERNAME_TEMPLATE, "reject")
+	PRIVACY_URL           = path.Join(SETTINGS_URL, "privacy")
+	VISIBILITY_URL        = path.Join(SETTINGS_URL, "visibility")
+	USERNAME_SETTINGS_URL = path.Join(SETTINGS_URL, "username")
+	PROFILE_SETTINGS_URL  = path.Join(SETTINGS_URL, "profile")
+
+	API_BLOCKS_URL     = path.Join(API_URL, "blocks")
+	API_BLOCK_USER_URL = path.Join(API_BLOCKS_URL, ANY_USERNAME_TEMPLATE)
+
+	API_USERNAME_URL = path.Join(API_URL, "username")
+
+	API_FEED_URL = path.Join(API_URL, FEED_URL)
+
+	API_POSTS_URL = path.Join(API_URL, "posts")
+	API_POST_URL  = path.Join(API_POSTS_URL, ANY_ID_TEMPLATE)
+	API_WALL_URL  = path.Join(API_URL, OTHER_PAGE_URL, "posts")
+
+	API_IMPORT_CONTACTS_URL = path.Join(API_URL, IMPORT_CONTACTS_URL)
+
+	API_OUTGOING_URL         = path.Join(API_URL, OUTGOING_URL)
+	API_OUTGOING_REQUEST_URL = path.Join(API_OUTGOING_URL, ANY_USERNAME_TEMPLATE)
+
+	DIALOG_URL   = path.Join(MESSAGE_URL, ANY_USERNAME_TEMPLATE)
+	REACTION_URL = path.Join(MESSAGE_URL, ANY_USERNAME_TEMPLATE, "react")
+	POLL_URL     = path.Join(MESSAGE_URL, ANY_USERNAME_TEMPLATE, "poll")
+	VOTE_URL     = path.Join(MESSAGE_URL, ANY_USERNAME_TEMPLATE, "vote")
+	EXPORT_URL   = path.Join(MESSAGE_URL, ANY_USERNAME_TEMPLATE, "export")
+
+	PIN_DIALOG_URL       = path.Join(MESSAGE_URL, ANY_USERNAME_TEMPLATE, "pin")
+	UNPIN_DIALOG_URL     = path.Join(MESSAGE_URL, ANY_USERNAME_TEMPLATE, "unpin")
+	ARCHIVE_DIALOG_URL   = path.Join(MESSAGE_URL, ANY_USERNAME_TEMPLATE, "archive")
+	UNARCHIVE_DIALOG_URL = path.Join(MESSAGE_URL, ANY_USERNAME_TEMPLATE, "unarchive")
+	MUTE_DIALOG_URL      = path.Join(MESSAGE_URL, ANY_USERNAME_TEMPLATE, "mute")
+	UNMUTE_DIALOG_URL    = path.Join(MESSAGE_URL, ANY_USERNAME_TEMPLATE, "unmute")
+
+	EDIT_SCHEDULED_URL   = path.Join(SCHEDULED_URL, ANY_ID_TEMPLATE, "edit")
+	CANCEL_SCHEDULED_URL = path.Join(SCHEDULED_URL, ANY_ID_TEMPLATE, "cancel")
 )
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/delonce/socialnetwork/internal/service/access"
+	"github.com/delonce/socialnetwork/internal/service/posts"
+	"github.com/delonce/socialnetwork/internal/service/user"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+func (handler *NetworkHandler) CreatePost(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	currentUser := handler.getCurrentUser(w, r)
+	manager, err := posts.NewPostManager(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Error when creating post manager, %v", err)
+		http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
+		return
+	}
+
+	if _, err = manager.CreatePost(currentUser.Username, r.FormValue("text")); err != nil {
+		handler.HandlerLogger.Errorf("Can't create post of %s, %v", currentUser.Username, err)
+	}
+
+	http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
+}
+
+func (handler *NetworkHandler) DeletePost(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	currentUser := handler.getCurrentUser(w, r)
+	manager, err := posts.NewPostManager(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Error when creating post manager, %v", err)
+		http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
+		return
+	}
+
+	postID := params.ByName(ID_URL_TEMPLATE)
+
+	if err = manager.DeletePost(currentUser.Username, postID); err != nil {
+		handler.HandlerLogger.Errorf("Can't delete post %s, %v", postID, err)
+	}
+
+	http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
+}
+
+func (handler *NetworkHandler) GetWallAPI(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	currentUser := handler.getAPICurrentUser(w, r)
+
+	if currentUser == nil {
+		return
+	}
+
+	owner := params.ByName(USERNAME_URL_TEMPLATE)
+
+	if !handler.authorizeAPI(w, currentUser.Username, access.ActionViewProfile, owner) {
+		return
+	}
+
+	postView, err := posts.NewPostViewer(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Something wrong")
+		return
+	}
+
+	page, _ := strconv.ParseInt(r.URL.Query().Get("page"), 10, 64)
+
+	writeJSON(w, http.StatusOK, postView.GetWall(owner, page))
+}
+
+func (handler *NetworkHandler) CreatePostAPI(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	currentUser := handler.getAPICurrentUser(w, r)
+
+	if currentUser == nil {
+		return
+	}
+
+	manager, err := posts.NewPostManager(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Something wrong")
+		return
+	}
+
+	postID, err := manager.CreatePost(currentUser.Username, r.FormValue("text"))
+
+	if err != nil {
+		writeJSONError(w, postErrorStatus(err), err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]string{"id": postID})
+}
+
+func (handler *NetworkHandler) DeletePostAPI(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	currentUser := handler.getAPICurrentUser(w, r)
+
+	if currentUser == nil {
+		return
+	}
+
+	manager, err := posts.NewPostManager(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Something wrong")
+		return
+	}
+
+	if err = manager.DeletePost(currentUser.Username, params.ByName(ID_URL_TEMPLATE)); err != nil {
+		writeJSONError(w, postErrorStatus(err), err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func postErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, posts.ErrEmptyPost), errors.Is(err, posts.ErrLongPost):
+		return http.StatusBadRequest
+	case errors.Is(err, posts.ErrPostNotFound), errors.Is(err, user.ErrUserNotFound):
+		return http.StatusNotFound
+	}
+
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/delonce/socialnetwork/internal/service"
+	"github.com/delonce/socialnetwork/internal/service/friendlists"
+	"github.com/delonce/socialnetwork/internal/service/user"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+var profileFieldTitles = map[string]string{
+	friendlists.VisibilityBio:      "О себе",
+	friendlists.VisibilityLocation: "Город",
+	friendlists.VisibilityBirthday: "День рождения",
+	friendlists.VisibilityWebsite:  "Сайт",
+}
+
+func (handler *NetworkHandler) GetProfilePage(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	currentUser := handler.getCurrentUser(w, r)
+	listView, err := friendlists.NewFriendListViewer(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Error when creating friend list service, %v", err)
+		http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
+		return
+	}
+
+	settings := listView.GetVisibility(currentUser.Username)
+	audiences := map[string]string{
+		friendlists.VisibilityBio:      settings.Bio,
+		friendlists.VisibilityLocation: settings.Location,
+		friendlists.VisibilityBirthday: settings.Birthday,
+		friendlists.VisibilityWebsite:  settings.Website,
+	}
+
+	fields := []map[string]string{}
+
+	for _, field := range friendlists.ProfileFields {
+		fields = append(fields, map[string]string{
+			"Name":    field,
+			"Title":   profileFieldTitles[field],
+			"Current": audiences[field],
+		})
+	}
+
+	birthday := ""
+
+	if currentUser.Birthday != nil {
+		birthday = currentUser.Birthday.Format(user.BirthdayLayout)
+	}
+
+	templateMap := map[string]interface{}{
+		"CurrentUser": currentUser,
+		"Birthday":    birthday,
+		"Lists":       listView.GetLists(currentUser.Username),
+		"Fields":      fields,
+
+		"MaxDisplayNameLength": user.MaxDisplayNameLength,
+		"MaxBioLength":         user.MaxBioLength,
+		"MaxLocationLength":    user.MaxLocationLength,
+		"MaxLinkLength":        user.MaxLinkLength,
+	}
+
+	PROFILE_TEMPLATE.Execute(w, templateMap)
+}
+
+func (handler *NetworkHandler) UpdateProfile(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	currentUser := handler.getCurrentUser(w, r)
+	authService, err := user.NewAuthService(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Can't create auth service, %v", err)
+		http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
+		return
+	}
+
+	manager, err := friendlists.NewFriendListManager(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Error when creating friend list manager, %v", err)
+		http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
+		return
+	}
+
+	profile := &service.Profile{
+		DisplayName: r.FormValue("displayname"),
+		Avatar:      r.FormValue("avatar"),
+		Bio:         r.FormValue("bio"),
+		Location:    r.FormValue("location"),
+		Website:     r.FormValue("website"),
+	}
+
+	if value := r.FormValue("birthday"); value != "" {
+		birthday, err := time.Parse(user.BirthdayLayout, value)
+
+		if err != nil {
+			handler.HandlerLogger.Errorf("Can't change profile of %s, %v", currentUser.Username, err)
+			http.Redirect(w, r, PROFILE_SETTINGS_URL, http.StatusSeeOther)
+			return
+		}
+
+		profile.Birthday = &birthday
+	}
+
+	if err = authService.UpdateProfile(currentUser.ID, profile); err != nil {
+		handler.HandlerLogger.Errorf("Can't change profile of %s, %v", currentUser.Username, err)
+		http.Redirect(w, r, PROFILE_SETTINGS_URL, http.StatusSeeOther)
+		return
+	}
+
+	for _, field := range friendlists.ProfileFields {
+		audience := r.FormValue(field + "_audience")
+
+		if audience == "" {
+			continue
+		}
+
+		if err = manager.SetVisibility(currentUser.Username, field, audience); err != nil {
+			handler.HandlerLogger.Errorf("Error when changing visibility of %s, %v", field, err)
+		}
+	}
+
+	http.Redirect(w, r, PROFILE_SETTINGS_URL, http.StatusSeeOther)
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/delonce/socialnetwork/internal/service/stories"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+func (handler *NetworkHandler) CreateStory(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	currentUser := handler.getCurrentUser(w, r)
+	manager, err := stories.NewStoryManager(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Error when creating story manager, %v", err)
+		http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, 2*stories.MaxImageSize)
+	image, err := readStoryImage(r)
+
+	if err == nil && image != nil {
+		_, err = manager.CreateImageStory(currentUser.Username, image, r.FormValue("text"))
+	} else if err == nil {
+		_, err = manager.CreateTextStory(currentUser.Username, r.FormValue("text"))
+	}
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Can't create story of %s, %v", currentUser.Username, err)
+	}
+
+	http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
+}
+
+func (handler *NetworkHandler) GetStoryPage(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	currentUser := handler.getCurrentUser(w, r)
+	storyID := params.ByName(ID_URL_TEMPLATE)
+
+	storyView, err := stories.NewStoryViewer(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Error when creating story service, %v", err)
+		http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
+		return
+	}
+
+	manager, err := stories.NewStoryManager(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Error when creating story manager, %v", err)
+		http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
+		return
+	}
+
+	story, err := storyView.GetStory(currentUser.Username, storyID)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Can't show story %s to %s, %v", storyID, currentUser.Username, err)
+		http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
+		return
+	}
+
+	if err = manager.MarkSeen(currentUser.Username, storyID); err != nil {
+		handler.HandlerLogger.Errorf("Can't mark story %s as seen, %v", storyID, err)
+	}
+
+	templateMap := map[string]interface{}{
+		"Story":    story,
+		"IsAuthor": story.Author == currentUser.Username,
+	}
+
+	STORY_TEMPLATE.Execute(w, templateMap)
+}
+
+func (handler *NetworkHandler) GetStoryImage(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	currentUser := handler.getCurrentUser(w, r)
+	storyView, err := stories.NewStoryViewer(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		http.Error(w, "Something wrong", http.StatusInternalServerError)
+		return
+	}
+
+	image, imageType, err := storyView.GetStoryImage(currentUser.Username, params.ByName(ID_URL_TEMPLATE))
+
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", imageType)
+	w.Header().Set("Cache-Control", "private, max-age=3600")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Write(image)
+}
+
+func (handler *NetworkHandler) DeleteStory(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	currentUser := handler.getCurrentUser(w, r)
+	manager, err := stories.NewStoryManager(handler.HandlerLogger, handler.HandlerConfig)
+
+	if err != nil {
+		handler.HandlerLogger.Errorf("Error when creating story manager, %v", err)
+		http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
+		return
+	}
+
+	storyID := params.ByName(ID_URL_TEMPLATE)
+
+	if err = manager.DeleteStory(currentUser.Username, storyID); err != nil {
+		handler.HandlerLogger.Errorf("Can't delete story %s, %v", storyID, err)
+	}
+
+	http.Redirect(w, r, HOME_URL, http.StatusSeeOther)
+}
+
+// readStoryImage returns nil when the form has no image, so the story is
+// a text one
+func readStoryImage(r *http.Request) ([]byte, error) {
+	uploaded, _, err := r.FormFile("image")
+
+	if errors.Is(err, http.ErrMissingFile) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer uploaded.Close()
+
+	return io.ReadAll(io.LimitReader(uploaded, stories.MaxImageSize+1))
+}
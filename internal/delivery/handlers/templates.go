@@ -1,8 +1,8 @@
 package handlers
 
 import (
+	"html/template"
 	"path"
-	"text/template"
 
 	"github.com/delonce/socialnetwork/internal/config"
 	"github.com/delonce/socialnetwork/pkg/logging"
@@ -15,19 +15,37 @@ var (
 	ROOT_TEMPLATE   = "web/templates"
 	BASE_TEMPLATE   = path.Join(ROOT_TEMPLATE, "base.html")
 
-	INDEX_TEMPLATE           = template.Must(template.ParseFiles(path.Join(ROOT_TEMPLATE, "index.html")))
-	REGISTER_TEMPLATE        = template.Must(template.ParseFiles(path.Join(ROOT_TEMPLATE, "register.html")))
-	LOGIN_TEMPLATE           = template.Must(template.ParseFiles(path.Join(ROOT_TEMPLATE, "login.html")))
-	HOMEPAGE_TEMPLATE        = template.Must(template.ParseFiles(path.Join(ROOT_TEMPLATE, "homepage.html"), BASE_TEMPLATE))
-	FRIENDS_TEMPLATE         = template.Must(template.ParseFiles(path.Join(ROOT_TEMPLATE, "friends.html"), BASE_TEMPLATE))
-	FRIEND_REQUESTS_TEMPLATE = template.Must(template.ParseFiles(path.Join(ROOT_TEMPLATE, "friend_requests.html"), BASE_TEMPLATE))
+	INDEX_TEMPLATE             = template.Must(template.ParseFiles(path.Join(ROOT_TEMPLATE, "index.html")))
+	REGISTER_TEMPLATE          = template.Must(template.ParseFiles(path.Join(ROOT_TEMPLATE, "register.html")))
+	LOGIN_TEMPLATE             = template.Must(template.ParseFiles(path.Join(ROOT_TEMPLATE, "login.html")))
+	HOMEPAGE_TEMPLATE          = template.Must(template.ParseFiles(path.Join(ROOT_TEMPLATE, "homepage.html"), BASE_TEMPLATE))
+	STORY_TEMPLATE             = template.Must(template.ParseFiles(path.Join(ROOT_TEMPLATE, "story.html"), BASE_TEMPLATE))
+	GROUPS_TEMPLATE            = template.Must(template.ParseFiles(path.Join(ROOT_TEMPLATE, "groups.html"), BASE_TEMPLATE))
+	GROUP_TEMPLATE             = template.Must(template.ParseFiles(path.Join(ROOT_TEMPLATE, "group.html"), BASE_TEMPLATE))
+	EVENTS_TEMPLATE            = template.Must(template.ParseFiles(path.Join(ROOT_TEMPLATE, "events.html"), BASE_TEMPLATE))
+	EVENT_TEMPLATE             = template.Must(template.ParseFiles(path.Join(ROOT_TEMPLATE, "event.html"), BASE_TEMPLATE))
+	FEED_TEMPLATE              = template.Must(template.ParseFiles(path.Join(ROOT_TEMPLATE, "feed.html"), BASE_TEMPLATE))
+	FRIENDS_TEMPLATE           = template.Must(template.ParseFiles(path.Join(ROOT_TEMPLATE, "friends.html"), BASE_TEMPLATE))
+	FRIEND_REQUESTS_TEMPLATE   = template.Must(template.ParseFiles(path.Join(ROOT_TEMPLATE, "friend_requests.html"), BASE_TEMPLATE))
+	OUTGOING_REQUESTS_TEMPLATE = template.Must(template.ParseFiles(path.Join(ROOT_TEMPLATE, "outgoing_requests.html"), BASE_TEMPLATE))
+	BLOCKED_USERS_TEMPLATE     = template.Must(template.ParseFiles(path.Join(ROOT_TEMPLATE, "blocked_users.html"), BASE_TEMPLATE))
+	IMPORT_CONTACTS_TEMPLATE   = template.Must(template.ParseFiles(path.Join(ROOT_TEMPLATE, "import_contacts.html"), BASE_TEMPLATE))
+
+	FRIEND_LISTS_TEMPLATE = template.Must(template.ParseFiles(path.Join(ROOT_TEMPLATE, "friend_lists.html"), BASE_TEMPLATE))
+	VISIBILITY_TEMPLATE   = template.Must(template.ParseFiles(path.Join(ROOT_TEMPLATE, "visibility.html"), BASE_TEMPLATE))
+	PROFILE_TEMPLATE      = template.Must(template.ParseFiles(path.Join(ROOT_TEMPLATE, "profile.html"), BASE_TEMPLATE))
+
+	FOLLOWS_TEMPLATE           = template.Must(template.ParseFiles(path.Join(ROOT_TEMPLATE, "follows.html"), BASE_TEMPLATE))
+	FOLLOWER_REQUESTS_TEMPLATE = template.Must(template.ParseFiles(path.Join(ROOT_TEMPLATE, "follower_requests.html"), BASE_TEMPLATE))
 
 	ALL_MESSAGES_TEMPLATE = template.Must(template.ParseFiles(path.Join(ROOT_TEMPLATE, "all_messages.html"), BASE_TEMPLATE))
 	SEND_MESSAGE_TEMPLATE = template.Must(template.ParseFiles(path.Join(ROOT_TEMPLATE, "send_message.html"), BASE_TEMPLATE))
+	SCHEDULED_TEMPLATE    = template.Must(template.ParseFiles(path.Join(ROOT_TEMPLATE, "scheduled_messages.html"), BASE_TEMPLATE))
 )
 
 const (
 	userIDKey          = "userID"
+	userDirectoryKey   = "userDirectory"
 	accessTokenCookie  = "authAccessToken"
 	refreshTokenCookie = "authRefreshToken"
 )
@@ -0,0 +1,112 @@
+package netapp
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/delonce/socialnetwork/internal/service/events"
+	"github.com/delonce/socialnetwork/internal/service/friends"
+	"github.com/delonce/socialnetwork/internal/service/messages"
+)
+
+type backgroundJob struct {
+	name     string
+	interval time.Duration
+	run      func() error
+}
+
+func (networkApp *NetApp) startBackgroundJobs() {
+	for _, job := range networkApp.getBackgroundJobs() {
+		networkApp.NetLogger.Infof("Starting background job %s every %s", job.name, job.interval)
+		go networkApp.runBackgroundJob(job)
+	}
+}
+
+func (networkApp *NetApp) getBackgroundJobs() []backgroundJob {
+	jobs := []backgroundJob{}
+
+	scheduler, err := messages.NewMessageScheduler(networkApp.NetLogger, networkApp.AppConfig)
+
+	if err != nil {
+		networkApp.NetLogger.Errorf("Failed to create message scheduler, %v", err)
+	} else {
+		jobs = append(jobs, backgroundJob{
+			name:     "scheduled messages delivery",
+			interval: networkApp.AppConfig.Scheduler.Interval,
+			run: func() error {
+				delivered, err := scheduler.DeliverDueMessages()
+
+				if delivered > 0 {
+					networkApp.NetLogger.Infof("Delivered %d scheduled messages", delivered)
+				}
+
+				return err
+			},
+		})
+	}
+
+	expirer, err := friends.NewRequestExpirer(networkApp.NetLogger, networkApp.AppConfig)
+
+	if err != nil {
+		networkApp.NetLogger.Errorf("Failed to create friend request expirer, %v", err)
+	} else {
+		jobs = append(jobs, backgroundJob{
+			name:     "friend requests expiry",
+			interval: networkApp.AppConfig.Friends.ExpiryInterval,
+			run: func() error {
+				expired, err := expirer.ExpireRequests()
+
+				if expired > 0 {
+					networkApp.NetLogger.Infof("Expired %d friend requests", expired)
+				}
+
+				return err
+			},
+		})
+	}
+
+	reminderSender, err := events.NewEventReminderSender(networkApp.NetLogger, networkApp.AppConfig)
+
+	if err != nil {
+		networkApp.NetLogger.Errorf("Failed to create event reminder sender, %v", err)
+	} else {
+		jobs = append(jobs, backgroundJob{
+			name:     "event reminders",
+			interval: networkApp.AppConfig.Events.ReminderInterval,
+			run: func() error {
+				sent, err := reminderSender.SendDueReminders()
+
+				if sent > 0 {
+					networkApp.NetLogger.Infof("Sent %d event reminders", sent)
+				}
+
+				return err
+			},
+		})
+	}
+
+	return jobs
+}
+
+func (networkApp *NetApp) runBackgroundJob(job backgroundJob) {
+	ticker := time.NewTicker(job.interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := networkApp.runJobOnce(job); err != nil {
+			networkApp.NetLogger.Errorf("Background job %s failed with error %v", job.name, err)
+		}
+	}
+}
+
+// runJobOnce turns a panic of the job into an error, services panic on
+// database errors and one failed run must not stop the whole process
+func (networkApp *NetApp) runJobOnce(job backgroundJob) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("panic: %v", recovered)
+		}
+	}()
+
+	return job.run()
+}
@@ -0,0 +1,158 @@
+package netapp
+
+import (
+	"github.com/delonce/socialnetwork/internal/service/events"
+	"github.com/delonce/socialnetwork/internal/service/follows"
+	"github.com/delonce/socialnetwork/internal/service/friendlists"
+	"github.com/delonce/socialnetwork/internal/service/friends"
+	"github.com/delonce/socialnetwork/internal/service/groups"
+	"github.com/delonce/socialnetwork/internal/service/guestbook"
+	"github.com/delonce/socialnetwork/internal/service/messages"
+	"github.com/delonce/socialnetwork/internal/service/migrations"
+	"github.com/delonce/socialnetwork/internal/service/stories"
+	"github.com/delonce/socialnetwork/internal/service/user"
+)
+
+// migration runs on every start unless it is marked once, such migrations
+// are recorded in the journal after they succeed and are skipped afterwards
+type migration struct {
+	name string
+	once bool
+	run  func() error
+}
+
+func (networkApp *NetApp) runMigrations() {
+	journal, err := migrations.NewJournal(networkApp.NetLogger, networkApp.AppConfig)
+
+	if err != nil {
+		networkApp.NetLogger.Errorf("Can't open migration journal, %v", err)
+		return
+	}
+
+	migrations := []migration{
+		{
+			name: "friendship states",
+			run: func() error {
+				return friends.MigrateFriendships(networkApp.NetLogger, networkApp.AppConfig)
+			},
+		},
+		{
+			name: "user ids",
+			once: true,
+			run: func() error {
+				userIDs, err := user.LoadUserIDs(networkApp.NetLogger, networkApp.AppConfig)
+
+				if err != nil {
+					return err
+				}
+
+				err = friends.MigrateFriendshipUserIDs(networkApp.NetLogger, networkApp.AppConfig, userIDs)
+
+				if err != nil {
+					return err
+				}
+
+				err = messages.MigrateMessageUserIDs(networkApp.NetLogger, networkApp.AppConfig, userIDs)
+
+				if err != nil {
+					return err
+				}
+
+				err = follows.MigrateFollowUserIDs(networkApp.NetLogger, networkApp.AppConfig, userIDs)
+
+				if err != nil {
+					return err
+				}
+
+				return friendlists.MigrateFriendListUserIDs(networkApp.NetLogger, networkApp.AppConfig, userIDs)
+			},
+		},
+		{
+			name: "friendship indexes",
+			run: func() error {
+				return friends.MigrateFriendshipIndexes(networkApp.NetLogger, networkApp.AppConfig)
+			},
+		},
+		{
+			name: "friend list indexes",
+			run: func() error {
+				return friendlists.MigrateFriendListIndexes(networkApp.NetLogger, networkApp.AppConfig)
+			},
+		},
+		{
+			name: "follow indexes",
+			run: func() error {
+				return follows.MigrateFollowIndexes(networkApp.NetLogger, networkApp.AppConfig)
+			},
+		},
+		{
+			name: "email hashes",
+			run: func() error {
+				return user.MigrateEmailHashes(networkApp.NetLogger, networkApp.AppConfig)
+			},
+		},
+		{
+			name: "user indexes",
+			run: func() error {
+				return user.MigrateUserIndexes(networkApp.NetLogger, networkApp.AppConfig)
+			},
+		},
+		{
+			name: "guestbook indexes",
+			run: func() error {
+				return guestbook.MigrateGuestbookIndexes(networkApp.NetLogger, networkApp.AppConfig)
+			},
+		},
+		{
+			name: "poll indexes",
+			run: func() error {
+				return messages.MigratePollIndexes(networkApp.NetLogger, networkApp.AppConfig)
+			},
+		},
+		{
+			name: "reaction indexes",
+			run: func() error {
+				return messages.MigrateReactionIndexes(networkApp.NetLogger, networkApp.AppConfig)
+			},
+		},
+		{
+			name: "story indexes",
+			run: func() error {
+				return stories.MigrateStoryIndexes(networkApp.NetLogger, networkApp.AppConfig)
+			},
+		},
+		{
+			name: "group indexes",
+			run: func() error {
+				return groups.MigrateGroupIndexes(networkApp.NetLogger, networkApp.AppConfig)
+			},
+		},
+		{
+			name: "event indexes",
+			run: func() error {
+				return events.MigrateEventIndexes(networkApp.NetLogger, networkApp.AppConfig)
+			},
+		},
+	}
+
+	for _, m := range migrations {
+		if m.once && journal.IsApplied(m.name) {
+			continue
+		}
+
+		networkApp.NetLogger.Infof("Running migration %s", m.name)
+
+		if err := m.run(); err != nil {
+			networkApp.NetLogger.Errorf("Migration %s failed with error %v", m.name, err)
+			continue
+		}
+
+		if !m.once {
+			continue
+		}
+
+		if err := journal.MarkApplied(m.name); err != nil {
+			networkApp.NetLogger.Errorf("Can't mark migration %s as applied, %v", m.name, err)
+		}
+	}
+}
@@ -11,6 +11,8 @@ import (
 
 type SocialNetwork interface {
 	Run()
+	runMigrations()
+	startBackgroundJobs()
 	startHTTPServer()
 }
 
@@ -21,6 +23,8 @@ type NetApp struct {
 }
 
 func (networkApp *NetApp) Run() {
+	networkApp.runMigrations()
+	networkApp.startBackgroundJobs()
 	networkApp.startHTTPServer()
 }
 
@@ -51,14 +55,14 @@ func (networkApp *NetApp) startHTTPServer() {
 		err := httpServer.ListenAndServeTLS(networkApp.AppConfig.Tls.Cert, networkApp.AppConfig.Tls.Key)
 
 		if err != nil {
-			networkApp.NetLogger.Error("Failed to start SSL mode on http server with error %v", err)
+			networkApp.NetLogger.Errorf("Failed to start SSL mode on http server with error %v", err)
 		}
 	} else {
 		networkApp.NetLogger.Info("Selected no tls mode")
 		err := httpServer.ListenAndServe()
 
 		if err != nil {
-			networkApp.NetLogger.Error("Failed to start noSSL mode on http server with error %v", err)
+			networkApp.NetLogger.Errorf("Failed to start noSSL mode on http server with error %v", err)
 		}
 	}
 }
@@ -0,0 +1,82 @@
+package access
+
+import (
+	"github.com/delonce/socialnetwork/internal/config"
+	"github.com/delonce/socialnetwork/internal/service/friends"
+	"github.com/delonce/socialnetwork/pkg/logging"
+)
+
+type RulePolicy struct {
+	relations   Relations
+	memberships Memberships
+}
+
+func NewPolicy(logger *logging.Logger, config *config.Config) (Policy, error) {
+	friendView, err := friends.NewFriendViewer(logger, config)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return NewRelationsPolicy(friendView), nil
+}
+
+// NewRelationsPolicy builds the policy over already known relations, services
+// holding a friend viewer use it to avoid one more connection
+func NewRelationsPolicy(relations Relations) Policy {
+	return &RulePolicy{
+		relations:   relations,
+		memberships: noMemberships{},
+	}
+}
+
+// NewGroupPolicy builds the policy which knows group memberships as well,
+// without them every group action is denied
+func NewGroupPolicy(relations Relations, memberships Memberships) Policy {
+	return &RulePolicy{
+		relations:   relations,
+		memberships: memberships,
+	}
+}
+
+// Authorize returns nil when any rule of the action grants it, otherwise the
+// error of the first failed condition of the last rule
+func (policy *RulePolicy) Authorize(subject string, action string, resource string) error {
+	actionRules, ok := rules[action]
+
+	if !ok {
+		return ErrUnknownAction
+	}
+
+	var err error
+
+	for _, actionRule := range actionRules {
+		if err = policy.check(actionRule, subject, resource); err == nil {
+			return nil
+		}
+	}
+
+	return err
+}
+
+func (policy *RulePolicy) Can(subject string, action string, resource string) bool {
+	return policy.Authorize(subject, action, resource) == nil
+}
+
+func (policy *RulePolicy) check(actionRule rule, subject string, resource string) error {
+	for _, cond := range actionRule {
+		if !cond.check(policy, subject, resource) {
+			return cond.err
+		}
+	}
+
+	return nil
+}
+
+// noMemberships is used by policies built without groups
+type noMemberships struct{}
+
+func (noMemberships) IsGroupMember(username string, groupID string) bool { return false }
+func (noMemberships) IsGroupAdmin(username string, groupID string) bool  { return false }
+func (noMemberships) IsGroupOwner(username string, groupID string) bool  { return false }
+func (noMemberships) IsOpenGroup(groupID string) bool                    { return false }
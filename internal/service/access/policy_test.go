@@ -0,0 +1,201 @@
+package access
+
+import (
+	"testing"
+
+	"github.com/delonce/socialnetwork/internal/service/friends"
+)
+
+// fakeRelations knows that alice is friends with bob, dave and erin, alice
+// blocked dave and erin blocked alice
+type fakeRelations struct {
+	friends map[string]bool
+	blocks  map[string]bool
+}
+
+func newFakeRelations() *fakeRelations {
+	return &fakeRelations{
+		friends: map[string]bool{
+			"alice/bob":  true,
+			"alice/dave": true,
+			"alice/erin": true,
+		},
+		blocks: map[string]bool{
+			"alice/dave": true,
+			"erin/alice": true,
+		},
+	}
+}
+
+func (relations *fakeRelations) CheckFriend(from string, to string) bool {
+	return relations.friends[from+"/"+to] || relations.friends[to+"/"+from]
+}
+
+func (relations *fakeRelations) CheckBlock(blocker string, blocked string) bool {
+	return relations.blocks[blocker+"/"+blocked]
+}
+
+func (relations *fakeRelations) CheckBlockedPair(first string, second string) bool {
+	return relations.CheckBlock(first, second) || relations.CheckBlock(second, first)
+}
+
+// fakeMemberships knows the open group owned by alice with bob as admin and
+// carol as member, and the closed group where alice is the only member
+type fakeMemberships struct {
+	roles map[string]map[string]string
+	open  map[string]bool
+}
+
+func newFakeMemberships() *fakeMemberships {
+	return &fakeMemberships{
+		roles: map[string]map[string]string{
+			"open":   {"alice": "owner", "bob": "admin", "carol": "member"},
+			"closed": {"alice": "owner"},
+		},
+		open: map[string]bool{"open": true},
+	}
+}
+
+func (memberships *fakeMemberships) IsGroupMember(username string, groupID string) bool {
+	return memberships.roles[groupID][username] != ""
+}
+
+func (memberships *fakeMemberships) IsGroupAdmin(username string, groupID string) bool {
+	role := memberships.roles[groupID][username]
+	return role == "admin" || role == "owner"
+}
+
+func (memberships *fakeMemberships) IsGroupOwner(username string, groupID string) bool {
+	return memberships.roles[groupID][username] == "owner"
+}
+
+func (memberships *fakeMemberships) IsOpenGroup(groupID string) bool {
+	return memberships.open[groupID]
+}
+
+type policyCase struct {
+	name     string
+	subject  string
+	resource string
+	err      error
+}
+
+// friendOnlyCases cover rules made of notSelf, notBlockedPair and isFriend
+var friendOnlyCases = []policyCase{
+	{"friend", "alice", "bob", nil},
+	{"self", "alice", "alice", ErrSelfAction},
+	{"blocked by subject", "alice", "dave", friends.ErrBlocked},
+	{"blocked by resource", "dave", "alice", friends.ErrBlocked},
+	{"not friend", "alice", "carol", friends.ErrNotFriends},
+}
+
+// selfOrFriendCases cover rules allowing the owner or a friend not in a block
+var selfOrFriendCases = []policyCase{
+	{"self", "alice", "alice", nil},
+	{"friend", "alice", "bob", nil},
+	{"blocked friend", "alice", "dave", friends.ErrBlocked},
+	{"not friend", "alice", "carol", friends.ErrNotFriends},
+}
+
+// notSelfNotBlockedCases cover rules which don't require a friendship
+var notSelfNotBlockedCases = []policyCase{
+	{"stranger", "alice", "carol", nil},
+	{"self", "alice", "alice", ErrSelfAction},
+	{"blocked by subject", "alice", "dave", friends.ErrBlocked},
+	{"blocked by resource", "alice", "erin", friends.ErrBlocked},
+}
+
+var policyCases = map[string][]policyCase{
+	ActionViewDialog:   friendOnlyCases,
+	ActionSendMessage:  friendOnlyCases,
+	ActionReactMessage: friendOnlyCases,
+	ActionVotePoll:     friendOnlyCases,
+	ActionInviteEvent:  friendOnlyCases,
+
+	ActionViewProfile: {
+		{"self", "alice", "alice", nil},
+		{"stranger", "carol", "alice", nil},
+		{"blocked by subject only", "alice", "dave", nil},
+		{"blocked by owner", "alice", "erin", friends.ErrBlocked},
+	},
+	ActionWriteGuestbook: selfOrFriendCases,
+	ActionViewStory:      selfOrFriendCases,
+
+	ActionSendFriendRequest: notSelfNotBlockedCases,
+	ActionFollow:            notSelfNotBlockedCases,
+	ActionChangeFriendship: {
+		{"other user", "alice", "dave", nil},
+		{"self", "alice", "alice", ErrSelfAction},
+	},
+
+	ActionJoinGroup: {
+		{"not member", "dave", "open", nil},
+		{"member", "carol", "open", ErrGroupMember},
+	},
+	ActionViewGroupBoard: {
+		{"open group", "dave", "open", nil},
+		{"member of closed group", "alice", "closed", nil},
+		{"stranger in closed group", "dave", "closed", ErrNotGroupMember},
+	},
+	ActionPostGroupBoard: {
+		{"member", "carol", "open", nil},
+		{"not member", "dave", "open", ErrNotGroupMember},
+	},
+	ActionModerateGroup: {
+		{"admin", "bob", "open", nil},
+		{"owner", "alice", "open", nil},
+		{"member", "carol", "open", ErrGroupRights},
+	},
+	ActionManageGroup: {
+		{"owner", "alice", "open", nil},
+		{"admin", "bob", "open", ErrGroupRights},
+	},
+}
+
+func TestRulePolicy(t *testing.T) {
+	policy := NewGroupPolicy(newFakeRelations(), newFakeMemberships())
+
+	for action := range rules {
+		if _, ok := policyCases[action]; !ok {
+			t.Errorf("action %s has no test cases", action)
+		}
+	}
+
+	for action, cases := range policyCases {
+		for _, testCase := range cases {
+			t.Run(action+"/"+testCase.name, func(t *testing.T) {
+				err := policy.Authorize(testCase.subject, action, testCase.resource)
+
+				if err != testCase.err {
+					t.Errorf("Authorize(%s, %s) = %v, want %v", testCase.subject, testCase.resource, err, testCase.err)
+				}
+
+				if can := policy.Can(testCase.subject, action, testCase.resource); can != (testCase.err == nil) {
+					t.Errorf("Can(%s, %s) = %v, want %v", testCase.subject, testCase.resource, can, testCase.err == nil)
+				}
+			})
+		}
+	}
+}
+
+func TestUnknownAction(t *testing.T) {
+	policy := NewGroupPolicy(newFakeRelations(), newFakeMemberships())
+
+	if err := policy.Authorize("alice", "unknown", "bob"); err != ErrUnknownAction {
+		t.Errorf("Authorize = %v, want %v", err, ErrUnknownAction)
+	}
+
+	if policy.Can("alice", "unknown", "bob") {
+		t.Error("Can allowed an unknown action")
+	}
+}
+
+func TestRelationsPolicyDeniesGroupActions(t *testing.T) {
+	policy := NewRelationsPolicy(newFakeRelations())
+
+	for _, action := range []string{ActionViewGroupBoard, ActionPostGroupBoard, ActionModerateGroup, ActionManageGroup} {
+		if policy.Can("alice", action, "open") {
+			t.Errorf("policy without memberships allowed %s", action)
+		}
+	}
+}
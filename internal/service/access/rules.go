@@ -0,0 +1,110 @@
+package access
+
+import "github.com/delonce/socialnetwork/internal/service/friends"
+
+// condition is one requirement of a rule and the error returned when it
+// doesn't hold
+type condition struct {
+	check func(policy *RulePolicy, subject string, resource string) bool
+	err   error
+}
+
+// rule grants an action when all its conditions hold
+type rule []condition
+
+var (
+	isSelf = condition{
+		check: func(policy *RulePolicy, subject string, resource string) bool {
+			return subject == resource
+		},
+		err: ErrNotOwner,
+	}
+
+	notSelf = condition{
+		check: func(policy *RulePolicy, subject string, resource string) bool {
+			return subject != resource
+		},
+		err: ErrSelfAction,
+	}
+
+	isFriend = condition{
+		check: func(policy *RulePolicy, subject string, resource string) bool {
+			return policy.relations.CheckFriend(subject, resource)
+		},
+		err: friends.ErrNotFriends,
+	}
+
+	notBlockedPair = condition{
+		check: func(policy *RulePolicy, subject string, resource string) bool {
+			return !policy.relations.CheckBlockedPair(subject, resource)
+		},
+		err: friends.ErrBlocked,
+	}
+
+	notBlockedByOwner = condition{
+		check: func(policy *RulePolicy, subject string, resource string) bool {
+			return !policy.relations.CheckBlock(resource, subject)
+		},
+		err: friends.ErrBlocked,
+	}
+
+	isGroupMember = condition{
+		check: func(policy *RulePolicy, subject string, resource string) bool {
+			return policy.memberships.IsGroupMember(subject, resource)
+		},
+		err: ErrNotGroupMember,
+	}
+
+	notGroupMember = condition{
+		check: func(policy *RulePolicy, subject string, resource string) bool {
+			return !policy.memberships.IsGroupMember(subject, resource)
+		},
+		err: ErrGroupMember,
+	}
+
+	isGroupAdmin = condition{
+		check: func(policy *RulePolicy, subject string, resource string) bool {
+			return policy.memberships.IsGroupAdmin(subject, resource)
+		},
+		err: ErrGroupRights,
+	}
+
+	isGroupOwner = condition{
+		check: func(policy *RulePolicy, subject string, resource string) bool {
+			return policy.memberships.IsGroupOwner(subject, resource)
+		},
+		err: ErrGroupRights,
+	}
+
+	isOpenGroup = condition{
+		check: func(policy *RulePolicy, subject string, resource string) bool {
+			return policy.memberships.IsOpenGroup(resource)
+		},
+		err: ErrNotGroupMember,
+	}
+)
+
+// rules lists the alternatives granting every action, an action missing
+// here is always denied
+var rules = map[string][]rule{
+	ActionViewDialog:   {{notSelf, notBlockedPair, isFriend}},
+	ActionSendMessage:  {{notSelf, notBlockedPair, isFriend}},
+	ActionReactMessage: {{notSelf, notBlockedPair, isFriend}},
+	ActionVotePoll:     {{notSelf, notBlockedPair, isFriend}},
+
+	ActionViewProfile:    {{isSelf}, {notBlockedByOwner}},
+	ActionWriteGuestbook: {{isSelf}, {notBlockedPair, isFriend}},
+	ActionViewStory:      {{isSelf}, {notBlockedPair, isFriend}},
+
+	ActionSendFriendRequest: {{notSelf, notBlockedPair}},
+	ActionChangeFriendship:  {{notSelf}},
+	ActionFollow:            {{notSelf, notBlockedPair}},
+
+	ActionInviteEvent: {{notSelf, notBlockedPair, isFriend}},
+
+	ActionJoinGroup:      {{notGroupMember}},
+	ActionViewGroupBoard: {{isOpenGroup}, {isGroupMember}},
+	ActionPostGroupBoard: {{isGroupMember}},
+	ActionModerateGroup:  {{isGroupAdmin}},
+	ActionManageGroup:    {{isGroupOwner}},
+}
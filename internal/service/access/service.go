@@ -0,0 +1,66 @@
+package access
+
+import "errors"
+
+// Actions a user may perform on another user, their resource is the
+// username of that other user
+const (
+	ActionViewDialog   = "dialog.view"
+	ActionSendMessage  = "dialog.send"
+	ActionReactMessage = "dialog.react"
+	ActionVotePoll     = "dialog.vote"
+
+	ActionViewProfile    = "profile.view"
+	ActionWriteGuestbook = "profile.guestbook"
+	ActionViewStory      = "profile.story"
+
+	ActionSendFriendRequest = "friends.request"
+	ActionChangeFriendship  = "friends.change"
+	ActionFollow            = "friends.follow"
+
+	ActionInviteEvent = "event.invite"
+)
+
+// Group actions, their resource is the group id
+const (
+	ActionJoinGroup      = "group.join"
+	ActionViewGroupBoard = "group.board.view"
+	ActionPostGroupBoard = "group.board.post"
+	ActionModerateGroup  = "group.moderate"
+	ActionManageGroup    = "group.manage"
+)
+
+var (
+	ErrSelfAction    = errors.New("Can't do this with yourself")
+	ErrNotOwner      = errors.New("Only the owner can do this")
+	ErrUnknownAction = errors.New("Unknown action")
+
+	ErrGroupMember    = errors.New("Already a member of the group")
+	ErrNotGroupMember = errors.New("Not a member of the group")
+	ErrGroupRights    = errors.New("Not enough rights in the group")
+)
+
+// Policy is the single place deciding whether a subject may perform an
+// action on a resource, handlers and services must ask it instead of
+// checking friendships on their own
+type Policy interface {
+	Authorize(subject string, action string, resource string) error
+	Can(subject string, action string, resource string) bool
+}
+
+// Relations are the facts about two users the rules are built on,
+// friends.FriendViewer provides all of them
+type Relations interface {
+	CheckFriend(from string, to string) bool
+	CheckBlock(blocker string, blocked string) bool
+	CheckBlockedPair(first string, second string) bool
+}
+
+// Memberships are the facts about a user in a group, admin rights belong
+// to the owner as well
+type Memberships interface {
+	IsGroupMember(username string, groupID string) bool
+	IsGroupAdmin(username string, groupID string) bool
+	IsGroupOwner(username string, groupID string) bool
+	IsOpenGroup(groupID string) bool
+}
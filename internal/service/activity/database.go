@@ -0,0 +1,87 @@
+package activity
+
+import (
+	"context"
+	"time"
+
+	"github.com/delonce/socialnetwork/internal/database"
+	"github.com/delonce/socialnetwork/internal/database/mongodb"
+	"github.com/delonce/socialnetwork/internal/service"
+	"github.com/delonce/socialnetwork/pkg/logging"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ActivityQueries interface {
+	AddEvent(ctx context.Context, event *service.ActivityEvent) (string, error)
+
+	// GetEventsOf returns events done by or with the users, older than
+	// the event identified by beforeDate and beforeID when they are set
+	GetEventsOf(ctx context.Context, userIDs []string, beforeDate time.Time, beforeID string, limit int64) (*mongo.Cursor, error)
+}
+
+type ActivityDB struct {
+	Storage database.DBStorage
+	Logger  *logging.Logger
+}
+
+func NewActivityDB(logger *logging.Logger, database *mongo.Database) ActivityQueries {
+	storage := mongodb.NewStorage(
+		map[string]*mongo.Collection{
+			service.ACTIVITY_COLLECTION: database.Collection(service.ACTIVITY_COLLECTION),
+		},
+		logger,
+	)
+
+	return &ActivityDB{
+		Storage: storage,
+		Logger:  logger,
+	}
+}
+
+func (activityStorage *ActivityDB) AddEvent(ctx context.Context, event *service.ActivityEvent) (string, error) {
+	st := activityStorage.Storage
+
+	return st.CreateObject(ctx, event, service.ACTIVITY_COLLECTION)
+}
+
+func (activityStorage *ActivityDB) GetEventsOf(ctx context.Context, userIDs []string, beforeDate time.Time,
+	beforeID string, limit int64) (*mongo.Cursor, error) {
+
+	st := activityStorage.Storage
+
+	conditions := []bson.M{
+		{"$or": []bson.M{
+			{"actor": bson.M{"$in": userIDs}},
+			{"subject": bson.M{"$in": userIDs}},
+		}},
+	}
+
+	if !beforeDate.IsZero() {
+		objBeforeID, err := primitive.ObjectIDFromHex(beforeID)
+
+		if err != nil {
+			return nil, err
+		}
+
+		conditions = append(conditions, bson.M{
+			"$or": []bson.M{
+				{"date": bson.M{"$lt": beforeDate}},
+				{"$and": []bson.M{
+					{"date": beforeDate},
+					{"_id": bson.M{"$lt": objBeforeID}},
+				}},
+			},
+		})
+	}
+
+	findOpts := options.FindOptions{}
+
+	findOpts.SetSort(bson.D{{Key: "date", Value: -1}, {Key: "_id", Value: -1}})
+	findOpts.SetLimit(limit)
+
+	return st.FindObjects(ctx, bson.M{"$and": conditions}, service.ACTIVITY_COLLECTION, &findOpts)
+}
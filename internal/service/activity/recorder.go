@@ -0,0 +1,39 @@
+package activity
+
+import (
+	"context"
+	"time"
+
+	"github.com/delonce/socialnetwork/internal/service"
+	"github.com/delonce/socialnetwork/pkg/logging"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type ActivityRecorder struct {
+	activityDatabase ActivityQueries
+	logger           *logging.Logger
+	context          context.Context
+}
+
+// NewRecorder shares the database of the calling service, like resolvers
+// of user ids do
+func NewRecorder(logger *logging.Logger, database *mongo.Database) Recorder {
+	return &ActivityRecorder{
+		activityDatabase: NewActivityDB(logger, database),
+		logger:           logger,
+	}
+}
+
+func (recorder *ActivityRecorder) Record(event service.ActivityEvent) {
+	if event.DateAt.IsZero() {
+		event.DateAt = time.Now()
+	}
+
+	// mongo keeps milliseconds only, cursors compare dates as stored
+	event.DateAt = event.DateAt.Truncate(time.Millisecond)
+
+	if _, err := recorder.activityDatabase.AddEvent(recorder.context, &event); err != nil {
+		recorder.logger.Errorf("Failed to record %s activity of %s, %v", event.Type, event.Actor, err)
+	}
+}
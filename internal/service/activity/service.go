@@ -0,0 +1,15 @@
+package activity
+
+import "github.com/delonce/socialnetwork/internal/service"
+
+const (
+	TypeFriendship = "friendship"
+	TypeUsername   = "username"
+	TypeProfile    = "profile"
+)
+
+// Recorder saves events after the operation which caused them succeeded,
+// a failed record is logged and never fails the operation itself
+type Recorder interface {
+	Record(event service.ActivityEvent)
+}
@@ -0,0 +1,116 @@
+package contacts
+
+import (
+	"context"
+
+	"github.com/delonce/socialnetwork/internal/config"
+	"github.com/delonce/socialnetwork/internal/service"
+	"github.com/delonce/socialnetwork/internal/service/friends"
+	"github.com/delonce/socialnetwork/internal/service/user"
+	"github.com/delonce/socialnetwork/pkg/logging"
+)
+
+type ContactFinderService struct {
+	userDatabase user.UserQueries
+	friendView   friends.FriendViewer
+	logger       *logging.Logger
+	context      context.Context
+}
+
+func NewContactFinder(logger *logging.Logger, config *config.Config) (ContactFinder, error) {
+	database, err := service.InitNewDatabase(config)
+
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	friendView, err := friends.NewFriendViewer(logger, config)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &ContactFinderService{
+		userDatabase: user.NewUserDB(logger, database),
+		friendView:   friendView,
+		logger:       logger,
+	}, nil
+}
+
+// FindContacts looks accounts up by hashes of normalized emails in one query,
+// the uploader and users from blocked pairs are never returned
+func (finder *ContactFinderService) FindContacts(username string, file []byte, format string) ([]service.ContactMatch, error) {
+	if len(file) > MaxFileSize {
+		return nil, ErrFileTooLarge
+	}
+
+	contacts, err := parseContacts(file, format)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(contacts) == 0 {
+		return nil, ErrNoEmails
+	}
+
+	if len(contacts) > MaxContacts {
+		return nil, ErrTooManyContacts
+	}
+
+	contactNames := map[string]string{}
+	emailHashes := []string{}
+
+	for _, contact := range contacts {
+		emailHash := user.HashEmail(contact.email)
+
+		if _, ok := contactNames[emailHash]; !ok {
+			emailHashes = append(emailHashes, emailHash)
+		}
+
+		if contactNames[emailHash] == "" {
+			contactNames[emailHash] = contact.name
+		}
+	}
+
+	cursor, err := finder.userDatabase.FindUsersByEmailHashes(finder.context, emailHashes)
+
+	if err != nil {
+		return nil, err
+	}
+
+	users := []service.User{}
+
+	if err = cursor.All(finder.context, &users); err != nil {
+		return nil, err
+	}
+
+	friendUsernames := map[string]bool{}
+	requestedUsernames := map[string]bool{}
+
+	for _, friend := range finder.friendView.GetUserFriends(username) {
+		friendUsernames[friend] = true
+	}
+
+	for _, request := range finder.friendView.GetOutgoingRequests(username) {
+		requestedUsernames[request.To] = true
+	}
+
+	matches := []service.ContactMatch{}
+
+	for _, matchedUser := range users {
+		if matchedUser.Username == username || finder.friendView.CheckBlockedPair(username, matchedUser.Username) {
+			continue
+		}
+
+		matches = append(matches, service.ContactMatch{
+			Username:      matchedUser.Username,
+			ContactName:   contactNames[matchedUser.EmailHash],
+			IsFriend:      friendUsernames[matchedUser.Username],
+			IsRequestSent: requestedUsernames[matchedUser.Username],
+		})
+	}
+
+	return matches, nil
+}
@@ -0,0 +1,175 @@
+package contacts
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+type contact struct {
+	name  string
+	email string
+}
+
+// DetectFormat guesses the format by the file extension and falls back to
+// the content for files uploaded without one
+func DetectFormat(filename string, file []byte) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".vcf", ".vcard":
+		return FORMAT_VCARD
+	case ".csv":
+		return FORMAT_CSV
+	}
+
+	if bytes.HasPrefix(bytes.ToUpper(bytes.TrimSpace(file)), []byte("BEGIN:VCARD")) {
+		return FORMAT_VCARD
+	}
+
+	return FORMAT_CSV
+}
+
+func parseContacts(file []byte, format string) ([]contact, error) {
+	switch format {
+	case FORMAT_VCARD:
+		return parseVCard(bytes.NewReader(file))
+	case FORMAT_CSV:
+		return parseCSV(bytes.NewReader(file))
+	}
+
+	return nil, ErrUnknownFormat
+}
+
+// parseVCard reads FN and EMAIL properties of every card, folded lines are
+// joined back before parsing
+func parseVCard(r io.Reader) ([]contact, error) {
+	lines := []string{}
+	scanner := bufio.NewScanner(r)
+
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) != 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+
+		lines = append(lines, line)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	contacts := []contact{}
+	name := ""
+	emails := []string{}
+
+	for _, line := range lines {
+		separator := strings.Index(line, ":")
+
+		if separator < 0 {
+			continue
+		}
+
+		property := strings.ToUpper(strings.SplitN(line[:separator], ";", 2)[0])
+		value := strings.TrimSpace(line[separator+1:])
+
+		if dot := strings.LastIndex(property, "."); dot >= 0 {
+			property = property[dot+1:]
+		}
+
+		switch {
+		case property == "BEGIN":
+			name, emails = "", []string{}
+		case property == "FN":
+			name = value
+		case property == "EMAIL":
+			emails = append(emails, strings.TrimPrefix(value, "mailto:"))
+		case property == "END":
+			for _, email := range emails {
+				contacts = append(contacts, contact{name: name, email: email})
+			}
+
+			name, emails = "", []string{}
+		}
+	}
+
+	return contacts, nil
+}
+
+// parseCSV takes emails from columns named like "E-mail" and names from
+// "Name" columns, files without a known header are scanned for emails
+func parseCSV(r io.Reader) ([]contact, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	records, err := reader.ReadAll()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(records) == 0 {
+		return []contact{}, nil
+	}
+
+	emailColumns := []int{}
+	nameColumns := []int{}
+
+	for i, title := range records[0] {
+		title = strings.ToLower(strings.TrimSpace(title))
+
+		switch {
+		case strings.Contains(title, "mail"):
+			emailColumns = append(emailColumns, i)
+		case strings.Contains(title, "name"):
+			nameColumns = append(nameColumns, i)
+		}
+	}
+
+	contacts := []contact{}
+
+	if len(emailColumns) == 0 {
+		for _, record := range records {
+			for _, field := range record {
+				if isEmail(field) {
+					contacts = append(contacts, contact{email: strings.TrimSpace(field)})
+				}
+			}
+		}
+
+		return contacts, nil
+	}
+
+	for _, record := range records[1:] {
+		nameParts := []string{}
+
+		for _, column := range nameColumns {
+			if column < len(record) && strings.TrimSpace(record[column]) != "" {
+				nameParts = append(nameParts, strings.TrimSpace(record[column]))
+			}
+		}
+
+		for _, column := range emailColumns {
+			if column < len(record) && isEmail(record[column]) {
+				contacts = append(contacts, contact{
+					name:  strings.Join(nameParts, " "),
+					email: strings.TrimSpace(record[column]),
+				})
+			}
+		}
+	}
+
+	return contacts, nil
+}
+
+func isEmail(value string) bool {
+	value = strings.TrimSpace(value)
+	at := strings.Index(value, "@")
+
+	return at > 0 && at < len(value)-1 && !strings.ContainsAny(value, " \t")
+}
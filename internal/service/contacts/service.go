@@ -0,0 +1,30 @@
+package contacts
+
+import (
+	"errors"
+
+	"github.com/delonce/socialnetwork/internal/service"
+)
+
+const (
+	FORMAT_VCARD = "vcf"
+	FORMAT_CSV   = "csv"
+)
+
+const (
+	MaxFileSize = 1 << 20
+	MaxContacts = 5000
+)
+
+var (
+	ErrUnknownFormat   = errors.New("Contacts file must be a vCard or CSV")
+	ErrFileTooLarge    = errors.New("Contacts file is too large")
+	ErrNoEmails        = errors.New("Contacts file doesn't contain emails")
+	ErrTooManyContacts = errors.New("Contacts file contains too many contacts")
+)
+
+// ContactFinder matches uploaded contacts with accounts, the uploaded file is
+// only read in memory and contacts without an account are dropped
+type ContactFinder interface {
+	FindContacts(username string, file []byte, format string) ([]service.ContactMatch, error)
+}
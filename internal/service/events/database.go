@@ -0,0 +1,306 @@
+package events
+
+import (
+	"context"
+	"time"
+
+	"github.com/delonce/socialnetwork/internal/database"
+	"github.com/delonce/socialnetwork/internal/database/mongodb"
+	"github.com/delonce/socialnetwork/internal/service"
+	"github.com/delonce/socialnetwork/pkg/logging"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type EventQueries interface {
+	CreateEvent(ctx context.Context, event *service.Event) (string, error)
+	GetEvent(ctx context.Context, eventID string) (*mongo.SingleResult, error)
+	GetUpcomingEvents(ctx context.Context, eventIDs []string, since time.Time) (*mongo.Cursor, error)
+	DeleteEvent(ctx context.Context, eventID string, creatorID string) error
+
+	ClaimDueReminders(ctx context.Context, instanceID string, lease time.Duration) (*mongo.SingleResult, error)
+	SetReminderStatus(ctx context.Context, eventID string, instanceID string, status string) error
+
+	AddGuest(ctx context.Context, guest *service.EventGuest) (bool, error)
+	GetGuest(ctx context.Context, eventID string, userID string) (*mongo.SingleResult, error)
+	GetGuests(ctx context.Context, eventID string, statuses []string) (*mongo.Cursor, error)
+	GetUserInvitations(ctx context.Context, userID string) (*mongo.Cursor, error)
+	SetGuestStatus(ctx context.Context, eventID string, userID string, status string) error
+	DeleteGuests(ctx context.Context, eventID string) error
+
+	AddReminder(ctx context.Context, reminder *service.EventReminder) (bool, error)
+	GetReminders(ctx context.Context, userID string) (*mongo.Cursor, error)
+	DeleteReminder(ctx context.Context, eventID string, userID string) error
+	DeleteReminders(ctx context.Context, eventID string) error
+
+	EnsureIndexes(ctx context.Context) error
+}
+
+type EventDB struct {
+	Storage database.DBStorage
+	Logger  *logging.Logger
+}
+
+func NewEventDB(logger *logging.Logger, database *mongo.Database) EventQueries {
+	storage := mongodb.NewStorage(
+		map[string]*mongo.Collection{
+			service.EVENT_COLLECTION:          database.Collection(service.EVENT_COLLECTION),
+			service.EVENT_GUEST_COLLECTION:    database.Collection(service.EVENT_GUEST_COLLECTION),
+			service.EVENT_REMINDER_COLLECTION: database.Collection(service.EVENT_REMINDER_COLLECTION),
+		},
+		logger,
+	)
+
+	return &EventDB{
+		Storage: storage,
+		Logger:  logger,
+	}
+}
+
+func (eventStorage *EventDB) CreateEvent(ctx context.Context, event *service.Event) (string, error) {
+	st := eventStorage.Storage
+
+	return st.CreateObject(ctx, event, service.EVENT_COLLECTION)
+}
+
+func (eventStorage *EventDB) GetEvent(ctx context.Context, eventID string) (*mongo.SingleResult, error) {
+	st := eventStorage.Storage
+	objEventID, err := primitive.ObjectIDFromHex(eventID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return st.FindOneObject(ctx, bson.M{"_id": objEventID}, service.EVENT_COLLECTION)
+}
+
+// GetUpcomingEvents returns the events which haven't finished by since,
+// an event without the end finishes when it starts
+func (eventStorage *EventDB) GetUpcomingEvents(ctx context.Context, eventIDs []string, since time.Time) (*mongo.Cursor, error) {
+	st := eventStorage.Storage
+	objEventIDs := []primitive.ObjectID{}
+
+	for _, eventID := range eventIDs {
+		objEventID, err := primitive.ObjectIDFromHex(eventID)
+
+		if err != nil {
+			continue
+		}
+
+		objEventIDs = append(objEventIDs, objEventID)
+	}
+
+	query := bson.M{
+		"$and": []bson.M{
+			{"_id": bson.M{"$in": objEventIDs}},
+			{"$or": []bson.M{
+				{"endsat": bson.M{"$gte": since}},
+				{"$and": []bson.M{
+					{"endsat": bson.M{"$exists": false}},
+					{"startsat": bson.M{"$gte": since}},
+				}},
+			}},
+		},
+	}
+
+	findOpts := options.FindOptions{}
+	findOpts.SetSort(bson.D{{Key: "startsat", Value: 1}})
+
+	return st.FindObjects(ctx, query, service.EVENT_COLLECTION, &findOpts)
+}
+
+func (eventStorage *EventDB) DeleteEvent(ctx context.Context, eventID string, creatorID string) error {
+	st := eventStorage.Storage
+	objEventID, err := primitive.ObjectIDFromHex(eventID)
+
+	if err != nil {
+		return err
+	}
+
+	query := bson.M{
+		"$and": []bson.M{
+			{"_id": objEventID},
+			{"creator": creatorID},
+		},
+	}
+
+	return st.Delete(ctx, query, service.EVENT_COLLECTION)
+}
+
+// ClaimDueReminders takes one event whose reminders are due, an event left
+// in sending by a stopped replica is taken again after its lease
+func (eventStorage *EventDB) ClaimDueReminders(ctx context.Context, instanceID string, lease time.Duration) (*mongo.SingleResult, error) {
+	st := eventStorage.Storage
+	now := time.Now()
+
+	query := bson.M{
+		"$or": []bson.M{
+			{"$and": []bson.M{
+				{"reminderstatus": reminderPending},
+				{"remindat": bson.M{"$lte": now}},
+			}},
+
+			{"$and": []bson.M{
+				{"reminderstatus": reminderSending},
+				{"lockeduntil": bson.M{"$lt": now}},
+			}},
+		},
+	}
+
+	model := bson.M{
+		"reminderstatus": reminderSending,
+		"lockedby":       instanceID,
+		"lockeduntil":    now.Add(lease),
+	}
+
+	return st.FindOneAndUpdate(ctx, query, model, service.EVENT_COLLECTION)
+}
+
+func (eventStorage *EventDB) SetReminderStatus(ctx context.Context, eventID string, instanceID string, status string) error {
+	st := eventStorage.Storage
+	objEventID, err := primitive.ObjectIDFromHex(eventID)
+
+	if err != nil {
+		return err
+	}
+
+	query := bson.M{
+		"$and": []bson.M{
+			{"_id": objEventID},
+			{"lockedby": instanceID},
+		},
+	}
+
+	return st.Update(ctx, query, bson.M{"reminderstatus": status}, service.EVENT_COLLECTION)
+}
+
+// AddGuest reports false when the user is already invited
+func (eventStorage *EventDB) AddGuest(ctx context.Context, guest *service.EventGuest) (bool, error) {
+	st := eventStorage.Storage
+
+	query := bson.M{
+		"eventid": guest.EventID,
+		"user":    guest.User,
+	}
+
+	return st.InsertIfAbsent(ctx, query, guest, service.EVENT_GUEST_COLLECTION)
+}
+
+func (eventStorage *EventDB) GetGuest(ctx context.Context, eventID string, userID string) (*mongo.SingleResult, error) {
+	st := eventStorage.Storage
+
+	return st.FindOneObject(ctx, guestQuery(eventID, userID), service.EVENT_GUEST_COLLECTION)
+}
+
+// GetGuests returns guests with the given answers, all guests when
+// statuses is nil
+func (eventStorage *EventDB) GetGuests(ctx context.Context, eventID string, statuses []string) (*mongo.Cursor, error) {
+	st := eventStorage.Storage
+	query := bson.M{"eventid": eventID}
+
+	if statuses != nil {
+		query = bson.M{
+			"$and": []bson.M{
+				{"eventid": eventID},
+				{"status": bson.M{"$in": statuses}},
+			},
+		}
+	}
+
+	findOpts := options.FindOptions{}
+	findOpts.SetSort(bson.D{{Key: "date", Value: 1}})
+
+	return st.FindObjects(ctx, query, service.EVENT_GUEST_COLLECTION, &findOpts)
+}
+
+func (eventStorage *EventDB) GetUserInvitations(ctx context.Context, userID string) (*mongo.Cursor, error) {
+	st := eventStorage.Storage
+
+	return st.FindObjects(ctx, bson.M{"user": userID}, service.EVENT_GUEST_COLLECTION)
+}
+
+func (eventStorage *EventDB) SetGuestStatus(ctx context.Context, eventID string, userID string, status string) error {
+	st := eventStorage.Storage
+
+	model := bson.M{
+		"status": status,
+		"date":   time.Now(),
+	}
+
+	return st.Update(ctx, guestQuery(eventID, userID), model, service.EVENT_GUEST_COLLECTION)
+}
+
+func (eventStorage *EventDB) DeleteGuests(ctx context.Context, eventID string) error {
+	st := eventStorage.Storage
+
+	return st.DeleteMany(ctx, bson.M{"eventid": eventID}, service.EVENT_GUEST_COLLECTION)
+}
+
+// AddReminder reports false when the guest already has the reminder, so a
+// repeated sending after a lost lease doesn't double it
+func (eventStorage *EventDB) AddReminder(ctx context.Context, reminder *service.EventReminder) (bool, error) {
+	st := eventStorage.Storage
+
+	query := bson.M{
+		"eventid": reminder.EventID,
+		"user":    reminder.User,
+	}
+
+	return st.InsertIfAbsent(ctx, query, reminder, service.EVENT_REMINDER_COLLECTION)
+}
+
+func (eventStorage *EventDB) GetReminders(ctx context.Context, userID string) (*mongo.Cursor, error) {
+	st := eventStorage.Storage
+
+	query := bson.M{
+		"$and": []bson.M{
+			{"user": userID},
+			{"expiresat": bson.M{"$gt": time.Now()}},
+		},
+	}
+
+	findOpts := options.FindOptions{}
+	findOpts.SetSort(bson.D{{Key: "expiresat", Value: 1}})
+
+	return st.FindObjects(ctx, query, service.EVENT_REMINDER_COLLECTION, &findOpts)
+}
+
+func (eventStorage *EventDB) DeleteReminder(ctx context.Context, eventID string, userID string) error {
+	st := eventStorage.Storage
+
+	return st.Delete(ctx, guestQuery(eventID, userID), service.EVENT_REMINDER_COLLECTION)
+}
+
+func (eventStorage *EventDB) DeleteReminders(ctx context.Context, eventID string) error {
+	st := eventStorage.Storage
+
+	return st.DeleteMany(ctx, bson.M{"eventid": eventID}, service.EVENT_REMINDER_COLLECTION)
+}
+
+// EnsureIndexes keeps one invitation and one reminder per guest, reminders
+// are removed by mongo once the event has started
+func (eventStorage *EventDB) EnsureIndexes(ctx context.Context) error {
+	st := eventStorage.Storage
+
+	if err := st.EnsureUniqueIndex(ctx, []string{"eventid", "user"}, service.EVENT_GUEST_COLLECTION); err != nil {
+		return err
+	}
+
+	if err := st.EnsureUniqueIndex(ctx, []string{"eventid", "user"}, service.EVENT_REMINDER_COLLECTION); err != nil {
+		return err
+	}
+
+	return st.EnsureTTLIndex(ctx, "expiresat", service.EVENT_REMINDER_COLLECTION)
+}
+
+func guestQuery(eventID string, userID string) bson.M {
+	return bson.M{
+		"$and": []bson.M{
+			{"eventid": eventID},
+			{"user": userID},
+		},
+	}
+}
@@ -0,0 +1,101 @@
+package events
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/delonce/socialnetwork/internal/service"
+)
+
+const (
+	ICS_CONTENT_TYPE = "text/calendar; charset=utf-8"
+
+	icsTimeLayout = "20060102T150405Z"
+	icsLineLength = 75
+)
+
+var icsEscaper = strings.NewReplacer(
+	"\\", "\\\\",
+	";", "\\;",
+	",", "\\,",
+	"\r\n", "\\n",
+	"\n", "\\n",
+	"\r", "",
+)
+
+// writeICS writes the event as an iCalendar file, times are in utc so the
+// calendar shows them in the zone of its user
+func writeICS(w io.Writer, event *service.Event, host string) error {
+	writer := bufio.NewWriter(w)
+
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//socialnetwork//events//RU",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"BEGIN:VEVENT",
+		"UID:" + event.ID + "@" + host,
+		"DTSTAMP:" + formatICSTime(time.Now()),
+		"CREATED:" + formatICSTime(event.CreatedAt),
+		"DTSTART:" + formatICSTime(event.StartsAt),
+	}
+
+	if event.EndsAt != nil {
+		lines = append(lines, "DTEND:"+formatICSTime(*event.EndsAt))
+	}
+
+	lines = append(lines, "SUMMARY:"+icsEscaper.Replace(event.Title))
+
+	if event.Description != "" {
+		lines = append(lines, "DESCRIPTION:"+icsEscaper.Replace(event.Description))
+	}
+
+	if event.Location != "" {
+		lines = append(lines, "LOCATION:"+icsEscaper.Replace(event.Location))
+	}
+
+	lines = append(lines, "END:VEVENT", "END:VCALENDAR")
+
+	for _, line := range lines {
+		if _, err := writer.WriteString(foldICSLine(line)); err != nil {
+			return err
+		}
+	}
+
+	return writer.Flush()
+}
+
+func formatICSTime(value time.Time) string {
+	return value.UTC().Format(icsTimeLayout)
+}
+
+// foldICSLine splits lines longer than 75 octets into continuation lines
+// starting with a space, never cutting a utf-8 character in two
+func foldICSLine(line string) string {
+	folded := strings.Builder{}
+	limit := icsLineLength
+
+	for len(line) > limit {
+		cut := limit
+
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+
+		folded.WriteString(line[:cut])
+		folded.WriteString("\r\n ")
+		line = line[cut:]
+
+		// the leading space of a continuation line counts as well
+		limit = icsLineLength - 1
+	}
+
+	folded.WriteString(line)
+	folded.WriteString("\r\n")
+
+	return folded.String()
+}
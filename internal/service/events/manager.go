@@ -0,0 +1,267 @@
+package events
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/delonce/socialnetwork/internal/config"
+	"github.com/delonce/socialnetwork/internal/service"
+	"github.com/delonce/socialnetwork/internal/service/access"
+	"github.com/delonce/socialnetwork/internal/service/user"
+	"github.com/delonce/socialnetwork/pkg/logging"
+)
+
+type EventManagerService struct {
+	eventDatabase  EventQueries
+	userResolver   user.Resolver
+	policy         access.Policy
+	logger         *logging.Logger
+	context        context.Context
+	reminderBefore time.Duration
+}
+
+func NewEventManager(logger *logging.Logger, config *config.Config) (EventManager, error) {
+	database, err := service.InitNewDatabase(config)
+
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	policy, err := access.NewPolicy(logger, config)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &EventManagerService{
+		eventDatabase:  NewEventDB(logger, database),
+		userResolver:   user.NewResolver(logger, database),
+		policy:         policy,
+		logger:         logger,
+		reminderBefore: config.Events.ReminderBefore,
+	}, nil
+}
+
+// CreateEvent stores the event with its creator as the first going guest
+func (manager *EventManagerService) CreateEvent(creator string, title string, description string, location string,
+	startsAt time.Time, endsAt *time.Time) (string, error) {
+
+	title = strings.TrimSpace(title)
+	description = strings.TrimSpace(description)
+	location = strings.TrimSpace(location)
+
+	if err := checkEventFields(title, description, location); err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+
+	if !startsAt.After(now) {
+		return "", ErrEventInPast
+	}
+
+	if endsAt != nil && !endsAt.After(startsAt) {
+		return "", ErrEventEnd
+	}
+
+	creatorID, err := manager.userResolver.GetUserID(creator)
+
+	if err != nil {
+		return "", err
+	}
+
+	eDb := manager.eventDatabase
+
+	eventID, err := eDb.CreateEvent(manager.context, &service.Event{
+		Creator:        creatorID,
+		Title:          title,
+		Description:    description,
+		Location:       location,
+		StartsAt:       startsAt,
+		EndsAt:         endsAt,
+		TimeZone:       startsAt.Location().String(),
+		CreatedAt:      now,
+		RemindAt:       startsAt.Add(-manager.reminderBefore),
+		ReminderStatus: reminderPending,
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	_, err = eDb.AddGuest(manager.context, &service.EventGuest{
+		EventID:   eventID,
+		User:      creatorID,
+		Status:    RSVP_GOING,
+		InvitedBy: creatorID,
+		DateAt:    now,
+	})
+
+	return eventID, err
+}
+
+func (manager *EventManagerService) DeleteEvent(username string, eventID string) error {
+	userID, err := manager.userResolver.GetUserID(username)
+
+	if err != nil {
+		return err
+	}
+
+	eDb := manager.eventDatabase
+
+	if err = eDb.DeleteEvent(manager.context, eventID, userID); err != nil {
+		return ErrEventNotFound
+	}
+
+	if err = eDb.DeleteGuests(manager.context, eventID); err != nil {
+		return err
+	}
+
+	return eDb.DeleteReminders(manager.context, eventID)
+}
+
+// InviteFriend lets the creator invite own friends
+func (manager *EventManagerService) InviteFriend(username string, eventID string, friend string) error {
+	userIDs, err := manager.userResolver.GetUserIDs([]string{username, friend})
+
+	if err != nil {
+		return err
+	}
+
+	event, err := findEvent(manager.context, manager.eventDatabase, eventID)
+
+	if err != nil {
+		return err
+	}
+
+	if event.Creator != userIDs[username] {
+		return ErrNotCreator
+	}
+
+	if err = manager.policy.Authorize(username, access.ActionInviteEvent, friend); err != nil {
+		return err
+	}
+
+	isAdded, err := manager.eventDatabase.AddGuest(manager.context, &service.EventGuest{
+		EventID:   eventID,
+		User:      userIDs[friend],
+		Status:    RSVP_INVITED,
+		InvitedBy: userIDs[username],
+		DateAt:    time.Now(),
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if !isAdded {
+		return ErrAlreadyInvited
+	}
+
+	return nil
+}
+
+// SetRSVP stores the answer of a guest, a guest answering after reminders
+// were sent gets the reminder at once
+func (manager *EventManagerService) SetRSVP(username string, eventID string, status string) error {
+	if status != RSVP_GOING && status != RSVP_MAYBE && status != RSVP_DECLINED {
+		return ErrWrongRSVP
+	}
+
+	userID, err := manager.userResolver.GetUserID(username)
+
+	if err != nil {
+		return err
+	}
+
+	eDb := manager.eventDatabase
+	event, err := findEvent(manager.context, eDb, eventID)
+
+	if err != nil {
+		return err
+	}
+
+	if event.Creator == userID {
+		return ErrCreatorRSVP
+	}
+
+	if err = eDb.SetGuestStatus(manager.context, eventID, userID, status); err != nil {
+		return ErrEventNotFound
+	}
+
+	if status == RSVP_DECLINED {
+		// the guest may have no reminder yet
+		eDb.DeleteReminder(manager.context, eventID, userID)
+		return nil
+	}
+
+	if event.ReminderStatus != reminderSent || !event.StartsAt.After(time.Now()) {
+		return nil
+	}
+
+	_, err = eDb.AddReminder(manager.context, newReminder(event, userID))
+
+	return err
+}
+
+func (manager *EventManagerService) DismissReminder(username string, eventID string) error {
+	userID, err := manager.userResolver.GetUserID(username)
+
+	if err != nil {
+		return err
+	}
+
+	if err = manager.eventDatabase.DeleteReminder(manager.context, eventID, userID); err != nil {
+		return ErrReminderNotFound
+	}
+
+	return nil
+}
+
+func checkEventFields(title string, description string, location string) error {
+	if title == "" {
+		return ErrEmptyTitle
+	}
+
+	if utf8.RuneCountInString(title) > MaxTitleLength {
+		return ErrLongTitle
+	}
+
+	if utf8.RuneCountInString(description) > MaxDescriptionLength {
+		return ErrLongDescription
+	}
+
+	if utf8.RuneCountInString(location) > MaxLocationLength {
+		return ErrLongLocation
+	}
+
+	return nil
+}
+
+func findEvent(ctx context.Context, eventDatabase EventQueries, eventID string) (*service.Event, error) {
+	result, err := eventDatabase.GetEvent(ctx, eventID)
+
+	if err != nil {
+		return nil, ErrEventNotFound
+	}
+
+	event := service.Event{}
+
+	if err = result.Decode(&event); err != nil {
+		return nil, err
+	}
+
+	return &event, nil
+}
+
+func newReminder(event *service.Event, userID string) *service.EventReminder {
+	return &service.EventReminder{
+		EventID:   event.ID,
+		User:      userID,
+		DateAt:    time.Now(),
+		ExpiresAt: event.StartsAt,
+	}
+}
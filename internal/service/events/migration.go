@@ -0,0 +1,20 @@
+package events
+
+import (
+	"context"
+
+	"github.com/delonce/socialnetwork/internal/config"
+	"github.com/delonce/socialnetwork/internal/service"
+	"github.com/delonce/socialnetwork/pkg/logging"
+)
+
+func MigrateEventIndexes(logger *logging.Logger, config *config.Config) error {
+	database, err := service.InitNewDatabase(config)
+
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return NewEventDB(logger, database).EnsureIndexes(context.Background())
+}
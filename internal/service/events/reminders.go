@@ -0,0 +1,109 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/delonce/socialnetwork/internal/config"
+	"github.com/delonce/socialnetwork/internal/service"
+	"github.com/delonce/socialnetwork/pkg/logging"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type EventReminderService struct {
+	eventDatabase EventQueries
+	logger        *logging.Logger
+	context       context.Context
+	instanceID    string
+	lease         time.Duration
+}
+
+func NewEventReminderSender(logger *logging.Logger, config *config.Config) (EventReminderSender, error) {
+	database, err := service.InitNewDatabase(config)
+
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	return &EventReminderService{
+		eventDatabase: NewEventDB(logger, database),
+		logger:        logger,
+		instanceID:    service.NewInstanceID(),
+		lease:         config.Scheduler.Lease,
+	}, nil
+}
+
+// SendDueReminders claims events one by one and gives a reminder to every
+// guest going or maybe going, it returns the number of new reminders
+func (sender *EventReminderService) SendDueReminders() (int, error) {
+	eDb := sender.eventDatabase
+	sent := 0
+
+	for {
+		result, err := eDb.ClaimDueReminders(sender.context, sender.instanceID, sender.lease)
+
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return sent, nil
+		}
+
+		if err != nil {
+			return sent, err
+		}
+
+		event := service.Event{}
+
+		if err = result.Decode(&event); err != nil {
+			sender.logger.Errorf("Error while decoding event, %v", err)
+			return sent, err
+		}
+
+		// reminders of an event which has already started are useless
+		if event.StartsAt.After(time.Now()) {
+			added, err := sender.remindGuests(&event)
+			sent += added
+
+			if err != nil {
+				sender.logger.Errorf("Error when sending reminders of event %s, %v", event.ID, err)
+				return sent, err
+			}
+		}
+
+		if err = eDb.SetReminderStatus(sender.context, event.ID, sender.instanceID, reminderSent); err != nil {
+			sender.logger.Errorf("Error when marking reminders of event %s as sent, %v", event.ID, err)
+		}
+	}
+}
+
+func (sender *EventReminderService) remindGuests(event *service.Event) (int, error) {
+	eDb := sender.eventDatabase
+	cursor, err := eDb.GetGuests(sender.context, event.ID, remindedStatuses)
+
+	if err != nil {
+		return 0, err
+	}
+
+	guests := []service.EventGuest{}
+
+	if err = cursor.All(sender.context, &guests); err != nil {
+		return 0, err
+	}
+
+	added := 0
+
+	for _, guest := range guests {
+		isAdded, err := eDb.AddReminder(sender.context, newReminder(event, guest.User))
+
+		if err != nil {
+			return added, err
+		}
+
+		if isAdded {
+			added++
+		}
+	}
+
+	return added, nil
+}
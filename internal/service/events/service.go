@@ -0,0 +1,81 @@
+package events
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/delonce/socialnetwork/internal/service"
+)
+
+const (
+	RSVP_INVITED  = "invited"
+	RSVP_GOING    = "going"
+	RSVP_MAYBE    = "maybe"
+	RSVP_DECLINED = "declined"
+)
+
+const (
+	reminderPending = "pending"
+	reminderSending = "sending"
+	reminderSent    = "sent"
+
+	eventInputLayout = "2006-01-02T15:04"
+)
+
+const (
+	MaxTitleLength       = 200
+	MaxDescriptionLength = 2000
+	MaxLocationLength    = 300
+)
+
+// remindedStatuses are the answers of guests who get a reminder
+var remindedStatuses = []string{RSVP_GOING, RSVP_MAYBE}
+
+var (
+	ErrEmptyTitle       = errors.New("Event title can't be empty")
+	ErrLongTitle        = errors.New("Event title is too long")
+	ErrLongDescription  = errors.New("Event description is too long")
+	ErrLongLocation     = errors.New("Event location is too long")
+	ErrEventInPast      = errors.New("Event can't start in the past")
+	ErrEventEnd         = errors.New("Event must end after it starts")
+	ErrEventNotFound    = errors.New("Event not found")
+	ErrNotCreator       = errors.New("Only the creator can do this")
+	ErrAlreadyInvited   = errors.New("User is already invited")
+	ErrWrongRSVP        = errors.New("Unknown answer to the invitation")
+	ErrCreatorRSVP      = errors.New("Creator always goes to the event")
+	ErrReminderNotFound = errors.New("Reminder not found")
+)
+
+type EventManager interface {
+	CreateEvent(creator string, title string, description string, location string,
+		startsAt time.Time, endsAt *time.Time) (string, error)
+	DeleteEvent(username string, eventID string) error
+	InviteFriend(username string, eventID string, friend string) error
+	SetRSVP(username string, eventID string, status string) error
+	DismissReminder(username string, eventID string) error
+}
+
+type EventViewer interface {
+	GetUserEvents(username string) []service.ViewEvent
+	GetEvent(username string, eventID string) (*service.ViewEventPage, error)
+	GetReminders(username string) []service.ViewEvent
+	ExportEvent(w io.Writer, username string, eventID string) error
+}
+
+type EventReminderSender interface {
+	SendDueReminders() (int, error)
+}
+
+// ParseEventTime reads the time of a datetime-local input in the zone of the
+// user who filled it in
+func ParseEventTime(value string, location *time.Location) (time.Time, error) {
+	eventTime, err := time.ParseInLocation(eventInputLayout, value, location)
+
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Wrong event time %s", value)
+	}
+
+	return eventTime, nil
+}
@@ -0,0 +1,267 @@
+package events
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/delonce/socialnetwork/internal/config"
+	"github.com/delonce/socialnetwork/internal/service"
+	"github.com/delonce/socialnetwork/internal/service/user"
+	"github.com/delonce/socialnetwork/pkg/logging"
+)
+
+type EventViewService struct {
+	eventDatabase EventQueries
+	userResolver  user.Resolver
+	logger        *logging.Logger
+	context       context.Context
+	host          string
+}
+
+func NewEventViewer(logger *logging.Logger, config *config.Config) (EventViewer, error) {
+	database, err := service.InitNewDatabase(config)
+
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	return &EventViewService{
+		eventDatabase: NewEventDB(logger, database),
+		userResolver:  user.NewResolver(logger, database),
+		logger:        logger,
+		host:          config.Http.Host,
+	}, nil
+}
+
+// GetUserEvents returns unfinished events the user created or is invited
+// to, the nearest first
+func (eventView *EventViewService) GetUserEvents(username string) []service.ViewEvent {
+	userID, err := eventView.userResolver.GetUserID(username)
+
+	if err != nil {
+		return []service.ViewEvent{}
+	}
+
+	cursor, err := eventView.eventDatabase.GetUserInvitations(eventView.context, userID)
+
+	if err != nil {
+		eventView.logger.Panic(err)
+	}
+
+	guests := []service.EventGuest{}
+
+	if err = cursor.All(eventView.context, &guests); err != nil {
+		eventView.logger.Panic(err)
+	}
+
+	statuses := map[string]string{}
+	eventIDs := []string{}
+
+	for _, guest := range guests {
+		statuses[guest.EventID] = guest.Status
+		eventIDs = append(eventIDs, guest.EventID)
+	}
+
+	return eventView.getUpcomingEvents(userID, eventIDs, statuses)
+}
+
+// GetEvent shows the event with the answers of guests, only guests and the
+// creator see it
+func (eventView *EventViewService) GetEvent(username string, eventID string) (*service.ViewEventPage, error) {
+	event, guest, err := eventView.getGuestEvent(username, eventID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := eventView.eventDatabase.GetGuests(eventView.context, eventID, nil)
+
+	if err != nil {
+		eventView.logger.Panic(err)
+	}
+
+	guests := []service.EventGuest{}
+
+	if err = cursor.All(eventView.context, &guests); err != nil {
+		eventView.logger.Panic(err)
+	}
+
+	userIDs := []string{event.Creator}
+
+	for _, eventGuest := range guests {
+		userIDs = append(userIDs, eventGuest.User)
+	}
+
+	usernames := eventView.userResolver.GetUsernames(userIDs)
+
+	eventPage := &service.ViewEventPage{
+		Event:    newViewEvent(event, usernames[event.Creator], guest.Status, guest.User),
+		Going:    []string{},
+		Maybe:    []string{},
+		Declined: []string{},
+		Invited:  []string{},
+	}
+
+	for _, eventGuest := range guests {
+		guestname := usernames[eventGuest.User]
+
+		switch eventGuest.Status {
+		case RSVP_GOING:
+			eventPage.Going = append(eventPage.Going, guestname)
+		case RSVP_MAYBE:
+			eventPage.Maybe = append(eventPage.Maybe, guestname)
+		case RSVP_DECLINED:
+			eventPage.Declined = append(eventPage.Declined, guestname)
+		default:
+			eventPage.Invited = append(eventPage.Invited, guestname)
+		}
+	}
+
+	return eventPage, nil
+}
+
+// GetReminders returns the events the user was reminded of and which
+// haven't started yet
+func (eventView *EventViewService) GetReminders(username string) []service.ViewEvent {
+	userID, err := eventView.userResolver.GetUserID(username)
+
+	if err != nil {
+		return []service.ViewEvent{}
+	}
+
+	cursor, err := eventView.eventDatabase.GetReminders(eventView.context, userID)
+
+	if err != nil {
+		eventView.logger.Panic(err)
+	}
+
+	reminders := []service.EventReminder{}
+
+	if err = cursor.All(eventView.context, &reminders); err != nil {
+		eventView.logger.Panic(err)
+	}
+
+	eventIDs := []string{}
+
+	for _, reminder := range reminders {
+		eventIDs = append(eventIDs, reminder.EventID)
+	}
+
+	return eventView.getUpcomingEvents(userID, eventIDs, map[string]string{})
+}
+
+func (eventView *EventViewService) ExportEvent(w io.Writer, username string, eventID string) error {
+	event, _, err := eventView.getGuestEvent(username, eventID)
+
+	if err != nil {
+		return err
+	}
+
+	return writeICS(w, event, eventView.host)
+}
+
+// getGuestEvent returns the event with the invitation of the user, events
+// the user isn't invited to are not found
+func (eventView *EventViewService) getGuestEvent(username string, eventID string) (*service.Event, *service.EventGuest, error) {
+	userID, err := eventView.userResolver.GetUserID(username)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result, err := eventView.eventDatabase.GetGuest(eventView.context, eventID, userID)
+
+	if err != nil {
+		return nil, nil, ErrEventNotFound
+	}
+
+	guest := service.EventGuest{}
+
+	if err = result.Decode(&guest); err != nil {
+		return nil, nil, err
+	}
+
+	event, err := findEvent(eventView.context, eventView.eventDatabase, eventID)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return event, &guest, nil
+}
+
+func (eventView *EventViewService) getUpcomingEvents(userID string, eventIDs []string,
+	statuses map[string]string) []service.ViewEvent {
+
+	viewEvents := []service.ViewEvent{}
+
+	if len(eventIDs) == 0 {
+		return viewEvents
+	}
+
+	cursor, err := eventView.eventDatabase.GetUpcomingEvents(eventView.context, eventIDs, time.Now())
+
+	if err != nil {
+		eventView.logger.Panic(err)
+	}
+
+	events := []service.Event{}
+
+	if err = cursor.All(eventView.context, &events); err != nil {
+		eventView.logger.Panic(err)
+	}
+
+	creatorIDs := []string{}
+
+	for _, event := range events {
+		creatorIDs = append(creatorIDs, event.Creator)
+	}
+
+	usernames := eventView.userResolver.GetUsernames(creatorIDs)
+
+	for i := range events {
+		event := &events[i]
+		viewEvents = append(viewEvents, newViewEvent(event, usernames[event.Creator], statuses[event.ID], userID))
+	}
+
+	return viewEvents
+}
+
+func newViewEvent(event *service.Event, creator string, status string, viewerID string) service.ViewEvent {
+	location := eventLocation(event)
+
+	viewEvent := service.ViewEvent{
+		ID:             event.ID,
+		Creator:        creator,
+		Title:          event.Title,
+		Description:    event.Description,
+		Location:       event.Location,
+		FormatStartsAt: event.StartsAt.In(location).Format("2006-01-02 15:04"),
+		TimeZone:       location.String(),
+		Status:         status,
+		IsCreator:      event.Creator == viewerID,
+	}
+
+	if event.EndsAt != nil {
+		viewEvent.FormatEndsAt = event.EndsAt.In(location).Format("2006-01-02 15:04")
+	}
+
+	return viewEvent
+}
+
+// eventLocation returns the zone the creator set the event times in
+func eventLocation(event *service.Event) *time.Location {
+	if event.TimeZone == "" {
+		return time.Local
+	}
+
+	location, err := time.LoadLocation(event.TimeZone)
+
+	if err != nil {
+		return time.Local
+	}
+
+	return location
+}
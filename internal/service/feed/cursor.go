@@ -0,0 +1,57 @@
+package feed
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/delonce/socialnetwork/internal/service"
+)
+
+// Cursor points at the last event of a page, events with the same date are
+// ordered by id so pages never skip or repeat them
+type Cursor struct {
+	Date time.Time
+	ID   string
+}
+
+func (cursor Cursor) IsZero() bool {
+	return cursor.Date.IsZero()
+}
+
+func newCursor(event service.ActivityEvent) Cursor {
+	return Cursor{Date: event.DateAt, ID: event.ID}
+}
+
+func (cursor Cursor) Encode() string {
+	value := strconv.FormatInt(cursor.Date.UnixMilli(), 10) + ":" + cursor.ID
+
+	return base64.RawURLEncoding.EncodeToString([]byte(value))
+}
+
+func ParseCursor(value string) (Cursor, error) {
+	if value == "" {
+		return Cursor{}, nil
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(value)
+
+	if err != nil {
+		return Cursor{}, ErrWrongCursor
+	}
+
+	parts := strings.SplitN(string(decoded), ":", 2)
+
+	if len(parts) != 2 || parts[1] == "" {
+		return Cursor{}, ErrWrongCursor
+	}
+
+	millis, err := strconv.ParseInt(parts[0], 10, 64)
+
+	if err != nil {
+		return Cursor{}, ErrWrongCursor
+	}
+
+	return Cursor{Date: time.UnixMilli(millis), ID: parts[1]}, nil
+}
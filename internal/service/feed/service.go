@@ -0,0 +1,28 @@
+package feed
+
+import (
+	"errors"
+
+	"github.com/delonce/socialnetwork/internal/service"
+)
+
+const TIMELINE_FANOUT = "fanout"
+
+const DefaultPageSize = 20
+
+var (
+	ErrWrongCursor     = errors.New("Wrong feed cursor")
+	ErrUnknownTimeline = errors.New("Unknown feed timeline")
+)
+
+type FeedViewer interface {
+	GetFeed(username string, cursor string) (*service.ViewFeedPage, error)
+}
+
+// Timeline returns events of a user's feed older than the cursor, newest
+// first. The fan-out-on-read timeline collects friends' events on every
+// request, a store precomputed on write only has to implement this interface
+// and be added to timelines
+type Timeline interface {
+	GetEvents(username string, before Cursor, limit int64) ([]service.ActivityEvent, error)
+}
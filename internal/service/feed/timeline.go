@@ -0,0 +1,87 @@
+package feed
+
+import (
+	"context"
+
+	"github.com/delonce/socialnetwork/internal/config"
+	"github.com/delonce/socialnetwork/internal/service"
+	"github.com/delonce/socialnetwork/internal/service/activity"
+	"github.com/delonce/socialnetwork/internal/service/friends"
+	"github.com/delonce/socialnetwork/internal/service/user"
+	"github.com/delonce/socialnetwork/pkg/logging"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type timelineFactory func(logger *logging.Logger, config *config.Config, database *mongo.Database) (Timeline, error)
+
+// timelines maps values of feed.timeline in the config to implementations
+var timelines = map[string]timelineFactory{
+	TIMELINE_FANOUT: newFanOutTimeline,
+}
+
+func newTimeline(logger *logging.Logger, config *config.Config, database *mongo.Database) (Timeline, error) {
+	factory, ok := timelines[config.Feed.Timeline]
+
+	if !ok {
+		return nil, ErrUnknownTimeline
+	}
+
+	return factory(logger, config, database)
+}
+
+type fanOutTimeline struct {
+	activityDatabase activity.ActivityQueries
+	friendView       friends.FriendViewer
+	userResolver     user.Resolver
+	context          context.Context
+}
+
+func newFanOutTimeline(logger *logging.Logger, config *config.Config, database *mongo.Database) (Timeline, error) {
+	friendView, err := friends.NewFriendViewer(logger, config)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &fanOutTimeline{
+		activityDatabase: activity.NewActivityDB(logger, database),
+		friendView:       friendView,
+		userResolver:     user.NewResolver(logger, database),
+	}, nil
+}
+
+// GetEvents reads events done by friends or with friends, nothing is
+// written when an event happens
+func (timeline *fanOutTimeline) GetEvents(username string, before Cursor, limit int64) ([]service.ActivityEvent, error) {
+	events := []service.ActivityEvent{}
+	userFriends := timeline.friendView.GetUserFriends(username)
+
+	if len(userFriends) == 0 {
+		return events, nil
+	}
+
+	friendIDs, err := timeline.userResolver.GetUserIDs(userFriends)
+
+	if err != nil {
+		return nil, err
+	}
+
+	ids := []string{}
+
+	for _, friendID := range friendIDs {
+		ids = append(ids, friendID)
+	}
+
+	cursor, err := timeline.activityDatabase.GetEventsOf(timeline.context, ids, before.Date, before.ID, limit)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = cursor.All(timeline.context, &events); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
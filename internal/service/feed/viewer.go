@@ -0,0 +1,102 @@
+package feed
+
+import (
+	"context"
+
+	"github.com/delonce/socialnetwork/internal/config"
+	"github.com/delonce/socialnetwork/internal/service"
+	"github.com/delonce/socialnetwork/internal/service/user"
+	"github.com/delonce/socialnetwork/pkg/logging"
+)
+
+type FeedViewService struct {
+	timeline     Timeline
+	userResolver user.Resolver
+	logger       *logging.Logger
+	context      context.Context
+	pageSize     int64
+}
+
+func NewFeedViewer(logger *logging.Logger, config *config.Config) (FeedViewer, error) {
+	database, err := service.InitNewDatabase(config)
+
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	timeline, err := newTimeline(logger, config, database)
+
+	if err != nil {
+		logger.Errorf("Can't create feed timeline %s, %v", config.Feed.Timeline, err)
+		return nil, err
+	}
+
+	pageSize := config.Feed.PageSize
+
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+
+	return &FeedViewService{
+		timeline:     timeline,
+		userResolver: user.NewResolver(logger, database),
+		logger:       logger,
+		pageSize:     pageSize,
+	}, nil
+}
+
+// GetFeed returns one page of friends' activity, the next cursor is empty
+// on the last page
+func (feedView *FeedViewService) GetFeed(username string, cursor string) (*service.ViewFeedPage, error) {
+	before, err := ParseCursor(cursor)
+
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := feedView.timeline.GetEvents(username, before, feedView.pageSize+1)
+
+	if err != nil {
+		return nil, err
+	}
+
+	feedPage := &service.ViewFeedPage{
+		Events: []service.ViewActivity{},
+	}
+
+	if int64(len(events)) > feedView.pageSize {
+		events = events[:feedView.pageSize]
+		feedPage.NextCursor = newCursor(events[len(events)-1]).Encode()
+	}
+
+	userIDs := []string{}
+
+	for _, event := range events {
+		userIDs = append(userIDs, event.Actor)
+
+		if event.Subject != "" {
+			userIDs = append(userIDs, event.Subject)
+		}
+	}
+
+	usernames := feedView.userResolver.GetUsernames(userIDs)
+
+	for _, event := range events {
+		viewEvent := service.ViewActivity{
+			ID:         event.ID,
+			Type:       event.Type,
+			Actor:      usernames[event.Actor],
+			Data:       event.Data,
+			FormatDate: event.DateAt.Format("2006-01-02 15:04"),
+		}
+
+		if event.Subject != "" {
+			viewEvent.Subject = usernames[event.Subject]
+		}
+
+		feedPage.Events = append(feedPage.Events, viewEvent)
+	}
+
+	return feedPage, nil
+}
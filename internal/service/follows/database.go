@@ -0,0 +1,198 @@
+package follows
+
+import (
+	"context"
+
+	"github.com/delonce/socialnetwork/internal/database"
+	"github.com/delonce/socialnetwork/internal/database/mongodb"
+	"github.com/delonce/socialnetwork/internal/service"
+	"github.com/delonce/socialnetwork/pkg/logging"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type FollowQueries interface {
+	AddFollow(ctx context.Context, follow *service.Follow) (bool, error)
+	DeleteFollow(ctx context.Context, follower string, followee string) error
+	SetFollowState(ctx context.Context, follower string, followee string, fromState string, state string) error
+
+	GetFollow(ctx context.Context, follower string, followee string) (*mongo.SingleResult, error)
+	GetFollowers(ctx context.Context, followee string, state string) (*mongo.Cursor, error)
+	GetFollowing(ctx context.Context, follower string) (*mongo.Cursor, error)
+
+	MigrateUserIDs(ctx context.Context, userIDs map[string]string) error
+	EnsureIndexes(ctx context.Context) error
+}
+
+// followUserFields lists fields holding user ids in follow collections
+var followUserFields = map[string][]string{
+	service.FOLLOW_COLLECTION: {"follower", "followee"},
+}
+
+type FollowDB struct {
+	Storage database.DBStorage
+	Logger  *logging.Logger
+}
+
+func NewFollowDB(logger *logging.Logger, database *mongo.Database) FollowQueries {
+	storage := mongodb.NewStorage(
+		map[string]*mongo.Collection{
+			service.FOLLOW_COLLECTION: database.Collection(service.FOLLOW_COLLECTION),
+		},
+		logger,
+	)
+
+	return &FollowDB{
+		Storage: storage,
+		Logger:  logger,
+	}
+}
+
+// AddFollow reports whether the follow was added, false means the follower
+// already follows the user or asked to
+func (followStorage *FollowDB) AddFollow(ctx context.Context, follow *service.Follow) (bool, error) {
+	st := followStorage.Storage
+	query := bson.M{
+		"follower": follow.Follower,
+		"followee": follow.Followee,
+	}
+
+	return st.InsertIfAbsent(ctx, query, follow, service.FOLLOW_COLLECTION)
+}
+
+func (followStorage *FollowDB) DeleteFollow(ctx context.Context, follower string, followee string) error {
+	st := followStorage.Storage
+
+	return st.Delete(ctx, followQuery(follower, followee), service.FOLLOW_COLLECTION)
+}
+
+func (followStorage *FollowDB) SetFollowState(ctx context.Context, follower string, followee string, fromState string, state string) error {
+	st := followStorage.Storage
+	query := bson.M{
+		"$and": []bson.M{
+			followQuery(follower, followee),
+			{"state": fromState},
+		},
+	}
+
+	model := bson.M{
+		"state": state,
+	}
+
+	return st.Update(ctx, query, model, service.FOLLOW_COLLECTION)
+}
+
+func (followStorage *FollowDB) GetFollow(ctx context.Context, follower string, followee string) (*mongo.SingleResult, error) {
+	st := followStorage.Storage
+
+	return st.FindOneObject(ctx, followQuery(follower, followee), service.FOLLOW_COLLECTION)
+}
+
+func (followStorage *FollowDB) GetFollowers(ctx context.Context, followee string, state string) (*mongo.Cursor, error) {
+	st := followStorage.Storage
+	query := bson.M{
+		"$and": []bson.M{
+			{"followee": followee},
+			{"state": state},
+		},
+	}
+
+	findOpts := options.FindOptions{}
+	findOpts.SetSort(bson.D{{Key: "date", Value: -1}})
+
+	return st.FindObjects(ctx, query, service.FOLLOW_COLLECTION, &findOpts)
+}
+
+func (followStorage *FollowDB) GetFollowing(ctx context.Context, follower string) (*mongo.Cursor, error) {
+	st := followStorage.Storage
+	query := bson.M{
+		"$and": []bson.M{
+			{"follower": follower},
+			{"state": FollowActive},
+		},
+	}
+
+	findOpts := options.FindOptions{}
+	findOpts.SetSort(bson.D{{Key: "date", Value: -1}})
+
+	return st.FindObjects(ctx, query, service.FOLLOW_COLLECTION, &findOpts)
+}
+
+// MigrateUserIDs rewrites usernames stored in follows into user ids
+func (followStorage *FollowDB) MigrateUserIDs(ctx context.Context, userIDs map[string]string) error {
+	st := followStorage.Storage
+
+	for collection, fields := range followUserFields {
+		for _, field := range fields {
+			for username, userID := range userIDs {
+				query := bson.M{field: username}
+
+				if err := st.UpdateMany(ctx, query, bson.M{field: userID}, collection); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// EnsureIndexes allows one follow per follower and followee, follows doubled
+// by concurrent requests before the index existed are removed except the
+// newest one
+func (followStorage *FollowDB) EnsureIndexes(ctx context.Context) error {
+	st := followStorage.Storage
+	fields := []string{"follower", "followee"}
+
+	err := st.EnsureUniqueIndex(ctx, fields, service.FOLLOW_COLLECTION)
+
+	if !mongo.IsDuplicateKeyError(err) {
+		return err
+	}
+
+	pipeline := []bson.M{
+		{"$sort": bson.M{"date": -1}},
+		{"$group": bson.M{
+			"_id":   bson.M{"follower": "$follower", "followee": "$followee"},
+			"ids":   bson.M{"$push": "$_id"},
+			"count": bson.M{"$sum": 1},
+		}},
+		{"$match": bson.M{"count": bson.M{"$gt": 1}}},
+	}
+
+	cursor, err := st.Aggregate(ctx, pipeline, service.FOLLOW_COLLECTION)
+
+	if err != nil {
+		return err
+	}
+
+	duplicates := []struct {
+		IDs []primitive.ObjectID `bson:"ids"`
+	}{}
+
+	if err = cursor.All(ctx, &duplicates); err != nil {
+		return err
+	}
+
+	for _, duplicate := range duplicates {
+		query := bson.M{"_id": bson.M{"$in": duplicate.IDs[1:]}}
+
+		if err = st.DeleteMany(ctx, query, service.FOLLOW_COLLECTION); err != nil {
+			return err
+		}
+	}
+
+	return st.EnsureUniqueIndex(ctx, fields, service.FOLLOW_COLLECTION)
+}
+
+func followQuery(follower string, followee string) bson.M {
+	return bson.M{
+		"$and": []bson.M{
+			{"follower": follower},
+			{"followee": followee},
+		},
+	}
+}
@@ -0,0 +1,171 @@
+package follows
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/delonce/socialnetwork/internal/config"
+	"github.com/delonce/socialnetwork/internal/service"
+	"github.com/delonce/socialnetwork/internal/service/access"
+	"github.com/delonce/socialnetwork/internal/service/friends"
+	"github.com/delonce/socialnetwork/internal/service/user"
+	"github.com/delonce/socialnetwork/pkg/logging"
+)
+
+type FollowManagerService struct {
+	followDatabase FollowQueries
+	friendView     friends.FriendViewer
+	userResolver   user.Resolver
+	policy         access.Policy
+	authService    user.Authorization
+	logger         *logging.Logger
+	context        context.Context
+}
+
+func NewFollowManager(logger *logging.Logger, config *config.Config) (FollowManager, error) {
+	database, err := service.InitNewDatabase(config)
+
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	friendView, err := friends.NewFriendViewer(logger, config)
+
+	if err != nil {
+		return nil, err
+	}
+
+	authService, err := user.NewAuthService(logger, config)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &FollowManagerService{
+		followDatabase: NewFollowDB(logger, database),
+		friendView:     friendView,
+		userResolver:   user.NewResolver(logger, database),
+		policy:         access.NewRelationsPolicy(friendView),
+		authService:    authService,
+		logger:         logger,
+	}, nil
+}
+
+// Follow creates an active follow for public accounts and a pending one
+// for private accounts, friends already follow each other implicitly
+func (manager *FollowManagerService) Follow(follower string, followee string) error {
+	err := manager.policy.Authorize(follower, access.ActionFollow, followee)
+
+	if errors.Is(err, access.ErrSelfAction) {
+		return ErrSelfFollow
+	}
+
+	if err != nil {
+		return ErrFollowBlocked
+	}
+
+	if manager.friendView.CheckFriend(follower, followee) {
+		return ErrAlreadyFollowing
+	}
+
+	userIDs, err := manager.getPairIDs(follower, followee)
+
+	if err != nil {
+		return err
+	}
+
+	followerID, followeeID := userIDs[follower], userIDs[followee]
+
+	followeeUser, err := manager.authService.GetUserByName(followee)
+
+	if err != nil {
+		return err
+	}
+
+	state := FollowActive
+
+	if followeeUser.IsPrivate {
+		state = FollowPending
+	}
+
+	isAdded, err := manager.followDatabase.AddFollow(manager.context, &service.Follow{
+		Follower: followerID,
+		Followee: followeeID,
+		State:    state,
+		DateAt:   time.Now(),
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if !isAdded {
+		return ErrAlreadyFollowing
+	}
+
+	return nil
+}
+
+func (manager *FollowManagerService) Unfollow(follower string, followee string) error {
+	userIDs, err := manager.getPairIDs(follower, followee)
+
+	if err != nil {
+		return ErrNotFollowing
+	}
+
+	if err = manager.followDatabase.DeleteFollow(manager.context, userIDs[follower], userIDs[followee]); err != nil {
+		return ErrNotFollowing
+	}
+
+	return nil
+}
+
+func (manager *FollowManagerService) ApproveFollower(user string, follower string) error {
+	userIDs, err := manager.getPairIDs(user, follower)
+
+	if err != nil {
+		return ErrFollowNotFound
+	}
+
+	err = manager.followDatabase.SetFollowState(manager.context, userIDs[follower], userIDs[user], FollowPending, FollowActive)
+
+	if err != nil {
+		return ErrFollowNotFound
+	}
+
+	return nil
+}
+
+func (manager *FollowManagerService) RejectFollower(user string, follower string) error {
+	userIDs, err := manager.getPairIDs(user, follower)
+
+	if err != nil {
+		return ErrFollowNotFound
+	}
+
+	if err = manager.followDatabase.DeleteFollow(manager.context, userIDs[follower], userIDs[user]); err != nil {
+		return ErrFollowNotFound
+	}
+
+	return nil
+}
+
+func (manager *FollowManagerService) getPairIDs(first string, second string) (map[string]string, error) {
+	userIDs, err := manager.userResolver.GetUserIDs([]string{first, second})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := userIDs[first]; !ok {
+		return nil, user.ErrUserNotFound
+	}
+
+	if _, ok := userIDs[second]; !ok {
+		return nil, user.ErrUserNotFound
+	}
+
+	return userIDs, nil
+}
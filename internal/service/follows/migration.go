@@ -0,0 +1,31 @@
+package follows
+
+import (
+	"context"
+
+	"github.com/delonce/socialnetwork/internal/config"
+	"github.com/delonce/socialnetwork/internal/service"
+	"github.com/delonce/socialnetwork/pkg/logging"
+)
+
+func MigrateFollowUserIDs(logger *logging.Logger, config *config.Config, userIDs map[string]string) error {
+	database, err := service.InitNewDatabase(config)
+
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return NewFollowDB(logger, database).MigrateUserIDs(context.Background(), userIDs)
+}
+
+func MigrateFollowIndexes(logger *logging.Logger, config *config.Config) error {
+	database, err := service.InitNewDatabase(config)
+
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return NewFollowDB(logger, database).EnsureIndexes(context.Background())
+}
@@ -0,0 +1,40 @@
+package follows
+
+import (
+	"errors"
+
+	"github.com/delonce/socialnetwork/internal/service"
+)
+
+const (
+	FollowActive  = "active"
+	FollowPending = "pending"
+)
+
+var (
+	ErrSelfFollow       = errors.New("Can't follow yourself")
+	ErrAlreadyFollowing = errors.New("User is already followed")
+	ErrNotFollowing     = errors.New("User is not followed")
+	ErrFollowBlocked    = errors.New("Following this user is blocked")
+	ErrFollowNotFound   = errors.New("Follow request not found")
+)
+
+type FollowManager interface {
+	Follow(follower string, followee string) error
+	Unfollow(follower string, followee string) error
+
+	ApproveFollower(user string, follower string) error
+	RejectFollower(user string, follower string) error
+}
+
+type FollowViewer interface {
+	GetFollowers(username string) []string
+	GetFollowing(username string) []string
+	GetPendingFollowers(username string) []service.Follow
+
+	CountFollowers(username string) int64
+	CountFollowing(username string) int64
+
+	IsFollowing(follower string, followee string) bool
+	IsFollowPending(follower string, followee string) bool
+}
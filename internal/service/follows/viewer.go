@@ -0,0 +1,203 @@
+package follows
+
+import (
+	"context"
+
+	"github.com/delonce/socialnetwork/internal/config"
+	"github.com/delonce/socialnetwork/internal/service"
+	"github.com/delonce/socialnetwork/internal/service/friends"
+	"github.com/delonce/socialnetwork/internal/service/user"
+	"github.com/delonce/socialnetwork/pkg/logging"
+)
+
+type FollowViewService struct {
+	followDatabase FollowQueries
+	friendView     friends.FriendViewer
+	userResolver   user.Resolver
+	logger         *logging.Logger
+	context        context.Context
+}
+
+func NewFollowViewer(logger *logging.Logger, config *config.Config) (FollowViewer, error) {
+	database, err := service.InitNewDatabase(config)
+
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	friendView, err := friends.NewFriendViewer(logger, config)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &FollowViewService{
+		followDatabase: NewFollowDB(logger, database),
+		friendView:     friendView,
+		userResolver:   user.NewResolver(logger, database),
+		logger:         logger,
+	}, nil
+}
+
+// GetFollowers returns explicit followers together with friends,
+// who are mutual followers implicitly
+func (viewService *FollowViewService) GetFollowers(username string) []string {
+	followers := viewService.getFollows(username, FollowActive)
+	usernames := []string{}
+
+	for _, follow := range followers {
+		usernames = append(usernames, follow.Follower)
+	}
+
+	return mergeUsernames(usernames, viewService.friendView.GetUserFriends(username))
+}
+
+func (viewService *FollowViewService) GetFollowing(username string) []string {
+	userID, err := viewService.userResolver.GetUserID(username)
+
+	if err != nil {
+		return []string{}
+	}
+
+	cursor, err := viewService.followDatabase.GetFollowing(viewService.context, userID)
+
+	if err != nil {
+		viewService.logger.Panic(err)
+	}
+
+	following := []service.Follow{}
+	followeeIDs := []string{}
+
+	err = cursor.All(viewService.context, &following)
+
+	if err != nil {
+		viewService.logger.Panic(err)
+	}
+
+	for _, follow := range following {
+		followeeIDs = append(followeeIDs, follow.Followee)
+	}
+
+	names := viewService.userResolver.GetUsernames(followeeIDs)
+	usernames := []string{}
+
+	for _, followeeID := range followeeIDs {
+		if name, ok := names[followeeID]; ok {
+			usernames = append(usernames, name)
+		}
+	}
+
+	return mergeUsernames(usernames, viewService.friendView.GetUserFriends(username))
+}
+
+func (viewService *FollowViewService) GetPendingFollowers(username string) []service.Follow {
+	return viewService.getFollows(username, FollowPending)
+}
+
+func (viewService *FollowViewService) CountFollowers(username string) int64 {
+	return int64(len(viewService.GetFollowers(username)))
+}
+
+func (viewService *FollowViewService) CountFollowing(username string) int64 {
+	return int64(len(viewService.GetFollowing(username)))
+}
+
+func (viewService *FollowViewService) IsFollowing(follower string, followee string) bool {
+	if viewService.friendView.CheckFriend(follower, followee) {
+		return true
+	}
+
+	follow, ok := viewService.getFollow(follower, followee)
+
+	return ok && follow.State == FollowActive
+}
+
+func (viewService *FollowViewService) IsFollowPending(follower string, followee string) bool {
+	follow, ok := viewService.getFollow(follower, followee)
+
+	return ok && follow.State == FollowPending
+}
+
+func (viewService *FollowViewService) getFollow(follower string, followee string) (*service.Follow, bool) {
+	userIDs, err := viewService.userResolver.GetUserIDs([]string{follower, followee})
+
+	if err != nil || len(userIDs) != 2 {
+		return nil, false
+	}
+
+	result, err := viewService.followDatabase.GetFollow(viewService.context, userIDs[follower], userIDs[followee])
+
+	if err != nil {
+		return nil, false
+	}
+
+	follow := service.Follow{}
+
+	if err = result.Decode(&follow); err != nil {
+		viewService.logger.Errorf("Error while decoding follow %s - %s", follower, followee)
+		return nil, false
+	}
+
+	return &follow, true
+}
+
+// getFollows returns follows of the user in the state with current
+// usernames in place of the stored user ids
+func (viewService *FollowViewService) getFollows(followee string, state string) []service.Follow {
+	followeeID, err := viewService.userResolver.GetUserID(followee)
+
+	if err != nil {
+		return []service.Follow{}
+	}
+
+	cursor, err := viewService.followDatabase.GetFollowers(viewService.context, followeeID, state)
+
+	if err != nil {
+		viewService.logger.Panic(err)
+	}
+
+	follows := []service.Follow{}
+	err = cursor.All(viewService.context, &follows)
+
+	if err != nil {
+		viewService.logger.Panic(err)
+	}
+
+	followerIDs := []string{}
+
+	for _, follow := range follows {
+		followerIDs = append(followerIDs, follow.Follower)
+	}
+
+	usernames := viewService.userResolver.GetUsernames(followerIDs)
+	resolved := []service.Follow{}
+
+	for _, follow := range follows {
+		username, ok := usernames[follow.Follower]
+
+		if !ok {
+			continue
+		}
+
+		follow.Follower = username
+		follow.Followee = followee
+		resolved = append(resolved, follow)
+	}
+
+	return resolved
+}
+
+func mergeUsernames(first []string, second []string) []string {
+	seen := map[string]bool{}
+	merged := []string{}
+
+	for _, username := range append(first, second...) {
+		if !seen[username] {
+			seen[username] = true
+			merged = append(merged, username)
+		}
+	}
+
+	return merged
+}
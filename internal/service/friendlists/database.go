@@ -0,0 +1,299 @@
+package friendlists
+
+import (
+	"context"
+
+	"github.com/delonce/socialnetwork/internal/database"
+	"github.com/delonce/socialnetwork/internal/database/mongodb"
+	"github.com/delonce/socialnetwork/internal/service"
+	"github.com/delonce/socialnetwork/pkg/logging"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type FriendListQueries interface {
+	AddList(ctx context.Context, list *service.FriendList) (string, error)
+	RenameList(ctx context.Context, owner string, listID string, name string) error
+	DeleteList(ctx context.Context, owner string, listID string) error
+
+	GetList(ctx context.Context, owner string, listID string) (*mongo.SingleResult, error)
+	GetListByName(ctx context.Context, owner string, name string) (*mongo.SingleResult, error)
+	GetLists(ctx context.Context, owner string) (*mongo.Cursor, error)
+
+	AddListMember(ctx context.Context, member *service.FriendListMember) (bool, error)
+	DeleteListMember(ctx context.Context, listID string, member string) error
+	DeleteListMembers(ctx context.Context, listID string) error
+	IsListMember(ctx context.Context, listID string, member string) bool
+	GetListMembers(ctx context.Context, owner string) (*mongo.Cursor, error)
+
+	GetVisibility(ctx context.Context, owner string) (*mongo.SingleResult, error)
+	SetVisibility(ctx context.Context, owner string, field string, audience string) error
+
+	MigrateUserIDs(ctx context.Context, userIDs map[string]string) error
+	EnsureIndexes(ctx context.Context) error
+}
+
+// listUserFields lists fields holding user ids in list and visibility collections
+var listUserFields = map[string][]string{
+	service.FRIEND_LIST_COLLECTION: {"owner"},
+	service.LIST_MEMBER_COLLECTION: {"owner", "member"},
+	service.VISIBILITY_COLLECTION:  {"owner"},
+}
+
+type FriendListDB struct {
+	Storage database.DBStorage
+	Logger  *logging.Logger
+}
+
+func NewFriendListDB(logger *logging.Logger, database *mongo.Database) FriendListQueries {
+	storage := mongodb.NewStorage(
+		map[string]*mongo.Collection{
+			service.FRIEND_LIST_COLLECTION: database.Collection(service.FRIEND_LIST_COLLECTION),
+			service.LIST_MEMBER_COLLECTION: database.Collection(service.LIST_MEMBER_COLLECTION),
+			service.VISIBILITY_COLLECTION:  database.Collection(service.VISIBILITY_COLLECTION),
+		},
+		logger,
+	)
+
+	return &FriendListDB{
+		Storage: storage,
+		Logger:  logger,
+	}
+}
+
+func (listStorage *FriendListDB) AddList(ctx context.Context, list *service.FriendList) (string, error) {
+	st := listStorage.Storage
+
+	return st.CreateObject(ctx, list, service.FRIEND_LIST_COLLECTION)
+}
+
+func (listStorage *FriendListDB) RenameList(ctx context.Context, owner string, listID string, name string) error {
+	st := listStorage.Storage
+	query, err := listQuery(owner, listID)
+
+	if err != nil {
+		return err
+	}
+
+	model := bson.M{
+		"name": name,
+	}
+
+	return st.Update(ctx, query, model, service.FRIEND_LIST_COLLECTION)
+}
+
+func (listStorage *FriendListDB) DeleteList(ctx context.Context, owner string, listID string) error {
+	st := listStorage.Storage
+	query, err := listQuery(owner, listID)
+
+	if err != nil {
+		return err
+	}
+
+	return st.Delete(ctx, query, service.FRIEND_LIST_COLLECTION)
+}
+
+func (listStorage *FriendListDB) GetList(ctx context.Context, owner string, listID string) (*mongo.SingleResult, error) {
+	st := listStorage.Storage
+	query, err := listQuery(owner, listID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return st.FindOneObject(ctx, query, service.FRIEND_LIST_COLLECTION)
+}
+
+func (listStorage *FriendListDB) GetListByName(ctx context.Context, owner string, name string) (*mongo.SingleResult, error) {
+	st := listStorage.Storage
+	query := bson.M{
+		"$and": []bson.M{
+			{"owner": owner},
+			{"name": name},
+		},
+	}
+
+	return st.FindOneObject(ctx, query, service.FRIEND_LIST_COLLECTION)
+}
+
+func (listStorage *FriendListDB) GetLists(ctx context.Context, owner string) (*mongo.Cursor, error) {
+	st := listStorage.Storage
+	query := bson.M{"owner": owner}
+
+	findOpts := options.FindOptions{}
+	findOpts.SetSort(bson.D{{Key: "name", Value: 1}})
+
+	return st.FindObjects(ctx, query, service.FRIEND_LIST_COLLECTION, &findOpts)
+}
+
+// AddListMember reports whether the member was added, false means the user
+// is already in the list
+func (listStorage *FriendListDB) AddListMember(ctx context.Context, member *service.FriendListMember) (bool, error) {
+	st := listStorage.Storage
+	query := bson.M{
+		"listid": member.ListID,
+		"member": member.Member,
+	}
+
+	return st.InsertIfAbsent(ctx, query, member, service.LIST_MEMBER_COLLECTION)
+}
+
+func (listStorage *FriendListDB) DeleteListMember(ctx context.Context, listID string, member string) error {
+	st := listStorage.Storage
+
+	return st.Delete(ctx, memberQuery(listID, member), service.LIST_MEMBER_COLLECTION)
+}
+
+func (listStorage *FriendListDB) DeleteListMembers(ctx context.Context, listID string) error {
+	st := listStorage.Storage
+	query := bson.M{"listid": listID}
+
+	return st.DeleteMany(ctx, query, service.LIST_MEMBER_COLLECTION)
+}
+
+func (listStorage *FriendListDB) IsListMember(ctx context.Context, listID string, member string) bool {
+	st := listStorage.Storage
+	_, err := st.FindOneObject(ctx, memberQuery(listID, member), service.LIST_MEMBER_COLLECTION)
+
+	return err == nil
+}
+
+func (listStorage *FriendListDB) GetListMembers(ctx context.Context, owner string) (*mongo.Cursor, error) {
+	st := listStorage.Storage
+	query := bson.M{"owner": owner}
+
+	findOpts := options.FindOptions{}
+	findOpts.SetSort(bson.D{{Key: "member", Value: 1}})
+
+	return st.FindObjects(ctx, query, service.LIST_MEMBER_COLLECTION, &findOpts)
+}
+
+func (listStorage *FriendListDB) GetVisibility(ctx context.Context, owner string) (*mongo.SingleResult, error) {
+	st := listStorage.Storage
+	query := bson.M{"owner": owner}
+
+	return st.FindOneObject(ctx, query, service.VISIBILITY_COLLECTION)
+}
+
+func (listStorage *FriendListDB) SetVisibility(ctx context.Context, owner string, field string, audience string) error {
+	st := listStorage.Storage
+	query := bson.M{"owner": owner}
+
+	model := bson.M{
+		"owner": owner,
+		field:   audience,
+	}
+
+	return st.Upsert(ctx, query, model, service.VISIBILITY_COLLECTION)
+}
+
+// MigrateUserIDs rewrites usernames stored in friend lists, their members and visibility settings into user ids
+func (listStorage *FriendListDB) MigrateUserIDs(ctx context.Context, userIDs map[string]string) error {
+	st := listStorage.Storage
+
+	for collection, fields := range listUserFields {
+		for _, field := range fields {
+			for username, userID := range userIDs {
+				query := bson.M{field: username}
+
+				if err := st.UpdateMany(ctx, query, bson.M{field: userID}, collection); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// EnsureIndexes allows a member only once per list and one visibility
+// document per owner, copies stored concurrently before the indexes existed
+// are removed except the latest one
+func (listStorage *FriendListDB) EnsureIndexes(ctx context.Context) error {
+	err := listStorage.ensureUniqueIndex(ctx, []string{"listid", "member"}, service.LIST_MEMBER_COLLECTION)
+
+	if err != nil {
+		return err
+	}
+
+	return listStorage.ensureUniqueIndex(ctx, []string{"owner"}, service.VISIBILITY_COLLECTION)
+}
+
+func (listStorage *FriendListDB) ensureUniqueIndex(ctx context.Context, fields []string, collection string) error {
+	st := listStorage.Storage
+
+	err := st.EnsureUniqueIndex(ctx, fields, collection)
+
+	if !mongo.IsDuplicateKeyError(err) {
+		return err
+	}
+
+	group := bson.M{}
+
+	for _, field := range fields {
+		group[field] = "$" + field
+	}
+
+	pipeline := []bson.M{
+		{"$sort": bson.M{"_id": -1}},
+		{"$group": bson.M{
+			"_id":   group,
+			"ids":   bson.M{"$push": "$_id"},
+			"count": bson.M{"$sum": 1},
+		}},
+		{"$match": bson.M{"count": bson.M{"$gt": 1}}},
+	}
+
+	cursor, err := st.Aggregate(ctx, pipeline, collection)
+
+	if err != nil {
+		return err
+	}
+
+	duplicates := []struct {
+		IDs []primitive.ObjectID `bson:"ids"`
+	}{}
+
+	if err = cursor.All(ctx, &duplicates); err != nil {
+		return err
+	}
+
+	for _, duplicate := range duplicates {
+		query := bson.M{"_id": bson.M{"$in": duplicate.IDs[1:]}}
+
+		if err = st.DeleteMany(ctx, query, collection); err != nil {
+			return err
+		}
+	}
+
+	return st.EnsureUniqueIndex(ctx, fields, collection)
+}
+
+func listQuery(owner string, listID string) (bson.M, error) {
+	objListID, err := primitive.ObjectIDFromHex(listID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	query := bson.M{
+		"$and": []bson.M{
+			{"_id": objListID},
+			{"owner": owner},
+		},
+	}
+
+	return query, nil
+}
+
+func memberQuery(listID string, member string) bson.M {
+	return bson.M{
+		"$and": []bson.M{
+			{"listid": listID},
+			{"member": member},
+		},
+	}
+}
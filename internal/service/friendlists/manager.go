@@ -0,0 +1,214 @@
+package friendlists
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/delonce/socialnetwork/internal/config"
+	"github.com/delonce/socialnetwork/internal/service"
+	"github.com/delonce/socialnetwork/internal/service/friends"
+	"github.com/delonce/socialnetwork/internal/service/user"
+	"github.com/delonce/socialnetwork/pkg/logging"
+)
+
+type FriendListManagerService struct {
+	listDatabase FriendListQueries
+	friendView   friends.FriendViewer
+	userResolver user.Resolver
+	logger       *logging.Logger
+	context      context.Context
+}
+
+func NewFriendListManager(logger *logging.Logger, config *config.Config) (FriendListManager, error) {
+	database, err := service.InitNewDatabase(config)
+
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	friendView, err := friends.NewFriendViewer(logger, config)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &FriendListManagerService{
+		listDatabase: NewFriendListDB(logger, database),
+		friendView:   friendView,
+		userResolver: user.NewResolver(logger, database),
+		logger:       logger,
+	}, nil
+}
+
+func (manager *FriendListManagerService) CreateList(owner string, name string) (string, error) {
+	ownerID, err := manager.userResolver.GetUserID(owner)
+
+	if err != nil {
+		return "", err
+	}
+
+	name, err = manager.checkListName(ownerID, name)
+
+	if err != nil {
+		return "", err
+	}
+
+	return manager.listDatabase.AddList(manager.context, &service.FriendList{
+		Owner:  ownerID,
+		Name:   name,
+		DateAt: time.Now(),
+	})
+}
+
+func (manager *FriendListManagerService) RenameList(owner string, listID string, name string) error {
+	ownerID, err := manager.userResolver.GetUserID(owner)
+
+	if err != nil {
+		return ErrListNotFound
+	}
+
+	name, err = manager.checkListName(ownerID, name)
+
+	if err != nil {
+		return err
+	}
+
+	if err = manager.listDatabase.RenameList(manager.context, ownerID, listID, name); err != nil {
+		return ErrListNotFound
+	}
+
+	return nil
+}
+
+// DeleteList removes the list with its members, audiences pointing to
+// the deleted list stop matching anyone
+func (manager *FriendListManagerService) DeleteList(owner string, listID string) error {
+	ownerID, err := manager.userResolver.GetUserID(owner)
+
+	if err != nil {
+		return ErrListNotFound
+	}
+
+	if err = manager.listDatabase.DeleteList(manager.context, ownerID, listID); err != nil {
+		return ErrListNotFound
+	}
+
+	return manager.listDatabase.DeleteListMembers(manager.context, listID)
+}
+
+func (manager *FriendListManagerService) AddToList(owner string, listID string, friend string) error {
+	userIDs, err := manager.userResolver.GetUserIDs([]string{owner, friend})
+
+	if err != nil {
+		return err
+	}
+
+	ownerID, friendID := userIDs[owner], userIDs[friend]
+
+	if _, err = manager.listDatabase.GetList(manager.context, ownerID, listID); err != nil {
+		return ErrListNotFound
+	}
+
+	if !manager.friendView.CheckFriend(owner, friend) {
+		return ErrNotFriend
+	}
+
+	isAdded, err := manager.listDatabase.AddListMember(manager.context, &service.FriendListMember{
+		ListID: listID,
+		Owner:  ownerID,
+		Member: friendID,
+		DateAt: time.Now(),
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if !isAdded {
+		return ErrAlreadyInList
+	}
+
+	return nil
+}
+
+func (manager *FriendListManagerService) RemoveFromList(owner string, listID string, friend string) error {
+	userIDs, err := manager.userResolver.GetUserIDs([]string{owner, friend})
+
+	if err != nil {
+		return err
+	}
+
+	if _, err = manager.listDatabase.GetList(manager.context, userIDs[owner], listID); err != nil {
+		return ErrListNotFound
+	}
+
+	friendID, ok := userIDs[friend]
+
+	if !ok {
+		return ErrNotInList
+	}
+
+	if err = manager.listDatabase.DeleteListMember(manager.context, listID, friendID); err != nil {
+		return ErrNotInList
+	}
+
+	return nil
+}
+
+func (manager *FriendListManagerService) SetVisibility(owner string, field string, audience string) error {
+	if !isVisibilityField(field) {
+		return ErrUnknownField
+	}
+
+	ownerID, err := manager.userResolver.GetUserID(owner)
+
+	if err != nil {
+		return err
+	}
+
+	if !isPredefinedAudience(audience) {
+		if _, err = manager.listDatabase.GetList(manager.context, ownerID, audience); err != nil {
+			return ErrUnknownAudience
+		}
+	}
+
+	return manager.listDatabase.SetVisibility(manager.context, ownerID, field, audience)
+}
+
+func (manager *FriendListManagerService) checkListName(ownerID string, name string) (string, error) {
+	name = strings.TrimSpace(name)
+
+	if name == "" {
+		return "", ErrEmptyListName
+	}
+
+	if len([]rune(name)) > MaxListNameLength {
+		return "", ErrLongListName
+	}
+
+	if _, err := manager.listDatabase.GetListByName(manager.context, ownerID, name); err == nil {
+		return "", ErrListExists
+	}
+
+	return name, nil
+}
+
+func isPredefinedAudience(audience string) bool {
+	return audience == AudiencePublic || audience == AudienceFriends || audience == AudienceOnlyMe
+}
+
+func isVisibilityField(field string) bool {
+	if field == VisibilityEmail || field == VisibilityFriends {
+		return true
+	}
+
+	for _, profileField := range ProfileFields {
+		if field == profileField {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,31 @@
+package friendlists
+
+import (
+	"context"
+
+	"github.com/delonce/socialnetwork/internal/config"
+	"github.com/delonce/socialnetwork/internal/service"
+	"github.com/delonce/socialnetwork/pkg/logging"
+)
+
+func MigrateFriendListUserIDs(logger *logging.Logger, config *config.Config, userIDs map[string]string) error {
+	database, err := service.InitNewDatabase(config)
+
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return NewFriendListDB(logger, database).MigrateUserIDs(context.Background(), userIDs)
+}
+
+func MigrateFriendListIndexes(logger *logging.Logger, config *config.Config) error {
+	database, err := service.InitNewDatabase(config)
+
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return NewFriendListDB(logger, database).EnsureIndexes(context.Background())
+}
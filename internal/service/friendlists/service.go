@@ -0,0 +1,62 @@
+package friendlists
+
+import (
+	"errors"
+
+	"github.com/delonce/socialnetwork/internal/service"
+)
+
+// Audiences are stored as strings, any value except predefined ones is
+// treated as the ID of one of the owner's friend lists
+const (
+	AudiencePublic  = "public"
+	AudienceFriends = "friends"
+	AudienceOnlyMe  = "onlyme"
+)
+
+const (
+	VisibilityEmail    = "email"
+	VisibilityFriends  = "friends"
+	VisibilityBio      = "bio"
+	VisibilityLocation = "location"
+	VisibilityBirthday = "birthday"
+	VisibilityWebsite  = "website"
+)
+
+// ProfileFields are the visibility fields shown on the profile edit page,
+// display name and avatar are not among them as lists show them to everyone
+var ProfileFields = []string{VisibilityBio, VisibilityLocation, VisibilityBirthday, VisibilityWebsite}
+
+const MaxListNameLength = 64
+
+var (
+	ErrEmptyListName   = errors.New("List name can't be empty")
+	ErrLongListName    = errors.New("List name is too long")
+	ErrListExists      = errors.New("List with this name already exists")
+	ErrListNotFound    = errors.New("List not found")
+	ErrNotFriend       = errors.New("Only friends can be added to lists")
+	ErrAlreadyInList   = errors.New("User is already in this list")
+	ErrNotInList       = errors.New("User is not in this list")
+	ErrUnknownField    = errors.New("Unknown visibility field")
+	ErrUnknownAudience = errors.New("Unknown audience")
+)
+
+type FriendListManager interface {
+	CreateList(owner string, name string) (string, error)
+	RenameList(owner string, listID string, name string) error
+	DeleteList(owner string, listID string) error
+
+	AddToList(owner string, listID string, friend string) error
+	RemoveFromList(owner string, listID string, friend string) error
+
+	SetVisibility(owner string, field string, audience string) error
+}
+
+type FriendListViewer interface {
+	GetLists(owner string) []service.ViewFriendList
+	GetList(owner string, listID string) (*service.ViewFriendList, error)
+
+	GetVisibility(owner string) service.VisibilitySettings
+	CanView(owner string, viewer string, audience string) bool
+	GetProfile(owner *service.User, viewer string) service.ViewProfile
+}
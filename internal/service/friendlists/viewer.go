@@ -0,0 +1,240 @@
+package friendlists
+
+import (
+	"context"
+
+	"github.com/delonce/socialnetwork/internal/config"
+	"github.com/delonce/socialnetwork/internal/service"
+	"github.com/delonce/socialnetwork/internal/service/friends"
+	"github.com/delonce/socialnetwork/internal/service/user"
+	"github.com/delonce/socialnetwork/pkg/logging"
+)
+
+type FriendListViewService struct {
+	listDatabase FriendListQueries
+	friendView   friends.FriendViewer
+	userResolver user.Resolver
+	logger       *logging.Logger
+	context      context.Context
+}
+
+func NewFriendListViewer(logger *logging.Logger, config *config.Config) (FriendListViewer, error) {
+	database, err := service.InitNewDatabase(config)
+
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	friendView, err := friends.NewFriendViewer(logger, config)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &FriendListViewService{
+		listDatabase: NewFriendListDB(logger, database),
+		friendView:   friendView,
+		userResolver: user.NewResolver(logger, database),
+		logger:       logger,
+	}, nil
+}
+
+// GetLists returns owner's lists with members who are still friends,
+// members of ended friendships are hidden rather than deleted
+func (viewService *FriendListViewService) GetLists(owner string) []service.ViewFriendList {
+	ownerID, err := viewService.userResolver.GetUserID(owner)
+
+	if err != nil {
+		return []service.ViewFriendList{}
+	}
+
+	cursor, err := viewService.listDatabase.GetLists(viewService.context, ownerID)
+
+	if err != nil {
+		viewService.logger.Panic(err)
+	}
+
+	lists := []service.FriendList{}
+	err = cursor.All(viewService.context, &lists)
+
+	if err != nil {
+		viewService.logger.Panic(err)
+	}
+
+	cursor, err = viewService.listDatabase.GetListMembers(viewService.context, ownerID)
+
+	if err != nil {
+		viewService.logger.Panic(err)
+	}
+
+	members := []service.FriendListMember{}
+	err = cursor.All(viewService.context, &members)
+
+	if err != nil {
+		viewService.logger.Panic(err)
+	}
+
+	friendSet := map[string]bool{}
+
+	for _, friend := range viewService.friendView.GetUserFriends(owner) {
+		friendSet[friend] = true
+	}
+
+	memberIDs := []string{}
+
+	for _, member := range members {
+		memberIDs = append(memberIDs, member.Member)
+	}
+
+	usernames := viewService.userResolver.GetUsernames(memberIDs)
+	listMembers := map[string][]string{}
+
+	for _, member := range members {
+		username := usernames[member.Member]
+
+		if friendSet[username] {
+			listMembers[member.ListID] = append(listMembers[member.ListID], username)
+		}
+	}
+
+	viewLists := []service.ViewFriendList{}
+
+	for _, list := range lists {
+		viewLists = append(viewLists, service.ViewFriendList{
+			ID:      list.ID,
+			Name:    list.Name,
+			Members: listMembers[list.ID],
+		})
+	}
+
+	return viewLists
+}
+
+func (viewService *FriendListViewService) GetList(owner string, listID string) (*service.ViewFriendList, error) {
+	for _, list := range viewService.GetLists(owner) {
+		if list.ID == listID {
+			return &list, nil
+		}
+	}
+
+	return nil, ErrListNotFound
+}
+
+// GetVisibility returns owner's settings, fields without explicit value
+// stay public as they were before visibility settings existed, except the
+// birthday which is shown to friends until the owner decides otherwise
+func (viewService *FriendListViewService) GetVisibility(owner string) service.VisibilitySettings {
+	settings := service.VisibilitySettings{}
+
+	if ownerID, err := viewService.userResolver.GetUserID(owner); err == nil {
+		settings = viewService.getStoredVisibility(ownerID)
+	}
+
+	settings.Owner = owner
+
+	if settings.Email == "" {
+		settings.Email = AudiencePublic
+	}
+
+	if settings.Friends == "" {
+		settings.Friends = AudiencePublic
+	}
+
+	if settings.Bio == "" {
+		settings.Bio = AudiencePublic
+	}
+
+	if settings.Location == "" {
+		settings.Location = AudiencePublic
+	}
+
+	if settings.Birthday == "" {
+		settings.Birthday = AudienceFriends
+	}
+
+	if settings.Website == "" {
+		settings.Website = AudiencePublic
+	}
+
+	return settings
+}
+
+func (viewService *FriendListViewService) getStoredVisibility(ownerID string) service.VisibilitySettings {
+	settings := service.VisibilitySettings{}
+	result, err := viewService.listDatabase.GetVisibility(viewService.context, ownerID)
+
+	if err != nil {
+		return settings
+	}
+
+	if err = result.Decode(&settings); err != nil {
+		viewService.logger.Errorf("Error while decoding visibility settings of %s", ownerID)
+	}
+
+	return settings
+}
+
+func (viewService *FriendListViewService) CanView(owner string, viewer string, audience string) bool {
+	if owner == viewer {
+		return true
+	}
+
+	switch audience {
+	case AudiencePublic:
+		return true
+	case AudienceOnlyMe:
+		return false
+	case AudienceFriends:
+		return viewService.friendView.CheckFriend(owner, viewer)
+	}
+
+	userIDs, err := viewService.userResolver.GetUserIDs([]string{owner, viewer})
+
+	if err != nil || len(userIDs) != 2 {
+		return false
+	}
+
+	if _, err = viewService.listDatabase.GetList(viewService.context, userIDs[owner], audience); err != nil {
+		return false
+	}
+
+	return viewService.listDatabase.IsListMember(viewService.context, audience, userIDs[viewer]) &&
+		viewService.friendView.CheckFriend(owner, viewer)
+}
+
+// GetProfile returns the profile fields of the owner the viewer may see
+func (viewService *FriendListViewService) GetProfile(owner *service.User, viewer string) service.ViewProfile {
+	settings := viewService.GetVisibility(owner.Username)
+
+	profile := service.ViewProfile{
+		DisplayName: owner.DisplayName,
+		Avatar:      owner.Avatar,
+	}
+
+	if profile.DisplayName == "" {
+		profile.DisplayName = owner.Username
+	}
+
+	if profile.Avatar == "" {
+		profile.Avatar = user.DefaultAvatar
+	}
+
+	if owner.Bio != "" && viewService.CanView(owner.Username, viewer, settings.Bio) {
+		profile.Bio = owner.Bio
+	}
+
+	if owner.Location != "" && viewService.CanView(owner.Username, viewer, settings.Location) {
+		profile.Location = owner.Location
+	}
+
+	if owner.Birthday != nil && viewService.CanView(owner.Username, viewer, settings.Birthday) {
+		profile.FormatBirthday = owner.Birthday.Format(user.BirthdayLayout)
+	}
+
+	if owner.Website != "" && viewService.CanView(owner.Username, viewer, settings.Website) {
+		profile.Website = owner.Website
+	}
+
+	return profile
+}
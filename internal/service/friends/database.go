@@ -2,6 +2,8 @@ package friends
 
 import (
 	"context"
+	"strings"
+	"time"
 
 	"github.com/delonce/socialnetwork/internal/database"
 	"github.com/delonce/socialnetwork/internal/database/mongodb"
@@ -11,28 +13,56 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type FriendQueries interface {
 	GetAllProbFriends(ctx context.Context, friendUsernames []string) (*mongo.Cursor, error)
-	GetFriends(ctx context.Context, username string) (*mongo.Cursor, error)
+	GetFriends(ctx context.Context, userID string) (*mongo.Cursor, error)
 
-	FindUserRequests(ctx context.Context, username string) (*mongo.Cursor, error)
+	FindUserRequests(ctx context.Context, userID string) (*mongo.Cursor, error)
 	GetAllFriendRequestTo(ctx context.Context, to string) (*mongo.Cursor, error)
-	GetRequestByNames(ctx context.Context, from string, to string) (*mongo.SingleResult, error)
+	GetPendingRequests(ctx context.Context, userID string) (*mongo.Cursor, error)
+	GetOutgoingRequests(ctx context.Context, from string) (*mongo.Cursor, error)
+	CountOutgoingRequests(ctx context.Context, from string) (int64, error)
+	GetExpiredRequests(ctx context.Context, before time.Time) (*mongo.Cursor, error)
+	GetMutualFriendCounts(ctx context.Context, friendIDs []string, except []string) (*mongo.Cursor, error)
+	GetPairRequest(ctx context.Context, first string, second string) (*mongo.SingleResult, error)
 
 	AddFriendRequest(ctx context.Context, request *service.FriendRequest) (string, error)
-	DeleteRequest(ctx context.Context, from string, to string) error
+	SetRequestState(ctx context.Context, requestID string, fromState string, model bson.M) error
 
 	IsExistRequest(ctx context.Context, from string, to string) bool
 	IsExistFriend(ctx context.Context, from string, to string) bool
 
-	SetAcceptedMark(ctx context.Context, requestId string) error
-	SetDeniedMark(ctx context.Context, requestID string, from string, to string) error
+	AddTransition(ctx context.Context, transition *service.FriendshipTransition) error
+	GetPairHistory(ctx context.Context, first string, second string) (*mongo.Cursor, error)
+
+	MigrateLegacyStates(ctx context.Context) error
+	MigratePairUsers(ctx context.Context) error
+	EnsureRequestIndexes(ctx context.Context) error
+	EnsureBlockIndexes(ctx context.Context) error
+	MigrateUserIDs(ctx context.Context, userIDs map[string]string) error
+
+	CountFriends(ctx context.Context, userID string) (int64, error)
+
+	AddBlock(ctx context.Context, block *service.UserBlock) (bool, error)
+	DeleteBlock(ctx context.Context, blocker string, blocked string) error
+	IsExistBlock(ctx context.Context, blocker string, blocked string) bool
+	IsBlockedPair(ctx context.Context, first string, second string) bool
+	GetBlocksBy(ctx context.Context, blocker string) (*mongo.Cursor, error)
+	GetRelatedBlocks(ctx context.Context, userID string) (*mongo.Cursor, error)
+	DeletePairFollows(ctx context.Context, first string, second string) error
 
 	GetUsersExcept(ctx context.Context, except []string) (*mongo.Cursor, error)
 }
 
+// FriendGraphQueries is implemented by storages able to traverse the
+// friendship graph on their side, others fall back to BFS over GetFriends
+type FriendGraphQueries interface {
+	GetSeparationDegree(ctx context.Context, from string, to string, maxHops int) (int, error)
+}
+
 type FriendDB struct {
 	Storage database.DBStorage
 	Logger  *logging.Logger
@@ -43,6 +73,9 @@ func NewFriendDB(logger *logging.Logger, database *mongo.Database) FriendQueries
 		map[string]*mongo.Collection{
 			service.USER_COLLECTION:           database.Collection(service.USER_COLLECTION),
 			service.FRIEND_REQUEST_COLLECTION: database.Collection(service.FRIEND_REQUEST_COLLECTION),
+			service.FRIEND_HISTORY_COLLECTION: database.Collection(service.FRIEND_HISTORY_COLLECTION),
+			service.BLOCK_COLLECTION:          database.Collection(service.BLOCK_COLLECTION),
+			service.FOLLOW_COLLECTION:         database.Collection(service.FOLLOW_COLLECTION),
 		},
 		logger,
 	)
@@ -62,37 +95,33 @@ func (friendStorage *FriendDB) GetAllProbFriends(ctx context.Context, friendUser
 	return st.FindObjects(ctx, query, service.USER_COLLECTION)
 }
 
-func (friendStorage *FriendDB) GetFriends(ctx context.Context, username string) (*mongo.Cursor, error) {
+func (friendStorage *FriendDB) GetFriends(ctx context.Context, userID string) (*mongo.Cursor, error) {
 	query := bson.M{
 		"$and": []bson.M{
 			{"$or": []bson.M{
-				{"from": username},
-				{"to": username},
+				{"from": userID},
+				{"to": userID},
 			}},
 
-			{"isaccepted": true},
+			{"state": StateAccepted},
 		},
 	}
 
 	return friendStorage.Storage.FindObjects(ctx, query, service.FRIEND_REQUEST_COLLECTION)
 }
 
+// AddFriendRequest returns ErrPairExists when the unique pair key shows
+// that a request of the same pair was stored concurrently
 func (friendStorage *FriendDB) AddFriendRequest(ctx context.Context, request *service.FriendRequest) (string, error) {
 	st := friendStorage.Storage
 
-	isReqExist := friendStorage.IsExistRequest(ctx, request.From, request.To)
-
-	if isReqExist {
-		return "", nil
-	}
-
-	isReqExist = friendStorage.IsExistRequest(ctx, request.To, request.From)
+	requestID, err := st.CreateObject(ctx, request, service.FRIEND_REQUEST_COLLECTION)
 
-	if isReqExist {
-		return "", nil
+	if mongo.IsDuplicateKeyError(err) {
+		return "", ErrPairExists
 	}
 
-	return st.CreateObject(ctx, request, service.FRIEND_REQUEST_COLLECTION)
+	return requestID, err
 }
 
 func (friendStorage *FriendDB) IsExistRequest(ctx context.Context, from string, to string) bool {
@@ -102,6 +131,7 @@ func (friendStorage *FriendDB) IsExistRequest(ctx context.Context, from string,
 		"$and": []bson.M{
 			{"from": from},
 			{"to": to},
+			{"state": StatePending},
 		},
 	}
 
@@ -118,20 +148,9 @@ func (friendStorage *FriendDB) IsExistFriend(ctx context.Context, from string, t
 	st := friendStorage.Storage
 
 	query := bson.M{
-		"$or": []bson.M{
-			{"$and": []bson.M{
-				{"from": from},
-				{"to": to},
-				{"isaccepted": true},
-				{"isdenied": false},
-			}},
-
-			{"$and": []bson.M{
-				{"from": to},
-				{"to": from},
-				{"isaccepted": true},
-				{"isdenied": false},
-			}},
+		"$and": []bson.M{
+			pairQuery(from, to),
+			{"state": StateAccepted},
 		},
 	}
 
@@ -144,78 +163,128 @@ func (friendStorage *FriendDB) IsExistFriend(ctx context.Context, from string, t
 	return true
 }
 
-func (friendStorage *FriendDB) DeleteRequest(ctx context.Context, from string, to string) error {
+func (friendStorage *FriendDB) FindUserRequests(ctx context.Context, userID string) (*mongo.Cursor, error) {
 	st := friendStorage.Storage
 	query := bson.M{
 		"$and": []bson.M{
-			{"from": from},
-			{"to": to},
-			{"isaccepted": false},
+			{"to": userID},
+			{"state": StatePending},
 		},
 	}
 
-	return st.Delete(ctx, query, service.FRIEND_REQUEST_COLLECTION)
+	return st.FindObjects(ctx, query, service.FRIEND_REQUEST_COLLECTION)
 }
 
-func (friendStorage *FriendDB) FindUserRequests(ctx context.Context, username string) (*mongo.Cursor, error) {
+func (friendStorage *FriendDB) GetAllFriendRequestTo(ctx context.Context, to string) (*mongo.Cursor, error) {
 	st := friendStorage.Storage
 	query := bson.M{
 		"$and": []bson.M{
-			{"to": username},
-			{"isaccepted": false},
-			{"isdenied": false},
+			{"to": to},
+			{"state": StatePending},
 		},
 	}
 
 	return st.FindObjects(ctx, query, service.FRIEND_REQUEST_COLLECTION)
 }
 
-func (friendStorage *FriendDB) GetAllFriendRequestTo(ctx context.Context, to string) (*mongo.Cursor, error) {
+func (friendStorage *FriendDB) GetPendingRequests(ctx context.Context, userID string) (*mongo.Cursor, error) {
 	st := friendStorage.Storage
 	query := bson.M{
 		"$and": []bson.M{
-			{"to": to},
-			{"isaccepted": false},
-			{"isdenied": false},
+			{"$or": []bson.M{
+				{"from": userID},
+				{"to": userID},
+			}},
+
+			{"state": StatePending},
 		},
 	}
 
 	return st.FindObjects(ctx, query, service.FRIEND_REQUEST_COLLECTION)
 }
 
-func (friendStorage *FriendDB) SetAcceptedMark(ctx context.Context, requestID string) error {
-	model := bson.M{
-		"isaccepted": true,
-		"isdenied":   false,
+func (friendStorage *FriendDB) GetOutgoingRequests(ctx context.Context, from string) (*mongo.Cursor, error) {
+	st := friendStorage.Storage
+	query := bson.M{
+		"$and": []bson.M{
+			{"from": from},
+			{"state": StatePending},
+		},
 	}
 
-	return friendStorage.SetMark(ctx, requestID, model)
+	findOpts := options.FindOptions{}
+	findOpts.SetSort(bson.D{{Key: "date", Value: -1}})
+
+	return st.FindObjects(ctx, query, service.FRIEND_REQUEST_COLLECTION, &findOpts)
 }
 
-func (friendStorage *FriendDB) SetDeniedMark(ctx context.Context, requestID string, from string, to string) error {
-	model := bson.M{
-		"from":       from,
-		"to":         to,
-		"isaccepted": false,
-		"isdenied":   true,
+func (friendStorage *FriendDB) CountOutgoingRequests(ctx context.Context, from string) (int64, error) {
+	st := friendStorage.Storage
+	query := bson.M{
+		"$and": []bson.M{
+			{"from": from},
+			{"state": StatePending},
+		},
 	}
 
-	return friendStorage.SetMark(ctx, requestID, model)
+	return st.CountObjects(ctx, query, service.FRIEND_REQUEST_COLLECTION)
 }
 
-func (friendStorage *FriendDB) GetRequestByNames(ctx context.Context, from string, to string) (*mongo.SingleResult, error) {
+func (friendStorage *FriendDB) GetExpiredRequests(ctx context.Context, before time.Time) (*mongo.Cursor, error) {
 	st := friendStorage.Storage
 	query := bson.M{
 		"$and": []bson.M{
-			{"from": from},
-			{"to": to},
+			{"state": StatePending},
+			{"date": bson.M{"$lt": before}},
 		},
 	}
 
-	return st.FindOneObject(ctx, query, service.FRIEND_REQUEST_COLLECTION)
+	return st.FindObjects(ctx, query, service.FRIEND_REQUEST_COLLECTION)
+}
+
+// GetMutualFriendCounts counts for every user outside except how many of
+// the given friends they are friends with
+func (friendStorage *FriendDB) GetMutualFriendCounts(ctx context.Context, friendIDs []string, except []string) (*mongo.Cursor, error) {
+	st := friendStorage.Storage
+
+	pipeline := []bson.M{
+		{"$match": bson.M{
+			"state": StateAccepted,
+			"$or": []bson.M{
+				{"from": bson.M{"$in": friendIDs}},
+				{"to": bson.M{"$in": friendIDs}},
+			},
+		}},
+		{"$project": bson.M{
+			"edges": []bson.M{
+				{"candidate": "$from", "via": "$to"},
+				{"candidate": "$to", "via": "$from"},
+			},
+		}},
+		{"$unwind": "$edges"},
+		{"$match": bson.M{
+			"edges.via":       bson.M{"$in": friendIDs},
+			"edges.candidate": bson.M{"$nin": except},
+		}},
+		{"$group": bson.M{
+			"_id":   "$edges.candidate",
+			"count": bson.M{"$sum": 1},
+		}},
+		{"$sort": bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}},
+	}
+
+	return st.Aggregate(ctx, pipeline, service.FRIEND_REQUEST_COLLECTION)
 }
 
-func (friendStorage *FriendDB) SetMark(ctx context.Context, requestID string, model bson.M) error {
+func (friendStorage *FriendDB) GetPairRequest(ctx context.Context, first string, second string) (*mongo.SingleResult, error) {
+	st := friendStorage.Storage
+
+	return st.FindOneObject(ctx, pairQuery(first, second), service.FRIEND_REQUEST_COLLECTION)
+}
+
+// SetRequestState updates the request only if it is still in fromState,
+// so two concurrent transitions can't both succeed
+func (friendStorage *FriendDB) SetRequestState(ctx context.Context, requestID string, fromState string, model bson.M) error {
 	st := friendStorage.Storage
 	objRequestID, err := primitive.ObjectIDFromHex(requestID)
 
@@ -224,12 +293,413 @@ func (friendStorage *FriendDB) SetMark(ctx context.Context, requestID string, mo
 	}
 
 	query := bson.M{
-		"_id": objRequestID,
+		"$and": []bson.M{
+			{"_id": objRequestID},
+			{"state": fromState},
+		},
 	}
 
 	return st.Update(ctx, query, model, service.FRIEND_REQUEST_COLLECTION)
 }
 
+func (friendStorage *FriendDB) AddTransition(ctx context.Context, transition *service.FriendshipTransition) error {
+	st := friendStorage.Storage
+	_, err := st.CreateObject(ctx, transition, service.FRIEND_HISTORY_COLLECTION)
+
+	return err
+}
+
+func (friendStorage *FriendDB) GetPairHistory(ctx context.Context, first string, second string) (*mongo.Cursor, error) {
+	st := friendStorage.Storage
+	query := bson.M{"pair": pairKey(first, second)}
+
+	findOpts := options.FindOptions{}
+	findOpts.SetSort(bson.D{{Key: "date", Value: 1}})
+
+	return st.FindObjects(ctx, query, service.FRIEND_HISTORY_COLLECTION, &findOpts)
+}
+
+// MigrateLegacyStates converts requests stored with the old isaccepted/isdenied
+// marks into explicit states
+func (friendStorage *FriendDB) MigrateLegacyStates(ctx context.Context) error {
+	st := friendStorage.Storage
+
+	legacyStates := []struct {
+		query bson.M
+		state string
+	}{
+		{bson.M{"isaccepted": true, "isdenied": false}, StateAccepted},
+		{bson.M{"isdenied": true}, StateRemoved},
+		{bson.M{"isaccepted": false, "isdenied": false}, StatePending},
+	}
+
+	for _, legacy := range legacyStates {
+		legacy.query["state"] = bson.M{"$exists": false}
+
+		err := st.UpdateMany(ctx, legacy.query, bson.M{"state": legacy.state}, service.FRIEND_REQUEST_COLLECTION)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// MigratePairUsers fills the users array, which lets graph queries walk
+// friendships regardless of who sent the request, and the unique pair key
+func (friendStorage *FriendDB) MigratePairUsers(ctx context.Context) error {
+	st := friendStorage.Storage
+
+	query := bson.M{
+		"$or": []bson.M{
+			{"users": bson.M{"$exists": false}},
+			{"pair": bson.M{"$exists": false}},
+		},
+	}
+
+	cursor, err := st.FindObjects(ctx, query, service.FRIEND_REQUEST_COLLECTION)
+
+	if err != nil {
+		return err
+	}
+
+	requests := []service.FriendRequest{}
+
+	if err = cursor.All(ctx, &requests); err != nil {
+		return err
+	}
+
+	for _, request := range requests {
+		objRequestID, err := primitive.ObjectIDFromHex(request.ID)
+
+		if err != nil {
+			return err
+		}
+
+		model := bson.M{
+			"users": pairUsers(request.From, request.To),
+			"pair":  pairKey(request.From, request.To),
+		}
+
+		if err = st.Update(ctx, bson.M{"_id": objRequestID}, model, service.FRIEND_REQUEST_COLLECTION); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// EnsureRequestIndexes allows one request per pair, requests doubled by
+// concurrent sends before the index existed are removed except the newest one
+func (friendStorage *FriendDB) EnsureRequestIndexes(ctx context.Context) error {
+	return friendStorage.ensureUniqueIndex(ctx, []string{"pair"}, service.FRIEND_REQUEST_COLLECTION)
+}
+
+// EnsureBlockIndexes allows one block per blocker and blocked user
+func (friendStorage *FriendDB) EnsureBlockIndexes(ctx context.Context) error {
+	return friendStorage.ensureUniqueIndex(ctx, []string{"blocker", "blocked"}, service.BLOCK_COLLECTION)
+}
+
+// ensureUniqueIndex creates the index, when documents already break it the
+// newest one of every duplicate group is kept and the rest are removed
+func (friendStorage *FriendDB) ensureUniqueIndex(ctx context.Context, fields []string, collection string) error {
+	st := friendStorage.Storage
+
+	err := st.EnsureUniqueIndex(ctx, fields, collection)
+
+	if !mongo.IsDuplicateKeyError(err) {
+		return err
+	}
+
+	group := bson.M{}
+
+	for _, field := range fields {
+		group[field] = "$" + field
+	}
+
+	pipeline := []bson.M{
+		{"$sort": bson.M{"date": -1}},
+		{"$group": bson.M{
+			"_id":   group,
+			"ids":   bson.M{"$push": "$_id"},
+			"count": bson.M{"$sum": 1},
+		}},
+		{"$match": bson.M{"count": bson.M{"$gt": 1}}},
+	}
+
+	cursor, err := st.Aggregate(ctx, pipeline, collection)
+
+	if err != nil {
+		return err
+	}
+
+	duplicates := []struct {
+		IDs []primitive.ObjectID `bson:"ids"`
+	}{}
+
+	if err = cursor.All(ctx, &duplicates); err != nil {
+		return err
+	}
+
+	for _, duplicate := range duplicates {
+		query := bson.M{"_id": bson.M{"$in": duplicate.IDs[1:]}}
+
+		if err = st.DeleteMany(ctx, query, collection); err != nil {
+			return err
+		}
+	}
+
+	return st.EnsureUniqueIndex(ctx, fields, collection)
+}
+
+// MigrateUserIDs rewrites usernames stored in requests, their history and
+// blocks into user ids, values which are not known usernames are left untouched
+func (friendStorage *FriendDB) MigrateUserIDs(ctx context.Context, userIDs map[string]string) error {
+	st := friendStorage.Storage
+
+	cursor, err := st.FindObjects(ctx, bson.M{}, service.FRIEND_REQUEST_COLLECTION)
+
+	if err != nil {
+		return err
+	}
+
+	requests := []service.FriendRequest{}
+
+	if err = cursor.All(ctx, &requests); err != nil {
+		return err
+	}
+
+	for _, request := range requests {
+		from, isFromChanged := service.MapUserID(userIDs, request.From)
+		to, isToChanged := service.MapUserID(userIDs, request.To)
+
+		if !isFromChanged && !isToChanged {
+			continue
+		}
+
+		objRequestID, err := primitive.ObjectIDFromHex(request.ID)
+
+		if err != nil {
+			return err
+		}
+
+		model := bson.M{
+			"from":  from,
+			"to":    to,
+			"users": pairUsers(from, to),
+			"pair":  pairKey(from, to),
+		}
+
+		if err = st.Update(ctx, bson.M{"_id": objRequestID}, model, service.FRIEND_REQUEST_COLLECTION); err != nil {
+			return err
+		}
+	}
+
+	cursor, err = st.FindObjects(ctx, bson.M{}, service.FRIEND_HISTORY_COLLECTION)
+
+	if err != nil {
+		return err
+	}
+
+	history := []service.FriendshipTransition{}
+
+	if err = cursor.All(ctx, &history); err != nil {
+		return err
+	}
+
+	for _, transition := range history {
+		pair := strings.Split(transition.Pair, ":")
+
+		if len(pair) != 2 {
+			continue
+		}
+
+		first, isFirstChanged := service.MapUserID(userIDs, pair[0])
+		second, isSecondChanged := service.MapUserID(userIDs, pair[1])
+		actor, isActorChanged := service.MapUserID(userIDs, transition.Actor)
+
+		if !isFirstChanged && !isSecondChanged && !isActorChanged {
+			continue
+		}
+
+		objTransitionID, err := primitive.ObjectIDFromHex(transition.ID)
+
+		if err != nil {
+			return err
+		}
+
+		model := bson.M{
+			"pair":  pairKey(first, second),
+			"actor": actor,
+		}
+
+		if err = st.Update(ctx, bson.M{"_id": objTransitionID}, model, service.FRIEND_HISTORY_COLLECTION); err != nil {
+			return err
+		}
+	}
+
+	for _, field := range []string{"blocker", "blocked"} {
+		for username, userID := range userIDs {
+			query := bson.M{field: username}
+
+			if err = st.UpdateMany(ctx, query, bson.M{field: userID}, service.BLOCK_COLLECTION); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (friendStorage *FriendDB) CountFriends(ctx context.Context, userID string) (int64, error) {
+	st := friendStorage.Storage
+	query := bson.M{
+		"$and": []bson.M{
+			{"users": userID},
+			{"state": StateAccepted},
+		},
+	}
+
+	return st.CountObjects(ctx, query, service.FRIEND_REQUEST_COLLECTION)
+}
+
+// GetSeparationDegree walks accepted friendships with $graphLookup starting
+// from the first user, edge depth 0 holds the user's own friendships
+func (friendStorage *FriendDB) GetSeparationDegree(ctx context.Context, from string, to string, maxHops int) (int, error) {
+	st := friendStorage.Storage
+	objFromID, err := primitive.ObjectIDFromHex(from)
+
+	if err != nil {
+		return 0, err
+	}
+
+	pipeline := []bson.M{
+		{"$match": bson.M{"_id": objFromID}},
+		{"$graphLookup": bson.M{
+			"from":                    service.FRIEND_REQUEST_COLLECTION,
+			"startWith":               bson.M{"$toString": "$_id"},
+			"connectFromField":        "users",
+			"connectToField":          "users",
+			"as":                      "network",
+			"maxDepth":                maxHops - 1,
+			"depthField":              "depth",
+			"restrictSearchWithMatch": bson.M{"state": StateAccepted},
+		}},
+		{"$unwind": "$network"},
+		{"$match": bson.M{"network.users": to}},
+		{"$group": bson.M{
+			"_id":   nil,
+			"depth": bson.M{"$min": "$network.depth"},
+		}},
+	}
+
+	cursor, err := st.Aggregate(ctx, pipeline, service.USER_COLLECTION)
+
+	if err != nil {
+		return 0, err
+	}
+
+	result := []struct {
+		Depth int `bson:"depth"`
+	}{}
+
+	if err = cursor.All(ctx, &result); err != nil {
+		return 0, err
+	}
+
+	if len(result) == 0 {
+		return 0, nil
+	}
+
+	return result[0].Depth + 1, nil
+}
+
+// AddBlock reports whether the block was added, false means the user is
+// already blocked
+func (friendStorage *FriendDB) AddBlock(ctx context.Context, block *service.UserBlock) (bool, error) {
+	st := friendStorage.Storage
+	query := bson.M{
+		"blocker": block.Blocker,
+		"blocked": block.Blocked,
+	}
+
+	return st.InsertIfAbsent(ctx, query, block, service.BLOCK_COLLECTION)
+}
+
+func (friendStorage *FriendDB) DeleteBlock(ctx context.Context, blocker string, blocked string) error {
+	st := friendStorage.Storage
+	query := bson.M{
+		"$and": []bson.M{
+			{"blocker": blocker},
+			{"blocked": blocked},
+		},
+	}
+
+	return st.Delete(ctx, query, service.BLOCK_COLLECTION)
+}
+
+func (friendStorage *FriendDB) IsExistBlock(ctx context.Context, blocker string, blocked string) bool {
+	st := friendStorage.Storage
+	query := bson.M{
+		"$and": []bson.M{
+			{"blocker": blocker},
+			{"blocked": blocked},
+		},
+	}
+
+	_, err := st.FindOneObject(ctx, query, service.BLOCK_COLLECTION)
+
+	return err == nil
+}
+
+func (friendStorage *FriendDB) IsBlockedPair(ctx context.Context, first string, second string) bool {
+	return friendStorage.IsExistBlock(ctx, first, second) || friendStorage.IsExistBlock(ctx, second, first)
+}
+
+func (friendStorage *FriendDB) GetBlocksBy(ctx context.Context, blocker string) (*mongo.Cursor, error) {
+	st := friendStorage.Storage
+	query := bson.M{"blocker": blocker}
+
+	findOpts := options.FindOptions{}
+	findOpts.SetSort(bson.D{{Key: "date", Value: -1}})
+
+	return st.FindObjects(ctx, query, service.BLOCK_COLLECTION, &findOpts)
+}
+
+func (friendStorage *FriendDB) GetRelatedBlocks(ctx context.Context, userID string) (*mongo.Cursor, error) {
+	st := friendStorage.Storage
+	query := bson.M{
+		"$or": []bson.M{
+			{"blocker": userID},
+			{"blocked": userID},
+		},
+	}
+
+	return st.FindObjects(ctx, query, service.BLOCK_COLLECTION)
+}
+
+// DeletePairFollows removes follows in both directions, a block ends them
+// for good instead of hiding them until the unblock
+func (friendStorage *FriendDB) DeletePairFollows(ctx context.Context, first string, second string) error {
+	st := friendStorage.Storage
+	query := bson.M{
+		"$or": []bson.M{
+			{"$and": []bson.M{
+				{"follower": first},
+				{"followee": second},
+			}},
+
+			{"$and": []bson.M{
+				{"follower": second},
+				{"followee": first},
+			}},
+		},
+	}
+
+	return st.DeleteMany(ctx, query, service.FOLLOW_COLLECTION)
+}
+
 func (friendStorage *FriendDB) GetUsersExcept(ctx context.Context, except []string) (*mongo.Cursor, error) {
 	st := friendStorage.Storage
 
@@ -239,3 +709,19 @@ func (friendStorage *FriendDB) GetUsersExcept(ctx context.Context, except []stri
 
 	return st.FindObjects(ctx, query, service.USER_COLLECTION)
 }
+
+func pairQuery(first string, second string) bson.M {
+	return bson.M{
+		"$or": []bson.M{
+			{"$and": []bson.M{
+				{"from": first},
+				{"to": second},
+			}},
+
+			{"$and": []bson.M{
+				{"from": second},
+				{"to": first},
+			}},
+		},
+	}
+}
@@ -0,0 +1,59 @@
+package friends
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrSelfRequest      = errors.New("Can't send friend request to yourself")
+	ErrRequestNotFound  = errors.New("Friend request not found")
+	ErrRequestExists    = errors.New("Friend request already sent")
+	ErrIncomingRequest  = errors.New("This user already sent you a friend request")
+	ErrAlreadyFriends   = errors.New("Users are already friends")
+	ErrNotFriends       = errors.New("Users are not friends")
+	ErrBlocked          = errors.New("Friendship is blocked")
+	ErrNotRequestSender = errors.New("Only the sender can cancel a friend request")
+	ErrNotRequestTarget = errors.New("Only the recipient can answer a friend request")
+	ErrConcurrentChange = errors.New("Friendship was changed by another request")
+	ErrPairExists       = errors.New("Friendship of the pair is already stored")
+	ErrSelfBlock        = errors.New("Can't block yourself")
+	ErrAlreadyBlocked   = errors.New("User is already blocked")
+	ErrBlockNotFound    = errors.New("User is not blocked")
+	ErrTooManyRequests  = errors.New("Too many outgoing friend requests")
+	ErrLongMessage      = errors.New("Friend request message is too long")
+)
+
+type TransitionError struct {
+	State  string
+	Action string
+}
+
+func (err *TransitionError) Error() string {
+	state := err.State
+
+	if state == StateNone {
+		state = "none"
+	}
+
+	return fmt.Sprintf("Can't %s friendship in state %s", err.Action, state)
+}
+
+// transitionError returns a well-known error for common invalid transitions
+// and a TransitionError for the rest
+func transitionError(state string, action string) error {
+	switch {
+	case state == StateBlocked:
+		return ErrBlocked
+	case action == ActionSend && state == StatePending:
+		return ErrRequestExists
+	case action == ActionSend && state == StateAccepted:
+		return ErrAlreadyFriends
+	case action == ActionRemove:
+		return ErrNotFriends
+	case state == StateNone:
+		return ErrRequestNotFound
+	}
+
+	return &TransitionError{State: state, Action: action}
+}
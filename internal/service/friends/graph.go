@@ -0,0 +1,90 @@
+package friends
+
+const MaxSeparationDegree = 3
+
+func (viewService *FriendViewService) GetMutualFriends(first string, second string) []string {
+	secondFriends := map[string]bool{}
+
+	for _, friend := range viewService.GetUserFriends(second) {
+		secondFriends[friend] = true
+	}
+
+	mutual := []string{}
+
+	for _, friend := range viewService.GetUserFriends(first) {
+		if secondFriends[friend] {
+			mutual = append(mutual, friend)
+		}
+	}
+
+	return mutual
+}
+
+func (viewService *FriendViewService) CountFriends(username string) int64 {
+	userID, err := viewService.userResolver.GetUserID(username)
+
+	if err != nil {
+		return 0
+	}
+
+	amount, err := viewService.friendDatabase.CountFriends(viewService.context, userID)
+
+	if err != nil {
+		viewService.logger.Errorf("Error when counting friends of %s, %v", username, err)
+		return 0
+	}
+
+	return amount
+}
+
+// GetSeparationDegree returns the number of hops between the users or 0
+// when they are not connected within MaxSeparationDegree hops
+func (viewService *FriendViewService) GetSeparationDegree(from string, to string) int {
+	if from == to {
+		return 0
+	}
+
+	fromID, toID, ok := viewService.getPairIDs(from, to)
+
+	if !ok {
+		return 0
+	}
+
+	if graph, ok := viewService.friendDatabase.(FriendGraphQueries); ok {
+		degree, err := graph.GetSeparationDegree(viewService.context, fromID, toID, MaxSeparationDegree)
+
+		if err == nil {
+			return degree
+		}
+
+		viewService.logger.Errorf("Graph query failed for %s - %s, falling back to BFS, %v", from, to, err)
+	}
+
+	return viewService.bfsSeparationDegree(fromID, toID, MaxSeparationDegree)
+}
+
+func (viewService *FriendViewService) bfsSeparationDegree(from string, to string, maxHops int) int {
+	visited := map[string]bool{from: true}
+	frontier := []string{from}
+
+	for hop := 1; hop <= maxHops && len(frontier) > 0; hop++ {
+		next := []string{}
+
+		for _, userID := range frontier {
+			for _, friend := range viewService.getFriendIDs(userID) {
+				if friend == to {
+					return hop
+				}
+
+				if !visited[friend] {
+					visited[friend] = true
+					next = append(next, friend)
+				}
+			}
+		}
+
+		frontier = next
+	}
+
+	return 0
+}
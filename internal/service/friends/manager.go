@@ -2,20 +2,39 @@ package friends
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"time"
 
 	"github.com/delonce/socialnetwork/internal/config"
 	"github.com/delonce/socialnetwork/internal/service"
+	"github.com/delonce/socialnetwork/internal/service/activity"
+	"github.com/delonce/socialnetwork/internal/service/user"
 	"github.com/delonce/socialnetwork/pkg/logging"
+
+	"go.mongodb.org/mongo-driver/bson"
 )
 
 type FriendManagerService struct {
 	friendDatabase FriendQueries
+	userResolver   user.Resolver
+	recorder       activity.Recorder
 	logger         *logging.Logger
 	context        context.Context
+
+	requestTTL          time.Duration
+	maxOutgoingRequests int64
 }
 
 func NewFriendManager(logger *logging.Logger, config *config.Config) (FriendManager, error) {
+	return newFriendManagerService(logger, config)
+}
+
+func NewRequestExpirer(logger *logging.Logger, config *config.Config) (RequestExpirer, error) {
+	return newFriendManagerService(logger, config)
+}
+
+func newFriendManagerService(logger *logging.Logger, config *config.Config) (*FriendManagerService, error) {
 	database, err := service.InitNewDatabase(config)
 
 	if err != nil {
@@ -24,82 +43,404 @@ func NewFriendManager(logger *logging.Logger, config *config.Config) (FriendMana
 	}
 
 	return &FriendManagerService{
-		friendDatabase: NewFriendDB(logger, database),
-		logger:         logger,
+		friendDatabase:      NewFriendDB(logger, database),
+		userResolver:        user.NewResolver(logger, database),
+		recorder:            activity.NewRecorder(logger, database),
+		logger:              logger,
+		requestTTL:          config.Friends.RequestTTL,
+		maxOutgoingRequests: config.Friends.MaxOutgoingRequests,
 	}, nil
 }
 
 func (manager *FriendManagerService) SendFriendRequest(from string, to string) error {
-	newRequest := service.FriendRequest{
-		From:       from,
-		To:         to,
-		IsAccepted: false,
-		DateAt:     time.Now(),
+	return manager.SendFriendRequestWithMessage(from, to, "")
+}
+
+// SendFriendRequestWithMessage sends a request with an optional note,
+// refusing it when the sender already has too many outstanding requests
+func (manager *FriendManagerService) SendFriendRequestWithMessage(from string, to string, message string) error {
+	message = strings.TrimSpace(message)
+
+	if len([]rune(message)) > MaxRequestMessageLength {
+		return ErrLongMessage
 	}
 
-	_, err := manager.friendDatabase.AddFriendRequest(manager.context, &newRequest)
+	if manager.maxOutgoingRequests > 0 {
+		fromID, err := manager.userResolver.GetUserID(from)
 
-	return err
+		if err != nil {
+			return err
+		}
+
+		count, err := manager.friendDatabase.CountOutgoingRequests(manager.context, fromID)
+
+		if err != nil {
+			return err
+		}
+
+		if count >= manager.maxOutgoingRequests {
+			return ErrTooManyRequests
+		}
+	}
+
+	return manager.changeFriendship(from, to, ActionSend, message)
 }
 
 func (manager *FriendManagerService) CancelRequest(from string, to string) error {
-	fDb := manager.friendDatabase
+	return manager.changeFriendship(from, to, ActionCancel, "")
+}
 
-	return fDb.DeleteRequest(manager.context, from, to)
+func (manager *FriendManagerService) RejectRequest(user string, friend string) error {
+	return manager.changeFriendship(user, friend, ActionDecline, "")
 }
 
-func (manager *FriendManagerService) RejectRequest(from string, to string) error {
+func (manager *FriendManagerService) AcceptNewFriend(user string, friend string) error {
+	return manager.changeFriendship(user, friend, ActionAccept, "")
+}
+
+func (manager *FriendManagerService) DeleteFriend(user string, friend string) error {
+	return manager.changeFriendship(user, friend, ActionRemove, "")
+}
+
+func (manager *FriendManagerService) GetFriendship(username string, friend string) (*service.FriendRequest, error) {
+	userIDs, err := manager.getPairIDs(username, friend)
+
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := manager.getPairRequest(userIDs[username], userIDs[friend])
+
+	if err != nil {
+		return nil, err
+	}
+
+	usernames := map[string]string{userIDs[username]: username, userIDs[friend]: friend}
+
+	request.From = usernames[request.From]
+	request.To = usernames[request.To]
+	request.Users = pairUsers(request.From, request.To)
+
+	return request, nil
+}
+
+func (manager *FriendManagerService) getPairRequest(userID string, friendID string) (*service.FriendRequest, error) {
+	request := service.FriendRequest{}
+
+	result, err := manager.friendDatabase.GetPairRequest(manager.context, userID, friendID)
+
+	if err != nil {
+		return nil, ErrRequestNotFound
+	}
+
+	if err := result.Decode(&request); err != nil {
+		manager.logger.Errorf("Error while decoding friend request %s - %s", userID, friendID)
+		return nil, err
+	}
+
+	return &request, nil
+}
+
+func (manager *FriendManagerService) getPairIDs(first string, second string) (map[string]string, error) {
+	userIDs, err := manager.userResolver.GetUserIDs([]string{first, second})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := userIDs[first]; !ok {
+		return nil, user.ErrUserNotFound
+	}
+
+	if _, ok := userIDs[second]; !ok {
+		return nil, user.ErrUserNotFound
+	}
+
+	return userIDs, nil
+}
+
+// BlockUser adds the user to the block list and moves the pair into the blocked
+// state, which also ends an existing friendship or pending request
+func (manager *FriendManagerService) BlockUser(user string, blocked string) error {
+	if user == blocked {
+		return ErrSelfBlock
+	}
+
+	userIDs, err := manager.getPairIDs(user, blocked)
+
+	if err != nil {
+		return err
+	}
+
 	fDb := manager.friendDatabase
-	err := fDb.DeleteRequest(manager.context, from, to)
+	userID, blockedID := userIDs[user], userIDs[blocked]
+
+	isAdded, err := fDb.AddBlock(manager.context, &service.UserBlock{
+		Blocker: userID,
+		Blocked: blockedID,
+		DateAt:  time.Now(),
+	})
 
 	if err != nil {
-		err = fDb.DeleteRequest(manager.context, to, from)
+		return err
+	}
 
-		if err != nil {
-			return err
+	if !isAdded {
+		return ErrAlreadyBlocked
+	}
+
+	invalidateSuggestions()
+
+	err = manager.changeFriendship(user, blocked, ActionBlock, "")
+
+	if err != nil && err != ErrBlocked {
+		// the block and the friendship state must agree, so the block is
+		// taken back when the pair can't be moved into the blocked state
+		if deleteErr := fDb.DeleteBlock(manager.context, userID, blockedID); deleteErr != nil {
+			manager.logger.Errorf("Failed to roll back block %s - %s, %v", user, blocked, deleteErr)
 		}
+
+		return err
+	}
+
+	if err = fDb.DeletePairFollows(manager.context, userID, blockedID); err != nil {
+		manager.logger.Errorf("Failed to delete follows of blocked pair %s - %s, %v", user, blocked, err)
+		return err
 	}
 
 	return nil
 }
 
-func (manager *FriendManagerService) AcceptNewFriend(user string, friend string) error {
-	requestID, err := manager.GetRequestID(friend, user)
+func (manager *FriendManagerService) UnblockUser(user string, blocked string) error {
+	userIDs, err := manager.getPairIDs(user, blocked)
+
+	if err != nil {
+		return err
+	}
+
+	fDb := manager.friendDatabase
+	userID, blockedID := userIDs[user], userIDs[blocked]
+
+	if !fDb.IsExistBlock(manager.context, userID, blockedID) {
+		return ErrBlockNotFound
+	}
+
+	if err = fDb.DeleteBlock(manager.context, userID, blockedID); err != nil {
+		return err
+	}
+
+	invalidateSuggestions()
+
+	if fDb.IsBlockedPair(manager.context, userID, blockedID) {
+		return nil
+	}
+
+	err = manager.changeFriendship(user, blocked, ActionUnblock, "")
+	transitionErr := &TransitionError{}
+
+	// the pair isn't in the blocked state, so nothing is left to roll back to
+	if err == ErrRequestNotFound || errors.As(err, &transitionErr) {
+		return nil
+	}
 
 	if err != nil {
+		// the pair is still blocked, so the block is restored
+		_, addErr := fDb.AddBlock(manager.context, &service.UserBlock{
+			Blocker: userID,
+			Blocked: blockedID,
+			DateAt:  time.Now(),
+		})
+
+		if addErr != nil {
+			manager.logger.Errorf("Failed to roll back unblock %s - %s, %v", user, blocked, addErr)
+		}
+
 		return err
 	}
 
-	return manager.friendDatabase.SetAcceptedMark(manager.context, requestID)
+	return nil
 }
 
-func (manager *FriendManagerService) DeleteFriend(user string, friend string) error {
-	requestID, err := manager.GetRequestID(friend, user)
+// ExpireRequests moves pending requests older than the configured period into
+// the expired state, the sender may send a new request afterwards
+func (manager *FriendManagerService) ExpireRequests() (int, error) {
+	if manager.requestTTL <= 0 {
+		return 0, nil
+	}
+
+	cursor, err := manager.friendDatabase.GetExpiredRequests(manager.context, time.Now().Add(-manager.requestTTL))
 
 	if err != nil {
-		requestID, err = manager.GetRequestID(user, friend)
+		return 0, err
+	}
+
+	requests := []service.FriendRequest{}
+
+	if err = cursor.All(manager.context, &requests); err != nil {
+		return 0, err
+	}
+
+	expired := 0
+
+	for _, request := range requests {
+		model := bson.M{"state": StateExpired}
+
+		if err = manager.friendDatabase.SetRequestState(manager.context, request.ID, StatePending, model); err != nil {
+			continue
+		}
+
+		expired++
+
+		err = manager.friendDatabase.AddTransition(manager.context, &service.FriendshipTransition{
+			Pair:      pairKey(request.From, request.To),
+			RequestID: request.ID,
+			Action:    ActionExpire,
+			FromState: StatePending,
+			ToState:   StateExpired,
+			DateAt:    time.Now(),
+		})
 
 		if err != nil {
-			return err
+			manager.logger.Errorf("Failed to save friendship history %s - %s, %v", request.From, request.To, err)
 		}
 	}
 
-	return manager.friendDatabase.SetDeniedMark(manager.context, requestID, friend, user)
+	if expired > 0 {
+		invalidateSuggestions()
+	}
+
+	return expired, nil
 }
 
-func (manager *FriendManagerService) GetRequestID(from string, to string) (string, error) {
-	request := service.FriendRequest{}
+// changeFriendship validates the action against the current state of the pair,
+// stores the new state and appends the transition to the pair history
+func (manager *FriendManagerService) changeFriendship(actor string, other string, action string, message string) error {
+	if actor == other {
+		return ErrSelfRequest
+	}
 
-	result, err := manager.friendDatabase.GetRequestByNames(manager.context, from, to)
+	userIDs, err := manager.getPairIDs(actor, other)
 
 	if err != nil {
-		return "", err
+		return err
 	}
 
-	if err := result.Decode(&request); err != nil {
-		manager.logger.Errorf("Error while decoding %s", request)
-		return "", err
+	actorID, otherID := userIDs[actor], userIDs[other]
+	request, err := manager.getPairRequest(actorID, otherID)
+
+	if err != nil && err != ErrRequestNotFound {
+		return err
+	}
+
+	state := StateNone
+
+	if request != nil {
+		state = request.State
 	}
 
-	return request.ID, nil
+	if action == ActionSend && manager.friendDatabase.IsBlockedPair(manager.context, actorID, otherID) {
+		return ErrBlocked
+	}
+
+	if action == ActionSend && state == StatePending && request.From == otherID {
+		return ErrIncomingRequest
+	}
+
+	nextState, err := nextFriendshipState(state, action)
+
+	if err != nil {
+		return err
+	}
+
+	if err = checkActorRole(request, actorID, action); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	requestID := ""
+
+	if request == nil {
+		requestID, err = manager.friendDatabase.AddFriendRequest(manager.context, &service.FriendRequest{
+			From:    actorID,
+			To:      otherID,
+			Users:   pairUsers(actorID, otherID),
+			Pair:    pairKey(actorID, otherID),
+			DateAt:  now,
+			State:   nextState,
+			Message: message,
+		})
+
+		// another request of the pair was stored first, the action is
+		// checked again against its state
+		if err == ErrPairExists {
+			return manager.changeFriendship(actor, other, action, message)
+		}
+	} else {
+		requestID = request.ID
+		model := bson.M{"state": nextState}
+
+		if action == ActionSend || action == ActionBlock {
+			model["from"] = actorID
+			model["to"] = otherID
+			model["date"] = now
+			model["message"] = message
+		}
+
+		err = manager.friendDatabase.SetRequestState(manager.context, requestID, state, model)
+
+		if err != nil {
+			manager.logger.Errorf("Failed to %s friendship %s - %s, %v", action, actor, other, err)
+			return ErrConcurrentChange
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+
+	invalidateSuggestions()
+
+	err = manager.friendDatabase.AddTransition(manager.context, &service.FriendshipTransition{
+		Pair:      pairKey(actorID, otherID),
+		RequestID: requestID,
+		Actor:     actorID,
+		Action:    action,
+		FromState: state,
+		ToState:   nextState,
+		DateAt:    now,
+	})
+
+	if err != nil {
+		manager.logger.Errorf("Failed to save friendship history %s - %s, %v", actor, other, err)
+	}
+
+	if nextState == StateAccepted {
+		manager.recorder.Record(service.ActivityEvent{
+			Type:    activity.TypeFriendship,
+			Actor:   actorID,
+			Subject: otherID,
+			DateAt:  now,
+		})
+	}
+
+	return nil
+}
+
+func checkActorRole(request *service.FriendRequest, actorID string, action string) error {
+	if request == nil {
+		return nil
+	}
+
+	switch action {
+	case ActionCancel:
+		if request.From != actorID {
+			return ErrNotRequestSender
+		}
+	case ActionAccept, ActionDecline:
+		if request.To != actorID {
+			return ErrNotRequestTarget
+		}
+	}
+
+	return nil
 }
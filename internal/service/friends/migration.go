@@ -0,0 +1,56 @@
+package friends
+
+import (
+	"context"
+
+	"github.com/delonce/socialnetwork/internal/config"
+	"github.com/delonce/socialnetwork/internal/service"
+	"github.com/delonce/socialnetwork/pkg/logging"
+)
+
+func MigrateFriendships(logger *logging.Logger, config *config.Config) error {
+	database, err := service.InitNewDatabase(config)
+
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	friendDatabase := NewFriendDB(logger, database)
+
+	if err = friendDatabase.MigrateLegacyStates(context.Background()); err != nil {
+		return err
+	}
+
+	return friendDatabase.MigratePairUsers(context.Background())
+}
+
+func MigrateFriendshipUserIDs(logger *logging.Logger, config *config.Config, userIDs map[string]string) error {
+	database, err := service.InitNewDatabase(config)
+
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return NewFriendDB(logger, database).MigrateUserIDs(context.Background(), userIDs)
+}
+
+// MigrateFriendshipIndexes runs after user ids are migrated, otherwise
+// rewriting a username into an id could break the unique indexes
+func MigrateFriendshipIndexes(logger *logging.Logger, config *config.Config) error {
+	database, err := service.InitNewDatabase(config)
+
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	friendDatabase := NewFriendDB(logger, database)
+
+	if err = friendDatabase.EnsureRequestIndexes(context.Background()); err != nil {
+		return err
+	}
+
+	return friendDatabase.EnsureBlockIndexes(context.Background())
+}
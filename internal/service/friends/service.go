@@ -5,24 +5,43 @@ import (
 )
 
 type FriendViewer interface {
-	GetAllProbablyFriends(username string) []service.User
+	GetAllProbablyFriends(username string) []service.FriendSuggestion
 	GetAllFriendRequests(username string) []service.FriendRequest
+	GetOutgoingRequests(username string) []service.ViewFriendRequest
 	GetUserFriends(username string) []string
 
 	CheckRequest(from string, to string) bool
 	CheckFriend(from string, to string) bool
 
+	GetFriendshipHistory(user string, friend string) []service.FriendshipTransition
+
+	GetMutualFriends(first string, second string) []string
+	GetSeparationDegree(from string, to string) int
+	CountFriends(username string) int64
+
+	GetBlockedUsers(username string) []service.UserBlock
+	CheckBlock(blocker string, blocked string) bool
+	CheckBlockedPair(first string, second string) bool
+
 	// FOR MESSAGE VIEWER
-	GetUsersExceptSomeone(exceptUsers []string) []service.User
+	GetUsersExceptSomeone(username string, exceptUsers []string) []service.User
 }
 
 type FriendManager interface {
-	GetRequestID(from string, to string) (string, error)
+	GetFriendship(user string, friend string) (*service.FriendRequest, error)
 
 	SendFriendRequest(from string, to string) error
+	SendFriendRequestWithMessage(from string, to string, message string) error
 	CancelRequest(from string, to string) error
-	RejectRequest(from string, to string) error
+	RejectRequest(user string, friend string) error
 
 	AcceptNewFriend(user string, friend string) error
 	DeleteFriend(user string, friend string) error
+
+	BlockUser(user string, blocked string) error
+	UnblockUser(user string, blocked string) error
+}
+
+type RequestExpirer interface {
+	ExpireRequests() (int, error)
 }
@@ -0,0 +1,91 @@
+package friends
+
+import (
+	"sort"
+	"strings"
+)
+
+const (
+	StateNone      = ""
+	StatePending   = "pending"
+	StateAccepted  = "accepted"
+	StateDeclined  = "declined"
+	StateCancelled = "cancelled"
+	StateRemoved   = "removed"
+	StateBlocked   = "blocked"
+	StateExpired   = "expired"
+)
+
+const (
+	ActionSend    = "send"
+	ActionCancel  = "cancel"
+	ActionDecline = "decline"
+	ActionAccept  = "accept"
+	ActionRemove  = "remove"
+	ActionBlock   = "block"
+	ActionUnblock = "unblock"
+	ActionExpire  = "expire"
+)
+
+// MaxRequestMessageLength limits the optional note attached to a request
+const MaxRequestMessageLength = 300
+
+// friendshipTransitions maps every action to the states it may start from
+// and the state the pair ends up in
+var friendshipTransitions = map[string]map[string]string{
+	ActionSend: {
+		StateNone:      StatePending,
+		StateDeclined:  StatePending,
+		StateCancelled: StatePending,
+		StateRemoved:   StatePending,
+		StateExpired:   StatePending,
+	},
+	ActionCancel: {
+		StatePending: StateCancelled,
+	},
+	ActionDecline: {
+		StatePending: StateDeclined,
+	},
+	ActionAccept: {
+		StatePending: StateAccepted,
+	},
+	ActionRemove: {
+		StateAccepted: StateRemoved,
+	},
+	ActionBlock: {
+		StateNone:      StateBlocked,
+		StatePending:   StateBlocked,
+		StateAccepted:  StateBlocked,
+		StateDeclined:  StateBlocked,
+		StateCancelled: StateBlocked,
+		StateRemoved:   StateBlocked,
+		StateExpired:   StateBlocked,
+	},
+	ActionUnblock: {
+		StateBlocked: StateRemoved,
+	},
+	ActionExpire: {
+		StatePending: StateExpired,
+	},
+}
+
+func nextFriendshipState(state string, action string) (string, error) {
+	next, ok := friendshipTransitions[action][state]
+
+	if !ok {
+		return "", transitionError(state, action)
+	}
+
+	return next, nil
+}
+
+func pairKey(first string, second string) string {
+	return strings.Join(pairUsers(first, second), ":")
+}
+
+func pairUsers(first string, second string) []string {
+	users := []string{first, second}
+	sort.Strings(users)
+
+	return users
+}
@@ -0,0 +1,127 @@
+package friends
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/delonce/socialnetwork/internal/service"
+)
+
+const (
+	suggestionCacheTTL  = 5 * time.Minute
+	suggestionCacheSize = 1000
+)
+
+type mutualCount struct {
+	UserID string `bson:"_id"`
+	Count  int64  `bson:"count"`
+}
+
+type rankedUser struct {
+	UserID        string
+	MutualFriends int64
+}
+
+type suggestionEntry struct {
+	ranking   []rankedUser
+	expiresAt time.Time
+}
+
+// suggestionCache keeps ranked user ids per user id, services are created
+// on every request so the cache lives on the package level. Names are
+// resolved on read, so a rename never shows up stale, and other replicas
+// catch up with local changes after suggestionCacheTTL
+var suggestionCache = struct {
+	sync.Mutex
+	entries map[string]suggestionEntry
+}{entries: map[string]suggestionEntry{}}
+
+func getCachedRanking(userID string) ([]rankedUser, bool) {
+	suggestionCache.Lock()
+	defer suggestionCache.Unlock()
+
+	entry, ok := suggestionCache.entries[userID]
+
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil, false
+	}
+
+	return entry.ranking, true
+}
+
+func setCachedRanking(userID string, ranking []rankedUser) {
+	suggestionCache.Lock()
+	defer suggestionCache.Unlock()
+
+	if len(suggestionCache.entries) >= suggestionCacheSize {
+		evictSuggestions()
+	}
+
+	suggestionCache.entries[userID] = suggestionEntry{
+		ranking:   ranking,
+		expiresAt: time.Now().Add(suggestionCacheTTL),
+	}
+}
+
+// evictSuggestions drops expired entries and, when the cache is still full,
+// an arbitrary one, the caller must hold the lock
+func evictSuggestions() {
+	now := time.Now()
+
+	for userID, entry := range suggestionCache.entries {
+		if now.After(entry.expiresAt) {
+			delete(suggestionCache.entries, userID)
+		}
+	}
+
+	for userID := range suggestionCache.entries {
+		if len(suggestionCache.entries) < suggestionCacheSize {
+			return
+		}
+
+		delete(suggestionCache.entries, userID)
+	}
+}
+
+// invalidateSuggestions drops the whole cache, a friendship change or a block
+// shifts mutual friend counts for every friend of both users
+func invalidateSuggestions() {
+	suggestionCache.Lock()
+	defer suggestionCache.Unlock()
+
+	suggestionCache.entries = map[string]suggestionEntry{}
+}
+
+// DropSuggestions forgets every cached ranking, rankings are ordered by
+// username, so it is called after a user is renamed
+func DropSuggestions() {
+	invalidateSuggestions()
+}
+
+func rankSuggestions(users []service.User, counts []mutualCount) []rankedUser {
+	mutuals := map[string]int64{}
+
+	for _, count := range counts {
+		mutuals[count.UserID] = count.Count
+	}
+
+	sort.SliceStable(users, func(i, j int) bool {
+		if mutuals[users[i].ID] != mutuals[users[j].ID] {
+			return mutuals[users[i].ID] > mutuals[users[j].ID]
+		}
+
+		return users[i].Username < users[j].Username
+	})
+
+	ranking := []rankedUser{}
+
+	for _, user := range users {
+		ranking = append(ranking, rankedUser{
+			UserID:        user.ID,
+			MutualFriends: mutuals[user.ID],
+		})
+	}
+
+	return ranking
+}
@@ -2,16 +2,21 @@ package friends
 
 import (
 	"context"
+	"time"
 
 	"github.com/delonce/socialnetwork/internal/config"
 	"github.com/delonce/socialnetwork/internal/service"
+	"github.com/delonce/socialnetwork/internal/service/user"
 	"github.com/delonce/socialnetwork/pkg/logging"
 )
 
 type FriendViewService struct {
 	friendDatabase FriendQueries
+	userResolver   user.Resolver
 	logger         *logging.Logger
 	context        context.Context
+
+	requestTTL time.Duration
 }
 
 func NewFriendViewer(logger *logging.Logger, config *config.Config) (FriendViewer, error) {
@@ -24,15 +29,38 @@ func NewFriendViewer(logger *logging.Logger, config *config.Config) (FriendViewe
 
 	return &FriendViewService{
 		friendDatabase: NewFriendDB(logger, database),
+		userResolver:   user.NewResolver(logger, database),
 		logger:         logger,
+		requestTTL:     config.Friends.RequestTTL,
 	}, nil
 }
 
-func (viewService *FriendViewService) GetAllProbablyFriends(username string) []service.User {
-	userFriends := viewService.GetUserFriends(username)
-	userFriends = append(userFriends, username)
+func (viewService *FriendViewService) GetAllProbablyFriends(username string) []service.FriendSuggestion {
+	userID, err := viewService.userResolver.GetUserID(username)
+
+	if err != nil {
+		return []service.FriendSuggestion{}
+	}
+
+	if ranking, ok := getCachedRanking(userID); ok {
+		return viewService.resolveSuggestions(ranking)
+	}
+
+	friendIDs := viewService.getFriendIDs(userID)
+	pendingIDs := viewService.getPendingUserIDs(userID)
+	blockedIDs := viewService.getBlockRelatedIDs(userID)
+
+	exceptIDs := append([]string{userID}, friendIDs...)
+	exceptIDs = append(exceptIDs, pendingIDs...)
+	exceptIDs = append(exceptIDs, blockedIDs...)
+
+	except := []string{}
 
-	cursor, err := viewService.friendDatabase.GetAllProbFriends(viewService.context, userFriends)
+	for _, exceptUsername := range viewService.userResolver.GetUsernames(exceptIDs) {
+		except = append(except, exceptUsername)
+	}
+
+	cursor, err := viewService.friendDatabase.GetAllProbFriends(viewService.context, except)
 
 	if err != nil {
 		viewService.logger.Panic(err)
@@ -45,11 +73,98 @@ func (viewService *FriendViewService) GetAllProbablyFriends(username string) []s
 		viewService.logger.Panic(err)
 	}
 
-	return users
+	cursor, err = viewService.friendDatabase.GetMutualFriendCounts(viewService.context, friendIDs, exceptIDs)
+
+	if err != nil {
+		viewService.logger.Panic(err)
+	}
+
+	counts := []mutualCount{}
+	err = cursor.All(viewService.context, &counts)
+
+	if err != nil {
+		viewService.logger.Panic(err)
+	}
+
+	ranking := rankSuggestions(users, counts)
+	setCachedRanking(userID, ranking)
+
+	return viewService.resolveSuggestions(ranking)
+}
+
+// resolveSuggestions maps a cached ranking to current usernames, users
+// deleted since the ranking was built are skipped
+func (viewService *FriendViewService) resolveSuggestions(ranking []rankedUser) []service.FriendSuggestion {
+	userIDs := []string{}
+
+	for _, ranked := range ranking {
+		userIDs = append(userIDs, ranked.UserID)
+	}
+
+	usernames := viewService.userResolver.GetUsernames(userIDs)
+	suggestions := []service.FriendSuggestion{}
+
+	for _, ranked := range ranking {
+		if usernames[ranked.UserID] == ranked.UserID {
+			continue
+		}
+
+		suggestions = append(suggestions, service.FriendSuggestion{
+			User:          service.User{ID: ranked.UserID, Username: usernames[ranked.UserID]},
+			MutualFriends: ranked.MutualFriends,
+		})
+	}
+
+	return suggestions
+}
+
+func (viewService *FriendViewService) getPendingUserIDs(userID string) []string {
+	cursor, err := viewService.friendDatabase.GetPendingRequests(viewService.context, userID)
+
+	if err != nil {
+		viewService.logger.Panic(err)
+	}
+
+	requests := []service.FriendRequest{}
+	pending := []string{}
+
+	err = cursor.All(viewService.context, &requests)
+
+	if err != nil {
+		viewService.logger.Panic(err)
+	}
+
+	for _, request := range requests {
+		if request.From != userID {
+			pending = append(pending, request.From)
+		} else {
+			pending = append(pending, request.To)
+		}
+	}
+
+	return pending
 }
 
 func (viewService *FriendViewService) GetUserFriends(username string) []string {
-	cursor, err := viewService.friendDatabase.GetFriends(viewService.context, username)
+	userID, err := viewService.userResolver.GetUserID(username)
+
+	if err != nil {
+		return []string{}
+	}
+
+	friendIDs := viewService.getFriendIDs(userID)
+	usernames := viewService.userResolver.GetUsernames(friendIDs)
+	friends := []string{}
+
+	for _, friendID := range friendIDs {
+		friends = append(friends, usernames[friendID])
+	}
+
+	return friends
+}
+
+func (viewService *FriendViewService) getFriendIDs(userID string) []string {
+	cursor, err := viewService.friendDatabase.GetFriends(viewService.context, userID)
 
 	if err != nil {
 		viewService.logger.Panic(err)
@@ -67,7 +182,7 @@ func (viewService *FriendViewService) GetUserFriends(username string) []string {
 	}
 
 	for _, accReq := range acceptedRequests {
-		if accReq.From != username {
+		if accReq.From != userID {
 			friends = append(friends, accReq.From)
 		} else {
 			friends = append(friends, accReq.To)
@@ -78,19 +193,64 @@ func (viewService *FriendViewService) GetUserFriends(username string) []string {
 }
 
 func (viewService *FriendViewService) CheckRequest(from string, to string) bool {
-	fDb := viewService.friendDatabase
+	fromID, toID, ok := viewService.getPairIDs(from, to)
 
-	return fDb.IsExistRequest(viewService.context, from, to)
+	return ok && viewService.friendDatabase.IsExistRequest(viewService.context, fromID, toID)
 }
 
 func (viewService *FriendViewService) CheckFriend(from string, to string) bool {
-	fDb := viewService.friendDatabase
+	fromID, toID, ok := viewService.getPairIDs(from, to)
+
+	return ok && viewService.friendDatabase.IsExistFriend(viewService.context, fromID, toID)
+}
+
+func (viewService *FriendViewService) GetFriendshipHistory(user string, friend string) []service.FriendshipTransition {
+	userID, friendID, ok := viewService.getPairIDs(user, friend)
+
+	if !ok {
+		return []service.FriendshipTransition{}
+	}
+
+	cursor, err := viewService.friendDatabase.GetPairHistory(viewService.context, userID, friendID)
+
+	if err != nil {
+		viewService.logger.Panic(err)
+	}
+
+	history := []service.FriendshipTransition{}
+	err = cursor.All(viewService.context, &history)
+
+	if err != nil {
+		viewService.logger.Panic(err)
+	}
+
+	actorIDs := []string{}
+
+	for _, transition := range history {
+		actorIDs = append(actorIDs, transition.Actor)
+	}
+
+	usernames := viewService.userResolver.GetUsernames(actorIDs)
 
-	return fDb.IsExistFriend(viewService.context, from, to)
+	for i := range history {
+		history[i].Pair = pairKey(user, friend)
+
+		if history[i].Actor != "" {
+			history[i].Actor = usernames[history[i].Actor]
+		}
+	}
+
+	return history
 }
 
 func (viewService *FriendViewService) GetAllFriendRequests(username string) []service.FriendRequest {
-	cursor, err := viewService.friendDatabase.GetAllFriendRequestTo(viewService.context, username)
+	userID, err := viewService.userResolver.GetUserID(username)
+
+	if err != nil {
+		return []service.FriendRequest{}
+	}
+
+	cursor, err := viewService.friendDatabase.GetAllFriendRequestTo(viewService.context, userID)
 
 	if err != nil {
 		viewService.logger.Panic(err)
@@ -103,10 +263,52 @@ func (viewService *FriendViewService) GetAllFriendRequests(username string) []se
 		viewService.logger.Panic(err)
 	}
 
-	return requests
+	return viewService.resolveRequests(requests)
+}
+
+func (viewService *FriendViewService) GetOutgoingRequests(username string) []service.ViewFriendRequest {
+	userID, err := viewService.userResolver.GetUserID(username)
+
+	if err != nil {
+		return []service.ViewFriendRequest{}
+	}
+
+	cursor, err := viewService.friendDatabase.GetOutgoingRequests(viewService.context, userID)
+
+	if err != nil {
+		viewService.logger.Panic(err)
+	}
+
+	requests := []service.FriendRequest{}
+	err = cursor.All(viewService.context, &requests)
+
+	if err != nil {
+		viewService.logger.Panic(err)
+	}
+
+	viewRequests := []service.ViewFriendRequest{}
+
+	for _, request := range viewService.resolveRequests(requests) {
+		viewRequest := service.ViewFriendRequest{
+			To:           request.To,
+			Message:      request.Message,
+			SentAt:       request.DateAt,
+			FormatSentAt: request.DateAt.Format("2006-01-02 15:04"),
+		}
+
+		if viewService.requestTTL > 0 {
+			viewRequest.ExpiresAt = request.DateAt.Add(viewService.requestTTL)
+			viewRequest.FormatExpiresAt = viewRequest.ExpiresAt.Format("2006-01-02 15:04")
+		}
+
+		viewRequests = append(viewRequests, viewRequest)
+	}
+
+	return viewRequests
 }
 
-func (viewService *FriendViewService) GetUsersExceptSomeone(exceptUsers []string) []service.User {
+func (viewService *FriendViewService) GetUsersExceptSomeone(username string, exceptUsers []string) []service.User {
+	exceptUsers = append(exceptUsers, viewService.getBlockRelatedUsers(username)...)
 	cursor, err := viewService.friendDatabase.GetUsersExcept(viewService.context, exceptUsers)
 
 	if err != nil {
@@ -122,3 +324,131 @@ func (viewService *FriendViewService) GetUsersExceptSomeone(exceptUsers []string
 
 	return users
 }
+
+// GetBlockedUsers returns blocks made by the user with current usernames
+// in place of the stored user ids
+func (viewService *FriendViewService) GetBlockedUsers(username string) []service.UserBlock {
+	userID, err := viewService.userResolver.GetUserID(username)
+
+	if err != nil {
+		return []service.UserBlock{}
+	}
+
+	cursor, err := viewService.friendDatabase.GetBlocksBy(viewService.context, userID)
+
+	if err != nil {
+		viewService.logger.Panic(err)
+	}
+
+	blocks := []service.UserBlock{}
+	err = cursor.All(viewService.context, &blocks)
+
+	if err != nil {
+		viewService.logger.Panic(err)
+	}
+
+	blockedIDs := []string{}
+
+	for _, block := range blocks {
+		blockedIDs = append(blockedIDs, block.Blocked)
+	}
+
+	usernames := viewService.userResolver.GetUsernames(blockedIDs)
+
+	for i, block := range blocks {
+		blocks[i].Blocker = username
+		blocks[i].Blocked = usernames[block.Blocked]
+	}
+
+	return blocks
+}
+
+func (viewService *FriendViewService) CheckBlock(blocker string, blocked string) bool {
+	blockerID, blockedID, ok := viewService.getPairIDs(blocker, blocked)
+
+	return ok && viewService.friendDatabase.IsExistBlock(viewService.context, blockerID, blockedID)
+}
+
+func (viewService *FriendViewService) CheckBlockedPair(first string, second string) bool {
+	firstID, secondID, ok := viewService.getPairIDs(first, second)
+
+	return ok && viewService.friendDatabase.IsBlockedPair(viewService.context, firstID, secondID)
+}
+
+// getBlockRelatedUsers returns usernames of users who blocked the user or
+// were blocked by them
+func (viewService *FriendViewService) getBlockRelatedUsers(username string) []string {
+	userID, err := viewService.userResolver.GetUserID(username)
+
+	if err != nil {
+		return []string{}
+	}
+
+	related := []string{}
+
+	for _, relatedUsername := range viewService.userResolver.GetUsernames(viewService.getBlockRelatedIDs(userID)) {
+		related = append(related, relatedUsername)
+	}
+
+	return related
+}
+
+func (viewService *FriendViewService) getBlockRelatedIDs(userID string) []string {
+	cursor, err := viewService.friendDatabase.GetRelatedBlocks(viewService.context, userID)
+
+	if err != nil {
+		viewService.logger.Panic(err)
+	}
+
+	blocks := []service.UserBlock{}
+	related := []string{}
+
+	err = cursor.All(viewService.context, &blocks)
+
+	if err != nil {
+		viewService.logger.Panic(err)
+	}
+
+	for _, block := range blocks {
+		if block.Blocker != userID {
+			related = append(related, block.Blocker)
+		} else {
+			related = append(related, block.Blocked)
+		}
+	}
+
+	return related
+}
+
+// resolveRequests replaces user ids stored in requests with current usernames
+func (viewService *FriendViewService) resolveRequests(requests []service.FriendRequest) []service.FriendRequest {
+	userIDs := []string{}
+
+	for _, request := range requests {
+		userIDs = append(userIDs, request.From, request.To)
+	}
+
+	usernames := viewService.userResolver.GetUsernames(userIDs)
+
+	for i, request := range requests {
+		requests[i].From = usernames[request.From]
+		requests[i].To = usernames[request.To]
+		requests[i].Users = pairUsers(requests[i].From, requests[i].To)
+	}
+
+	return requests
+}
+
+func (viewService *FriendViewService) getPairIDs(first string, second string) (string, string, bool) {
+	userIDs, err := viewService.userResolver.GetUserIDs([]string{first, second})
+
+	if err != nil {
+		viewService.logger.Errorf("Error when resolving users %s - %s, %v", first, second, err)
+		return "", "", false
+	}
+
+	firstID, isFirstFound := userIDs[first]
+	secondID, isSecondFound := userIDs[second]
+
+	return firstID, secondID, isFirstFound && isSecondFound
+}
@@ -0,0 +1,330 @@
+package groups
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/delonce/socialnetwork/internal/database"
+	"github.com/delonce/socialnetwork/internal/database/mongodb"
+	"github.com/delonce/socialnetwork/internal/service"
+	"github.com/delonce/socialnetwork/pkg/logging"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type GroupQueries interface {
+	CreateGroup(ctx context.Context, group *service.Group) (string, error)
+	GetGroup(ctx context.Context, groupID string) (*mongo.SingleResult, error)
+	GetGroupsByIDs(ctx context.Context, groupIDs []string) (*mongo.Cursor, error)
+	FindGroups(ctx context.Context, name string, limit int64) (*mongo.Cursor, error)
+	UpdateGroup(ctx context.Context, groupID string, fields bson.M) error
+	DeleteGroup(ctx context.Context, groupID string) error
+
+	AddMember(ctx context.Context, member *service.GroupMember) (bool, error)
+	GetMember(ctx context.Context, groupID string, userID string) (*mongo.SingleResult, error)
+	GetMembers(ctx context.Context, groupID string, roles []string) (*mongo.Cursor, error)
+	GetUserMemberships(ctx context.Context, userID string, groupIDs []string) (*mongo.Cursor, error)
+	CountMembers(ctx context.Context, groupID string) int64
+	SetMemberRole(ctx context.Context, groupID string, userID string, fromRoles []string, role string) error
+	DeleteMember(ctx context.Context, groupID string, userID string, roles []string) error
+	DeleteMembers(ctx context.Context, groupID string) error
+
+	AddPost(ctx context.Context, post *service.GroupPost) (string, error)
+	GetPost(ctx context.Context, groupID string, postID string) (*mongo.SingleResult, error)
+	DeletePost(ctx context.Context, groupID string, postID string) error
+	GetPosts(ctx context.Context, groupID string, skip int64, limit int64) (*mongo.Cursor, error)
+	CountPosts(ctx context.Context, groupID string) int64
+	DeletePosts(ctx context.Context, groupID string) error
+
+	EnsureIndexes(ctx context.Context) error
+}
+
+type GroupDB struct {
+	Storage database.DBStorage
+	Logger  *logging.Logger
+}
+
+func NewGroupDB(logger *logging.Logger, database *mongo.Database) GroupQueries {
+	storage := mongodb.NewStorage(
+		map[string]*mongo.Collection{
+			service.GROUP_COLLECTION:        database.Collection(service.GROUP_COLLECTION),
+			service.GROUP_MEMBER_COLLECTION: database.Collection(service.GROUP_MEMBER_COLLECTION),
+			service.GROUP_POST_COLLECTION:   database.Collection(service.GROUP_POST_COLLECTION),
+		},
+		logger,
+	)
+
+	return &GroupDB{
+		Storage: storage,
+		Logger:  logger,
+	}
+}
+
+func (groupStorage *GroupDB) CreateGroup(ctx context.Context, group *service.Group) (string, error) {
+	st := groupStorage.Storage
+
+	return st.CreateObject(ctx, group, service.GROUP_COLLECTION)
+}
+
+func (groupStorage *GroupDB) GetGroup(ctx context.Context, groupID string) (*mongo.SingleResult, error) {
+	st := groupStorage.Storage
+	objGroupID, err := primitive.ObjectIDFromHex(groupID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return st.FindOneObject(ctx, bson.M{"_id": objGroupID}, service.GROUP_COLLECTION)
+}
+
+func (groupStorage *GroupDB) GetGroupsByIDs(ctx context.Context, groupIDs []string) (*mongo.Cursor, error) {
+	st := groupStorage.Storage
+	objGroupIDs := []primitive.ObjectID{}
+
+	for _, groupID := range groupIDs {
+		objGroupID, err := primitive.ObjectIDFromHex(groupID)
+
+		if err != nil {
+			continue
+		}
+
+		objGroupIDs = append(objGroupIDs, objGroupID)
+	}
+
+	findOpts := options.FindOptions{}
+	findOpts.SetSort(bson.D{{Key: "name", Value: 1}})
+
+	return st.FindObjects(ctx, bson.M{"_id": bson.M{"$in": objGroupIDs}}, service.GROUP_COLLECTION, &findOpts)
+}
+
+// FindGroups looks the name up as a plain substring, an empty name returns
+// the newest groups
+func (groupStorage *GroupDB) FindGroups(ctx context.Context, name string, limit int64) (*mongo.Cursor, error) {
+	st := groupStorage.Storage
+	query := bson.M{}
+
+	if name != "" {
+		query = bson.M{"name": primitive.Regex{Pattern: regexp.QuoteMeta(name), Options: "i"}}
+	}
+
+	findOpts := options.FindOptions{}
+
+	findOpts.SetSort(bson.D{{Key: "date", Value: -1}})
+	findOpts.SetLimit(limit)
+
+	return st.FindObjects(ctx, query, service.GROUP_COLLECTION, &findOpts)
+}
+
+func (groupStorage *GroupDB) UpdateGroup(ctx context.Context, groupID string, fields bson.M) error {
+	st := groupStorage.Storage
+	objGroupID, err := primitive.ObjectIDFromHex(groupID)
+
+	if err != nil {
+		return err
+	}
+
+	return st.Update(ctx, bson.M{"_id": objGroupID}, fields, service.GROUP_COLLECTION)
+}
+
+func (groupStorage *GroupDB) DeleteGroup(ctx context.Context, groupID string) error {
+	st := groupStorage.Storage
+	objGroupID, err := primitive.ObjectIDFromHex(groupID)
+
+	if err != nil {
+		return err
+	}
+
+	return st.Delete(ctx, bson.M{"_id": objGroupID}, service.GROUP_COLLECTION)
+}
+
+// AddMember reports false when the user is already in the group or asked
+// to join it
+func (groupStorage *GroupDB) AddMember(ctx context.Context, member *service.GroupMember) (bool, error) {
+	st := groupStorage.Storage
+
+	query := bson.M{
+		"groupid": member.GroupID,
+		"user":    member.User,
+	}
+
+	return st.InsertIfAbsent(ctx, query, member, service.GROUP_MEMBER_COLLECTION)
+}
+
+func (groupStorage *GroupDB) GetMember(ctx context.Context, groupID string, userID string) (*mongo.SingleResult, error) {
+	st := groupStorage.Storage
+
+	query := bson.M{
+		"$and": []bson.M{
+			{"groupid": groupID},
+			{"user": userID},
+		},
+	}
+
+	return st.FindOneObject(ctx, query, service.GROUP_MEMBER_COLLECTION)
+}
+
+func (groupStorage *GroupDB) GetMembers(ctx context.Context, groupID string, roles []string) (*mongo.Cursor, error) {
+	st := groupStorage.Storage
+
+	findOpts := options.FindOptions{}
+	findOpts.SetSort(bson.D{{Key: "date", Value: 1}})
+
+	return st.FindObjects(ctx, membersQuery(groupID, roles), service.GROUP_MEMBER_COLLECTION, &findOpts)
+}
+
+// GetUserMemberships returns all memberships and requests of the user, or
+// only those in the given groups when groupIDs isn't nil
+func (groupStorage *GroupDB) GetUserMemberships(ctx context.Context, userID string, groupIDs []string) (*mongo.Cursor, error) {
+	st := groupStorage.Storage
+	query := bson.M{"user": userID}
+
+	if groupIDs != nil {
+		query = bson.M{
+			"$and": []bson.M{
+				{"user": userID},
+				{"groupid": bson.M{"$in": groupIDs}},
+			},
+		}
+	}
+
+	return st.FindObjects(ctx, query, service.GROUP_MEMBER_COLLECTION)
+}
+
+func (groupStorage *GroupDB) CountMembers(ctx context.Context, groupID string) int64 {
+	st := groupStorage.Storage
+
+	amount, err := st.CountObjects(ctx, membersQuery(groupID, memberRoles), service.GROUP_MEMBER_COLLECTION)
+
+	if err != nil {
+		groupStorage.Logger.Errorf("Error when counting members of group %s, %v", groupID, err)
+		return 0
+	}
+
+	return amount
+}
+
+// SetMemberRole changes the role only while it is one of fromRoles, so
+// concurrent changes can't skip a step like approval
+func (groupStorage *GroupDB) SetMemberRole(ctx context.Context, groupID string, userID string,
+	fromRoles []string, role string) error {
+
+	st := groupStorage.Storage
+
+	return st.Update(ctx, memberQuery(groupID, userID, fromRoles), bson.M{"role": role}, service.GROUP_MEMBER_COLLECTION)
+}
+
+func (groupStorage *GroupDB) DeleteMember(ctx context.Context, groupID string, userID string, roles []string) error {
+	st := groupStorage.Storage
+
+	return st.Delete(ctx, memberQuery(groupID, userID, roles), service.GROUP_MEMBER_COLLECTION)
+}
+
+func (groupStorage *GroupDB) DeleteMembers(ctx context.Context, groupID string) error {
+	st := groupStorage.Storage
+
+	return st.DeleteMany(ctx, bson.M{"groupid": groupID}, service.GROUP_MEMBER_COLLECTION)
+}
+
+func (groupStorage *GroupDB) AddPost(ctx context.Context, post *service.GroupPost) (string, error) {
+	st := groupStorage.Storage
+
+	return st.CreateObject(ctx, post, service.GROUP_POST_COLLECTION)
+}
+
+func (groupStorage *GroupDB) GetPost(ctx context.Context, groupID string, postID string) (*mongo.SingleResult, error) {
+	st := groupStorage.Storage
+	query, err := postQuery(groupID, postID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return st.FindOneObject(ctx, query, service.GROUP_POST_COLLECTION)
+}
+
+func (groupStorage *GroupDB) DeletePost(ctx context.Context, groupID string, postID string) error {
+	st := groupStorage.Storage
+	query, err := postQuery(groupID, postID)
+
+	if err != nil {
+		return err
+	}
+
+	return st.Delete(ctx, query, service.GROUP_POST_COLLECTION)
+}
+
+func (groupStorage *GroupDB) GetPosts(ctx context.Context, groupID string, skip int64, limit int64) (*mongo.Cursor, error) {
+	st := groupStorage.Storage
+
+	findOpts := options.FindOptions{}
+
+	findOpts.SetSort(bson.D{{Key: "date", Value: -1}})
+	findOpts.SetSkip(skip)
+	findOpts.SetLimit(limit)
+
+	return st.FindObjects(ctx, bson.M{"groupid": groupID}, service.GROUP_POST_COLLECTION, &findOpts)
+}
+
+func (groupStorage *GroupDB) CountPosts(ctx context.Context, groupID string) int64 {
+	st := groupStorage.Storage
+
+	amount, err := st.CountObjects(ctx, bson.M{"groupid": groupID}, service.GROUP_POST_COLLECTION)
+
+	if err != nil {
+		groupStorage.Logger.Errorf("Error when counting posts of group %s, %v", groupID, err)
+		return 0
+	}
+
+	return amount
+}
+
+func (groupStorage *GroupDB) DeletePosts(ctx context.Context, groupID string) error {
+	st := groupStorage.Storage
+
+	return st.DeleteMany(ctx, bson.M{"groupid": groupID}, service.GROUP_POST_COLLECTION)
+}
+
+// EnsureIndexes keeps one membership per user and group, so a second join
+// request sent at the same time fails instead of doubling the member
+func (groupStorage *GroupDB) EnsureIndexes(ctx context.Context) error {
+	st := groupStorage.Storage
+
+	return st.EnsureUniqueIndex(ctx, []string{"groupid", "user"}, service.GROUP_MEMBER_COLLECTION)
+}
+
+func memberQuery(groupID string, userID string, roles []string) bson.M {
+	return bson.M{
+		"$and": []bson.M{
+			{"groupid": groupID},
+			{"user": userID},
+			{"role": bson.M{"$in": roles}},
+		},
+	}
+}
+
+func membersQuery(groupID string, roles []string) bson.M {
+	return bson.M{
+		"$and": []bson.M{
+			{"groupid": groupID},
+			{"role": bson.M{"$in": roles}},
+		},
+	}
+}
+
+func postQuery(groupID string, postID string) (bson.M, error) {
+	objPostID, err := primitive.ObjectIDFromHex(postID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return bson.M{
+		"$and": []bson.M{
+			{"_id": objPostID},
+			{"groupid": groupID},
+		},
+	}, nil
+}
@@ -0,0 +1,384 @@
+package groups
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/delonce/socialnetwork/internal/config"
+	"github.com/delonce/socialnetwork/internal/service"
+	"github.com/delonce/socialnetwork/internal/service/access"
+	"github.com/delonce/socialnetwork/internal/service/user"
+	"github.com/delonce/socialnetwork/pkg/logging"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type GroupManagerService struct {
+	groupDatabase GroupQueries
+	userResolver  user.Resolver
+	policy        access.Policy
+	logger        *logging.Logger
+	context       context.Context
+}
+
+func NewGroupManager(logger *logging.Logger, config *config.Config) (GroupManager, error) {
+	database, err := service.InitNewDatabase(config)
+
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	groupDatabase := NewGroupDB(logger, database)
+	userResolver := user.NewResolver(logger, database)
+	policy, err := newGroupPolicy(logger, config, groupDatabase, userResolver)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &GroupManagerService{
+		groupDatabase: groupDatabase,
+		userResolver:  userResolver,
+		policy:        policy,
+		logger:        logger,
+	}, nil
+}
+
+func (manager *GroupManagerService) CreateGroup(owner string, name string, description string, joinPolicy string) (string, error) {
+	name, description, err := checkGroupFields(name, description, joinPolicy)
+
+	if err != nil {
+		return "", err
+	}
+
+	ownerID, err := manager.userResolver.GetUserID(owner)
+
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	gDb := manager.groupDatabase
+
+	groupID, err := gDb.CreateGroup(manager.context, &service.Group{
+		Name:        name,
+		Description: description,
+		JoinPolicy:  joinPolicy,
+		DateAt:      now,
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	_, err = gDb.AddMember(manager.context, &service.GroupMember{
+		GroupID: groupID,
+		User:    ownerID,
+		Role:    GROUP_ROLE_OWNER,
+		DateAt:  now,
+	})
+
+	return groupID, err
+}
+
+func (manager *GroupManagerService) UpdateGroup(username string, groupID string, name string,
+	description string, joinPolicy string) error {
+
+	name, description, err := checkGroupFields(name, description, joinPolicy)
+
+	if err != nil {
+		return err
+	}
+
+	if err = manager.policy.Authorize(username, access.ActionManageGroup, groupID); err != nil {
+		return err
+	}
+
+	fields := bson.M{
+		"name":        name,
+		"description": description,
+		"joinpolicy":  joinPolicy,
+	}
+
+	if err = manager.groupDatabase.UpdateGroup(manager.context, groupID, fields); err != nil {
+		return ErrGroupNotFound
+	}
+
+	return nil
+}
+
+// DeleteGroup removes the group with its members and board
+func (manager *GroupManagerService) DeleteGroup(username string, groupID string) error {
+	if err := manager.policy.Authorize(username, access.ActionManageGroup, groupID); err != nil {
+		return err
+	}
+
+	gDb := manager.groupDatabase
+
+	if err := gDb.DeleteGroup(manager.context, groupID); err != nil {
+		return ErrGroupNotFound
+	}
+
+	if err := gDb.DeleteMembers(manager.context, groupID); err != nil {
+		return err
+	}
+
+	return gDb.DeletePosts(manager.context, groupID)
+}
+
+// JoinGroup makes the user a member of an open group at once, joining a
+// group by approval leaves a request for its admins
+func (manager *GroupManagerService) JoinGroup(username string, groupID string) error {
+	if err := manager.policy.Authorize(username, access.ActionJoinGroup, groupID); err != nil {
+		return err
+	}
+
+	group, err := findGroup(manager.context, manager.groupDatabase, groupID)
+
+	if err != nil {
+		return err
+	}
+
+	userID, err := manager.userResolver.GetUserID(username)
+
+	if err != nil {
+		return err
+	}
+
+	role := GROUP_ROLE_REQUESTED
+
+	if group.JoinPolicy == JOIN_OPEN {
+		role = GROUP_ROLE_MEMBER
+	}
+
+	isAdded, err := manager.groupDatabase.AddMember(manager.context, &service.GroupMember{
+		GroupID: groupID,
+		User:    userID,
+		Role:    role,
+		DateAt:  time.Now(),
+	})
+
+	if err != nil || isAdded {
+		return err
+	}
+
+	if role == GROUP_ROLE_REQUESTED {
+		return ErrAlreadyRequested
+	}
+
+	// the request was sent while the group was still joined by approval
+	err = manager.groupDatabase.SetMemberRole(manager.context, groupID, userID,
+		[]string{GROUP_ROLE_REQUESTED}, GROUP_ROLE_MEMBER)
+
+	if err != nil {
+		return access.ErrGroupMember
+	}
+
+	return nil
+}
+
+// LeaveGroup takes the user out of the group or cancels the join request,
+// the owner has to delete the group instead
+func (manager *GroupManagerService) LeaveGroup(username string, groupID string) error {
+	userID, err := manager.userResolver.GetUserID(username)
+
+	if err != nil {
+		return err
+	}
+
+	roles := []string{GROUP_ROLE_ADMIN, GROUP_ROLE_MEMBER, GROUP_ROLE_REQUESTED}
+
+	if err = manager.groupDatabase.DeleteMember(manager.context, groupID, userID, roles); err == nil {
+		return nil
+	}
+
+	if member, err := findMember(manager.context, manager.groupDatabase, groupID, userID); err == nil &&
+		member.Role == GROUP_ROLE_OWNER {
+
+		return ErrOwnerLeave
+	}
+
+	return ErrMemberNotFound
+}
+
+func (manager *GroupManagerService) ApproveRequest(username string, groupID string, member string) error {
+	memberID, err := manager.getMemberID(username, access.ActionModerateGroup, groupID, member)
+
+	if err != nil {
+		return err
+	}
+
+	err = manager.groupDatabase.SetMemberRole(manager.context, groupID, memberID,
+		[]string{GROUP_ROLE_REQUESTED}, GROUP_ROLE_MEMBER)
+
+	if err != nil {
+		return ErrRequestNotFound
+	}
+
+	return nil
+}
+
+func (manager *GroupManagerService) RejectRequest(username string, groupID string, member string) error {
+	memberID, err := manager.getMemberID(username, access.ActionModerateGroup, groupID, member)
+
+	if err != nil {
+		return err
+	}
+
+	err = manager.groupDatabase.DeleteMember(manager.context, groupID, memberID, []string{GROUP_ROLE_REQUESTED})
+
+	if err != nil {
+		return ErrRequestNotFound
+	}
+
+	return nil
+}
+
+// RemoveMember lets admins remove members, only the owner removes admins
+// and nobody removes the owner
+func (manager *GroupManagerService) RemoveMember(username string, groupID string, member string) error {
+	memberID, err := manager.getMemberID(username, access.ActionModerateGroup, groupID, member)
+
+	if err != nil {
+		return err
+	}
+
+	roles := []string{GROUP_ROLE_MEMBER}
+
+	if manager.policy.Can(username, access.ActionManageGroup, groupID) {
+		roles = append(roles, GROUP_ROLE_ADMIN)
+	}
+
+	if err = manager.groupDatabase.DeleteMember(manager.context, groupID, memberID, roles); err != nil {
+		return ErrMemberNotFound
+	}
+
+	return nil
+}
+
+// SetMemberRole moves a member between admins and plain members, the owner
+// role is never given or taken here
+func (manager *GroupManagerService) SetMemberRole(username string, groupID string, member string, role string) error {
+	if role != GROUP_ROLE_ADMIN && role != GROUP_ROLE_MEMBER {
+		return ErrWrongRole
+	}
+
+	memberID, err := manager.getMemberID(username, access.ActionManageGroup, groupID, member)
+
+	if err != nil {
+		return err
+	}
+
+	err = manager.groupDatabase.SetMemberRole(manager.context, groupID, memberID,
+		[]string{GROUP_ROLE_ADMIN, GROUP_ROLE_MEMBER}, role)
+
+	if err != nil {
+		return ErrMemberNotFound
+	}
+
+	return nil
+}
+
+func (manager *GroupManagerService) AddPost(username string, groupID string, text string) (string, error) {
+	text = strings.TrimSpace(text)
+
+	if text == "" {
+		return "", ErrEmptyPost
+	}
+
+	if utf8.RuneCountInString(text) > MaxPostLength {
+		return "", ErrLongPost
+	}
+
+	if err := manager.policy.Authorize(username, access.ActionPostGroupBoard, groupID); err != nil {
+		return "", err
+	}
+
+	authorID, err := manager.userResolver.GetUserID(username)
+
+	if err != nil {
+		return "", err
+	}
+
+	return manager.groupDatabase.AddPost(manager.context, &service.GroupPost{
+		GroupID: groupID,
+		Author:  authorID,
+		Text:    text,
+		DateAt:  time.Now(),
+	})
+}
+
+// DeletePost lets authors delete their posts and admins delete any post
+func (manager *GroupManagerService) DeletePost(username string, groupID string, postID string) error {
+	userID, err := manager.userResolver.GetUserID(username)
+
+	if err != nil {
+		return err
+	}
+
+	gDb := manager.groupDatabase
+	result, err := gDb.GetPost(manager.context, groupID, postID)
+
+	if err != nil {
+		return ErrPostNotFound
+	}
+
+	post := service.GroupPost{}
+
+	if err = result.Decode(&post); err != nil {
+		manager.logger.Errorf("Error while decoding group post %s, %v", postID, err)
+		return err
+	}
+
+	if post.Author != userID {
+		if err = manager.policy.Authorize(username, access.ActionModerateGroup, groupID); err != nil {
+			return err
+		}
+	}
+
+	if err = gDb.DeletePost(manager.context, groupID, postID); err != nil {
+		return ErrPostNotFound
+	}
+
+	return nil
+}
+
+// getMemberID authorizes the action in the group and resolves the member
+// it is done with
+func (manager *GroupManagerService) getMemberID(username string, action string, groupID string, member string) (string, error) {
+	if err := manager.policy.Authorize(username, action, groupID); err != nil {
+		return "", err
+	}
+
+	if username == member {
+		return "", access.ErrSelfAction
+	}
+
+	return manager.userResolver.GetUserID(member)
+}
+
+func checkGroupFields(name string, description string, joinPolicy string) (string, string, error) {
+	name = strings.TrimSpace(name)
+	description = strings.TrimSpace(description)
+
+	if name == "" {
+		return "", "", ErrEmptyGroupName
+	}
+
+	if utf8.RuneCountInString(name) > MaxNameLength {
+		return "", "", ErrLongGroupName
+	}
+
+	if utf8.RuneCountInString(description) > MaxDescriptionLength {
+		return "", "", ErrLongDescription
+	}
+
+	if joinPolicy != JOIN_OPEN && joinPolicy != JOIN_APPROVAL {
+		return "", "", ErrWrongJoinPolicy
+	}
+
+	return name, description, nil
+}
@@ -0,0 +1,111 @@
+package groups
+
+import (
+	"context"
+
+	"github.com/delonce/socialnetwork/internal/config"
+	"github.com/delonce/socialnetwork/internal/service"
+	"github.com/delonce/socialnetwork/internal/service/access"
+	"github.com/delonce/socialnetwork/internal/service/friends"
+	"github.com/delonce/socialnetwork/internal/service/user"
+	"github.com/delonce/socialnetwork/pkg/logging"
+)
+
+// GroupMemberships gives the access policy the facts about groups, every
+// check reads the storage so a role change is seen at once
+type GroupMemberships struct {
+	groupDatabase GroupQueries
+	userResolver  user.Resolver
+	context       context.Context
+}
+
+func NewGroupMemberships(groupDatabase GroupQueries, userResolver user.Resolver) access.Memberships {
+	return &GroupMemberships{
+		groupDatabase: groupDatabase,
+		userResolver:  userResolver,
+	}
+}
+
+func (memberships *GroupMemberships) IsGroupMember(username string, groupID string) bool {
+	role := memberships.getRole(username, groupID)
+
+	return role == GROUP_ROLE_OWNER || role == GROUP_ROLE_ADMIN || role == GROUP_ROLE_MEMBER
+}
+
+func (memberships *GroupMemberships) IsGroupAdmin(username string, groupID string) bool {
+	role := memberships.getRole(username, groupID)
+
+	return role == GROUP_ROLE_OWNER || role == GROUP_ROLE_ADMIN
+}
+
+func (memberships *GroupMemberships) IsGroupOwner(username string, groupID string) bool {
+	return memberships.getRole(username, groupID) == GROUP_ROLE_OWNER
+}
+
+func (memberships *GroupMemberships) IsOpenGroup(groupID string) bool {
+	group, err := findGroup(memberships.context, memberships.groupDatabase, groupID)
+
+	return err == nil && group.JoinPolicy == JOIN_OPEN
+}
+
+func (memberships *GroupMemberships) getRole(username string, groupID string) string {
+	userID, err := memberships.userResolver.GetUserID(username)
+
+	if err != nil {
+		return ""
+	}
+
+	member, err := findMember(memberships.context, memberships.groupDatabase, groupID, userID)
+
+	if err != nil {
+		return ""
+	}
+
+	return member.Role
+}
+
+// newGroupPolicy builds the access policy knowing both friendships and
+// group memberships
+func newGroupPolicy(logger *logging.Logger, config *config.Config, groupDatabase GroupQueries,
+	userResolver user.Resolver) (access.Policy, error) {
+
+	friendView, err := friends.NewFriendViewer(logger, config)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return access.NewGroupPolicy(friendView, NewGroupMemberships(groupDatabase, userResolver)), nil
+}
+
+func findGroup(ctx context.Context, groupDatabase GroupQueries, groupID string) (*service.Group, error) {
+	result, err := groupDatabase.GetGroup(ctx, groupID)
+
+	if err != nil {
+		return nil, ErrGroupNotFound
+	}
+
+	group := service.Group{}
+
+	if err = result.Decode(&group); err != nil {
+		return nil, err
+	}
+
+	return &group, nil
+}
+
+func findMember(ctx context.Context, groupDatabase GroupQueries, groupID string, userID string) (*service.GroupMember, error) {
+	result, err := groupDatabase.GetMember(ctx, groupID, userID)
+
+	if err != nil {
+		return nil, ErrMemberNotFound
+	}
+
+	member := service.GroupMember{}
+
+	if err = result.Decode(&member); err != nil {
+		return nil, err
+	}
+
+	return &member, nil
+}
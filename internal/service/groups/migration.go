@@ -0,0 +1,20 @@
+package groups
+
+import (
+	"context"
+
+	"github.com/delonce/socialnetwork/internal/config"
+	"github.com/delonce/socialnetwork/internal/service"
+	"github.com/delonce/socialnetwork/pkg/logging"
+)
+
+func MigrateGroupIndexes(logger *logging.Logger, config *config.Config) error {
+	database, err := service.InitNewDatabase(config)
+
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return NewGroupDB(logger, database).EnsureIndexes(context.Background())
+}
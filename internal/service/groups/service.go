@@ -0,0 +1,66 @@
+package groups
+
+import (
+	"errors"
+
+	"github.com/delonce/socialnetwork/internal/service"
+)
+
+const (
+	GROUP_ROLE_OWNER     = "owner"
+	GROUP_ROLE_ADMIN     = "admin"
+	GROUP_ROLE_MEMBER    = "member"
+	GROUP_ROLE_REQUESTED = "requested"
+
+	JOIN_OPEN     = "open"
+	JOIN_APPROVAL = "approval"
+)
+
+const (
+	MaxNameLength        = 100
+	MaxDescriptionLength = 1000
+	MaxPostLength        = 2000
+	PageSize             = 10
+	SearchLimit          = 20
+)
+
+// memberRoles are the roles of users who are really in the group
+var memberRoles = []string{GROUP_ROLE_OWNER, GROUP_ROLE_ADMIN, GROUP_ROLE_MEMBER}
+
+var (
+	ErrEmptyGroupName   = errors.New("Group name can't be empty")
+	ErrLongGroupName    = errors.New("Group name is too long")
+	ErrLongDescription  = errors.New("Group description is too long")
+	ErrWrongJoinPolicy  = errors.New("Unknown join policy")
+	ErrWrongRole        = errors.New("Role can't be given")
+	ErrGroupNotFound    = errors.New("Group not found")
+	ErrMemberNotFound   = errors.New("Group member not found")
+	ErrRequestNotFound  = errors.New("Join request not found")
+	ErrAlreadyRequested = errors.New("Join request is already sent")
+	ErrOwnerLeave       = errors.New("Owner can't leave the group")
+	ErrEmptyPost        = errors.New("Group post can't be empty")
+	ErrLongPost         = errors.New("Group post is too long")
+	ErrPostNotFound     = errors.New("Group post not found")
+)
+
+type GroupManager interface {
+	CreateGroup(owner string, name string, description string, joinPolicy string) (string, error)
+	UpdateGroup(username string, groupID string, name string, description string, joinPolicy string) error
+	DeleteGroup(username string, groupID string) error
+
+	JoinGroup(username string, groupID string) error
+	LeaveGroup(username string, groupID string) error
+	ApproveRequest(username string, groupID string, member string) error
+	RejectRequest(username string, groupID string, member string) error
+	RemoveMember(username string, groupID string, member string) error
+	SetMemberRole(username string, groupID string, member string, role string) error
+
+	AddPost(username string, groupID string, text string) (string, error)
+	DeletePost(username string, groupID string, postID string) error
+}
+
+type GroupViewer interface {
+	GetUserGroups(username string) []service.ViewGroup
+	FindGroups(username string, name string) []service.ViewGroup
+	GetGroup(username string, groupID string, page int64) (*service.ViewGroupPage, error)
+}
@@ -0,0 +1,273 @@
+package groups
+
+import (
+	"context"
+	"strings"
+
+	"github.com/delonce/socialnetwork/internal/config"
+	"github.com/delonce/socialnetwork/internal/service"
+	"github.com/delonce/socialnetwork/internal/service/access"
+	"github.com/delonce/socialnetwork/internal/service/user"
+	"github.com/delonce/socialnetwork/pkg/logging"
+)
+
+type GroupViewService struct {
+	groupDatabase GroupQueries
+	userResolver  user.Resolver
+	policy        access.Policy
+	logger        *logging.Logger
+	context       context.Context
+}
+
+func NewGroupViewer(logger *logging.Logger, config *config.Config) (GroupViewer, error) {
+	database, err := service.InitNewDatabase(config)
+
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	groupDatabase := NewGroupDB(logger, database)
+	userResolver := user.NewResolver(logger, database)
+	policy, err := newGroupPolicy(logger, config, groupDatabase, userResolver)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &GroupViewService{
+		groupDatabase: groupDatabase,
+		userResolver:  userResolver,
+		policy:        policy,
+		logger:        logger,
+	}, nil
+}
+
+// GetUserGroups returns the groups the user is in or asked to join
+func (groupView *GroupViewService) GetUserGroups(username string) []service.ViewGroup {
+	userID, err := groupView.userResolver.GetUserID(username)
+
+	if err != nil {
+		return []service.ViewGroup{}
+	}
+
+	roles := groupView.getRoles(userID, nil)
+	groupIDs := []string{}
+
+	for groupID := range roles {
+		groupIDs = append(groupIDs, groupID)
+	}
+
+	if len(groupIDs) == 0 {
+		return []service.ViewGroup{}
+	}
+
+	cursor, err := groupView.groupDatabase.GetGroupsByIDs(groupView.context, groupIDs)
+
+	if err != nil {
+		groupView.logger.Panic(err)
+	}
+
+	groups := []service.Group{}
+
+	if err = cursor.All(groupView.context, &groups); err != nil {
+		groupView.logger.Panic(err)
+	}
+
+	return groupView.newViewGroups(groups, roles)
+}
+
+// FindGroups looks groups up by a part of the name, an empty name shows
+// the newest groups
+func (groupView *GroupViewService) FindGroups(username string, name string) []service.ViewGroup {
+	cursor, err := groupView.groupDatabase.FindGroups(groupView.context, strings.TrimSpace(name), SearchLimit)
+
+	if err != nil {
+		groupView.logger.Panic(err)
+	}
+
+	groups := []service.Group{}
+
+	if err = cursor.All(groupView.context, &groups); err != nil {
+		groupView.logger.Panic(err)
+	}
+
+	roles := map[string]string{}
+
+	if userID, err := groupView.userResolver.GetUserID(username); err == nil && len(groups) != 0 {
+		groupIDs := []string{}
+
+		for _, group := range groups {
+			groupIDs = append(groupIDs, group.ID)
+		}
+
+		roles = groupView.getRoles(userID, groupIDs)
+	}
+
+	return groupView.newViewGroups(groups, roles)
+}
+
+// GetGroup returns the group page, members and the board are shown only
+// to those allowed to view the board and join requests only to admins
+func (groupView *GroupViewService) GetGroup(username string, groupID string, page int64) (*service.ViewGroupPage, error) {
+	group, err := findGroup(groupView.context, groupView.groupDatabase, groupID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if page < 0 {
+		page = 0
+	}
+
+	policy := groupView.policy
+	gDb := groupView.groupDatabase
+
+	groupPage := &service.ViewGroupPage{
+		Group:        groupView.newViewGroup(group, ""),
+		Members:      []service.ViewGroupMember{},
+		Requests:     []service.ViewGroupMember{},
+		Posts:        []service.ViewGroupPost{},
+		Page:         page,
+		CanJoin:      policy.Can(username, access.ActionJoinGroup, groupID),
+		CanViewBoard: policy.Can(username, access.ActionViewGroupBoard, groupID),
+		CanPost:      policy.Can(username, access.ActionPostGroupBoard, groupID),
+		CanModerate:  policy.Can(username, access.ActionModerateGroup, groupID),
+		CanManage:    policy.Can(username, access.ActionManageGroup, groupID),
+	}
+
+	userID, err := groupView.userResolver.GetUserID(username)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if member, err := findMember(groupView.context, gDb, groupID, userID); err == nil {
+		groupPage.Group.Role = member.Role
+	}
+
+	if groupPage.CanModerate {
+		groupPage.Requests = groupView.getMembers(groupID, []string{GROUP_ROLE_REQUESTED})
+	}
+
+	if !groupPage.CanViewBoard {
+		return groupPage, nil
+	}
+
+	groupPage.Members = groupView.getMembers(groupID, memberRoles)
+
+	cursor, err := gDb.GetPosts(groupView.context, groupID, page*PageSize, PageSize)
+
+	if err != nil {
+		groupView.logger.Panic(err)
+	}
+
+	posts := []service.GroupPost{}
+
+	if err = cursor.All(groupView.context, &posts); err != nil {
+		groupView.logger.Panic(err)
+	}
+
+	authorIDs := []string{}
+
+	for _, post := range posts {
+		authorIDs = append(authorIDs, post.Author)
+	}
+
+	usernames := groupView.userResolver.GetUsernames(authorIDs)
+
+	for _, post := range posts {
+		groupPage.Posts = append(groupPage.Posts, service.ViewGroupPost{
+			ID:         post.ID,
+			Author:     usernames[post.Author],
+			Text:       post.Text,
+			FormatDate: post.DateAt.Format("2006-01-02 15:04"),
+			CanDelete:  post.Author == userID || groupPage.CanModerate,
+		})
+	}
+
+	groupPage.Total = gDb.CountPosts(groupView.context, groupID)
+	groupPage.OlderPage = page + 1
+	groupPage.NewerPage = page - 1
+	groupPage.HasOlder = (page+1)*PageSize < groupPage.Total
+	groupPage.HasNewer = page > 0
+
+	return groupPage, nil
+}
+
+func (groupView *GroupViewService) getMembers(groupID string, roles []string) []service.ViewGroupMember {
+	cursor, err := groupView.groupDatabase.GetMembers(groupView.context, groupID, roles)
+
+	if err != nil {
+		groupView.logger.Panic(err)
+	}
+
+	members := []service.GroupMember{}
+
+	if err = cursor.All(groupView.context, &members); err != nil {
+		groupView.logger.Panic(err)
+	}
+
+	userIDs := []string{}
+
+	for _, member := range members {
+		userIDs = append(userIDs, member.User)
+	}
+
+	usernames := groupView.userResolver.GetUsernames(userIDs)
+	viewMembers := []service.ViewGroupMember{}
+
+	for _, member := range members {
+		viewMembers = append(viewMembers, service.ViewGroupMember{
+			Username:   usernames[member.User],
+			Role:       member.Role,
+			FormatDate: member.DateAt.Format("2006-01-02 15:04"),
+		})
+	}
+
+	return viewMembers
+}
+
+// getRoles maps group ids to the role of the user in them
+func (groupView *GroupViewService) getRoles(userID string, groupIDs []string) map[string]string {
+	cursor, err := groupView.groupDatabase.GetUserMemberships(groupView.context, userID, groupIDs)
+
+	if err != nil {
+		groupView.logger.Panic(err)
+	}
+
+	members := []service.GroupMember{}
+
+	if err = cursor.All(groupView.context, &members); err != nil {
+		groupView.logger.Panic(err)
+	}
+
+	roles := map[string]string{}
+
+	for _, member := range members {
+		roles[member.GroupID] = member.Role
+	}
+
+	return roles
+}
+
+func (groupView *GroupViewService) newViewGroups(groups []service.Group, roles map[string]string) []service.ViewGroup {
+	viewGroups := []service.ViewGroup{}
+
+	for i := range groups {
+		viewGroups = append(viewGroups, groupView.newViewGroup(&groups[i], roles[groups[i].ID]))
+	}
+
+	return viewGroups
+}
+
+func (groupView *GroupViewService) newViewGroup(group *service.Group, role string) service.ViewGroup {
+	return service.ViewGroup{
+		ID:          group.ID,
+		Name:        group.Name,
+		Description: group.Description,
+		JoinPolicy:  group.JoinPolicy,
+		Members:     groupView.groupDatabase.CountMembers(groupView.context, group.ID),
+		Role:        role,
+	}
+}
@@ -0,0 +1,210 @@
+package guestbook
+
+import (
+	"context"
+
+	"github.com/delonce/socialnetwork/internal/database"
+	"github.com/delonce/socialnetwork/internal/database/mongodb"
+	"github.com/delonce/socialnetwork/internal/service"
+	"github.com/delonce/socialnetwork/pkg/logging"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type GuestbookQueries interface {
+	AddEntry(ctx context.Context, entry *service.GuestbookEntry) (string, error)
+	GetEntry(ctx context.Context, ownerID string, entryID string) (*mongo.SingleResult, error)
+	DeleteEntry(ctx context.Context, ownerID string, entryID string) error
+	SetEntryHidden(ctx context.Context, ownerID string, entryID string, isHidden bool) error
+	IncrementLikes(ctx context.Context, entryID string, delta int64) error
+
+	GetEntries(ctx context.Context, ownerID string, withHidden bool, skip int64, limit int64) (*mongo.Cursor, error)
+	CountEntries(ctx context.Context, ownerID string, withHidden bool) int64
+
+	AddLike(ctx context.Context, like *service.GuestbookLike) (bool, error)
+	DeleteLike(ctx context.Context, entryID string, userID string) error
+	DeleteLikes(ctx context.Context, entryID string) error
+	GetLikedEntries(ctx context.Context, userID string, entryIDs []string) (*mongo.Cursor, error)
+
+	EnsureIndexes(ctx context.Context) error
+}
+
+type GuestbookDB struct {
+	Storage database.DBStorage
+	Logger  *logging.Logger
+}
+
+func NewGuestbookDB(logger *logging.Logger, database *mongo.Database) GuestbookQueries {
+	storage := mongodb.NewStorage(
+		map[string]*mongo.Collection{
+			service.GUESTBOOK_COLLECTION:      database.Collection(service.GUESTBOOK_COLLECTION),
+			service.GUESTBOOK_LIKE_COLLECTION: database.Collection(service.GUESTBOOK_LIKE_COLLECTION),
+		},
+		logger,
+	)
+
+	return &GuestbookDB{
+		Storage: storage,
+		Logger:  logger,
+	}
+}
+
+func (guestbookStorage *GuestbookDB) AddEntry(ctx context.Context, entry *service.GuestbookEntry) (string, error) {
+	st := guestbookStorage.Storage
+
+	return st.CreateObject(ctx, entry, service.GUESTBOOK_COLLECTION)
+}
+
+func (guestbookStorage *GuestbookDB) GetEntry(ctx context.Context, ownerID string, entryID string) (*mongo.SingleResult, error) {
+	st := guestbookStorage.Storage
+	query, err := entryQuery(ownerID, entryID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return st.FindOneObject(ctx, query, service.GUESTBOOK_COLLECTION)
+}
+
+func (guestbookStorage *GuestbookDB) DeleteEntry(ctx context.Context, ownerID string, entryID string) error {
+	st := guestbookStorage.Storage
+	query, err := entryQuery(ownerID, entryID)
+
+	if err != nil {
+		return err
+	}
+
+	return st.Delete(ctx, query, service.GUESTBOOK_COLLECTION)
+}
+
+func (guestbookStorage *GuestbookDB) SetEntryHidden(ctx context.Context, ownerID string, entryID string, isHidden bool) error {
+	st := guestbookStorage.Storage
+	query, err := entryQuery(ownerID, entryID)
+
+	if err != nil {
+		return err
+	}
+
+	return st.Update(ctx, query, bson.M{"ishidden": isHidden}, service.GUESTBOOK_COLLECTION)
+}
+
+// IncrementLikes changes the counter on the storage side, so concurrent
+// likes never overwrite each other
+func (guestbookStorage *GuestbookDB) IncrementLikes(ctx context.Context, entryID string, delta int64) error {
+	st := guestbookStorage.Storage
+	objEntryID, err := primitive.ObjectIDFromHex(entryID)
+
+	if err != nil {
+		return err
+	}
+
+	return st.Increment(ctx, bson.M{"_id": objEntryID}, bson.M{"likes": delta}, service.GUESTBOOK_COLLECTION)
+}
+
+func (guestbookStorage *GuestbookDB) GetEntries(ctx context.Context, ownerID string, withHidden bool,
+	skip int64, limit int64) (*mongo.Cursor, error) {
+
+	st := guestbookStorage.Storage
+
+	findOpts := options.FindOptions{}
+
+	findOpts.SetSort(bson.D{{Key: "date", Value: -1}})
+	findOpts.SetSkip(skip)
+	findOpts.SetLimit(limit)
+
+	return st.FindObjects(ctx, entriesQuery(ownerID, withHidden), service.GUESTBOOK_COLLECTION, &findOpts)
+}
+
+func (guestbookStorage *GuestbookDB) CountEntries(ctx context.Context, ownerID string, withHidden bool) int64 {
+	st := guestbookStorage.Storage
+
+	amount, err := st.CountObjects(ctx, entriesQuery(ownerID, withHidden), service.GUESTBOOK_COLLECTION)
+
+	if err != nil {
+		guestbookStorage.Logger.Errorf("Error when counting guestbook entries of %s, %v", ownerID, err)
+		return 0
+	}
+
+	return amount
+}
+
+// AddLike reports false when the user already liked the entry
+func (guestbookStorage *GuestbookDB) AddLike(ctx context.Context, like *service.GuestbookLike) (bool, error) {
+	st := guestbookStorage.Storage
+
+	query := bson.M{
+		"entryid": like.EntryID,
+		"user":    like.User,
+	}
+
+	return st.InsertIfAbsent(ctx, query, like, service.GUESTBOOK_LIKE_COLLECTION)
+}
+
+func (guestbookStorage *GuestbookDB) DeleteLike(ctx context.Context, entryID string, userID string) error {
+	st := guestbookStorage.Storage
+
+	query := bson.M{
+		"entryid": entryID,
+		"user":    userID,
+	}
+
+	return st.Delete(ctx, query, service.GUESTBOOK_LIKE_COLLECTION)
+}
+
+func (guestbookStorage *GuestbookDB) DeleteLikes(ctx context.Context, entryID string) error {
+	st := guestbookStorage.Storage
+
+	return st.DeleteMany(ctx, bson.M{"entryid": entryID}, service.GUESTBOOK_LIKE_COLLECTION)
+}
+
+func (guestbookStorage *GuestbookDB) GetLikedEntries(ctx context.Context, userID string, entryIDs []string) (*mongo.Cursor, error) {
+	st := guestbookStorage.Storage
+
+	query := bson.M{
+		"$and": []bson.M{
+			{"user": userID},
+			{"entryid": bson.M{"$in": entryIDs}},
+		},
+	}
+
+	return st.FindObjects(ctx, query, service.GUESTBOOK_LIKE_COLLECTION)
+}
+
+// EnsureIndexes makes a second like of the same user impossible even when
+// two requests insert it at once
+func (guestbookStorage *GuestbookDB) EnsureIndexes(ctx context.Context) error {
+	st := guestbookStorage.Storage
+
+	return st.EnsureUniqueIndex(ctx, []string{"entryid", "user"}, service.GUESTBOOK_LIKE_COLLECTION)
+}
+
+func entryQuery(ownerID string, entryID string) (bson.M, error) {
+	objEntryID, err := primitive.ObjectIDFromHex(entryID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return bson.M{
+		"$and": []bson.M{
+			{"_id": objEntryID},
+			{"owner": ownerID},
+		},
+	}, nil
+}
+
+func entriesQuery(ownerID string, withHidden bool) bson.M {
+	if withHidden {
+		return bson.M{"owner": ownerID}
+	}
+
+	return bson.M{
+		"$and": []bson.M{
+			{"owner": ownerID},
+			{"ishidden": false},
+		},
+	}
+}
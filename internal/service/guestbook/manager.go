@@ -0,0 +1,167 @@
+package guestbook
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/delonce/socialnetwork/internal/config"
+	"github.com/delonce/socialnetwork/internal/service"
+	"github.com/delonce/socialnetwork/internal/service/access"
+	"github.com/delonce/socialnetwork/internal/service/friends"
+	"github.com/delonce/socialnetwork/internal/service/user"
+	"github.com/delonce/socialnetwork/pkg/logging"
+)
+
+type GuestbookManagerService struct {
+	guestbookDatabase GuestbookQueries
+	policy            access.Policy
+	userResolver      user.Resolver
+	logger            *logging.Logger
+	context           context.Context
+}
+
+func NewGuestbookManager(logger *logging.Logger, config *config.Config) (GuestbookManager, error) {
+	database, err := service.InitNewDatabase(config)
+
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	friendView, err := friends.NewFriendViewer(logger, config)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &GuestbookManagerService{
+		guestbookDatabase: NewGuestbookDB(logger, database),
+		policy:            access.NewRelationsPolicy(friendView),
+		userResolver:      user.NewResolver(logger, database),
+		logger:            logger,
+	}, nil
+}
+
+func (manager *GuestbookManagerService) AddEntry(owner string, author string, text string) (string, error) {
+	text = strings.TrimSpace(text)
+
+	if text == "" {
+		return "", ErrEmptyEntry
+	}
+
+	if utf8.RuneCountInString(text) > MaxEntryLength {
+		return "", ErrLongEntry
+	}
+
+	if err := manager.policy.Authorize(author, access.ActionWriteGuestbook, owner); err != nil {
+		return "", err
+	}
+
+	userIDs, err := manager.userResolver.GetUserIDs([]string{owner, author})
+
+	if err != nil {
+		return "", err
+	}
+
+	return manager.guestbookDatabase.AddEntry(manager.context, &service.GuestbookEntry{
+		Owner:  userIDs[owner],
+		Author: userIDs[author],
+		Text:   text,
+		DateAt: time.Now(),
+	})
+}
+
+func (manager *GuestbookManagerService) DeleteEntry(owner string, entryID string) error {
+	ownerID, err := manager.userResolver.GetUserID(owner)
+
+	if err != nil {
+		return err
+	}
+
+	if err = manager.guestbookDatabase.DeleteEntry(manager.context, ownerID, entryID); err != nil {
+		return ErrEntryNotFound
+	}
+
+	return manager.guestbookDatabase.DeleteLikes(manager.context, entryID)
+}
+
+func (manager *GuestbookManagerService) SetEntryHidden(owner string, entryID string, isHidden bool) error {
+	ownerID, err := manager.userResolver.GetUserID(owner)
+
+	if err != nil {
+		return err
+	}
+
+	if err = manager.guestbookDatabase.SetEntryHidden(manager.context, ownerID, entryID, isHidden); err != nil {
+		return ErrEntryNotFound
+	}
+
+	return nil
+}
+
+// ToggleLike likes the entry or takes the like back, the counter is changed
+// only when the like really appeared or disappeared
+func (manager *GuestbookManagerService) ToggleLike(username string, owner string, entryID string) error {
+	if err := manager.policy.Authorize(username, access.ActionWriteGuestbook, owner); err != nil {
+		return err
+	}
+
+	userIDs, err := manager.userResolver.GetUserIDs([]string{owner, username})
+
+	if err != nil {
+		return err
+	}
+
+	entry, err := manager.getEntry(userIDs[owner], entryID)
+
+	if err != nil {
+		return err
+	}
+
+	if entry.IsHidden && username != owner {
+		return ErrEntryNotFound
+	}
+
+	gDb := manager.guestbookDatabase
+	userID := userIDs[username]
+
+	isAdded, err := gDb.AddLike(manager.context, &service.GuestbookLike{
+		EntryID: entryID,
+		User:    userID,
+		DateAt:  time.Now(),
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if isAdded {
+		return gDb.IncrementLikes(manager.context, entryID, 1)
+	}
+
+	if err = gDb.DeleteLike(manager.context, entryID, userID); err != nil {
+		// the like was taken back by a concurrent request
+		return nil
+	}
+
+	return gDb.IncrementLikes(manager.context, entryID, -1)
+}
+
+func (manager *GuestbookManagerService) getEntry(ownerID string, entryID string) (*service.GuestbookEntry, error) {
+	result, err := manager.guestbookDatabase.GetEntry(manager.context, ownerID, entryID)
+
+	if err != nil {
+		return nil, ErrEntryNotFound
+	}
+
+	entry := service.GuestbookEntry{}
+
+	if err = result.Decode(&entry); err != nil {
+		manager.logger.Errorf("Error while decoding guestbook entry %s, %v", entryID, err)
+		return nil, err
+	}
+
+	return &entry, nil
+}
@@ -0,0 +1,29 @@
+package guestbook
+
+import (
+	"errors"
+
+	"github.com/delonce/socialnetwork/internal/service"
+)
+
+const (
+	MaxEntryLength = 1000
+	PageSize       = 10
+)
+
+var (
+	ErrEmptyEntry    = errors.New("Guestbook entry can't be empty")
+	ErrLongEntry     = errors.New("Guestbook entry is too long")
+	ErrEntryNotFound = errors.New("Guestbook entry not found")
+)
+
+type GuestbookManager interface {
+	AddEntry(owner string, author string, text string) (string, error)
+	DeleteEntry(owner string, entryID string) error
+	SetEntryHidden(owner string, entryID string, isHidden bool) error
+	ToggleLike(username string, owner string, entryID string) error
+}
+
+type GuestbookViewer interface {
+	GetGuestbook(owner string, viewer string, page int64) *service.ViewGuestbookPage
+}
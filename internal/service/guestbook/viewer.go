@@ -0,0 +1,147 @@
+package guestbook
+
+import (
+	"context"
+
+	"github.com/delonce/socialnetwork/internal/config"
+	"github.com/delonce/socialnetwork/internal/service"
+	"github.com/delonce/socialnetwork/internal/service/access"
+	"github.com/delonce/socialnetwork/internal/service/friends"
+	"github.com/delonce/socialnetwork/internal/service/user"
+	"github.com/delonce/socialnetwork/pkg/logging"
+)
+
+type GuestbookViewService struct {
+	guestbookDatabase GuestbookQueries
+	policy            access.Policy
+	userResolver      user.Resolver
+	logger            *logging.Logger
+	context           context.Context
+}
+
+func NewGuestbookViewer(logger *logging.Logger, config *config.Config) (GuestbookViewer, error) {
+	database, err := service.InitNewDatabase(config)
+
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	friendView, err := friends.NewFriendViewer(logger, config)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &GuestbookViewService{
+		guestbookDatabase: NewGuestbookDB(logger, database),
+		policy:            access.NewRelationsPolicy(friendView),
+		userResolver:      user.NewResolver(logger, database),
+		logger:            logger,
+	}, nil
+}
+
+// GetGuestbook returns one page of entries, hidden entries are shown to
+// the owner only
+func (guestbookView *GuestbookViewService) GetGuestbook(owner string, viewer string, page int64) *service.ViewGuestbookPage {
+	if page < 0 {
+		page = 0
+	}
+
+	isOwner := owner == viewer
+
+	guestbookPage := &service.ViewGuestbookPage{
+		Entries:  []service.ViewGuestbookEntry{},
+		Page:     page,
+		IsOwner:  isOwner,
+		CanWrite: guestbookView.policy.Can(viewer, access.ActionWriteGuestbook, owner),
+	}
+
+	userIDs, err := guestbookView.userResolver.GetUserIDs([]string{owner, viewer})
+
+	if err != nil {
+		guestbookView.logger.Panic(err)
+	}
+
+	ownerID, ok := userIDs[owner]
+
+	if !ok {
+		return guestbookPage
+	}
+
+	gDb := guestbookView.guestbookDatabase
+	cursor, err := gDb.GetEntries(guestbookView.context, ownerID, isOwner, page*PageSize, PageSize)
+
+	if err != nil {
+		guestbookView.logger.Panic(err)
+	}
+
+	entries := []service.GuestbookEntry{}
+	err = cursor.All(guestbookView.context, &entries)
+
+	if err != nil {
+		guestbookView.logger.Panic(err)
+	}
+
+	likedEntries := guestbookView.getLikedEntries(userIDs[viewer], entries)
+	authorIDs := []string{}
+
+	for _, entry := range entries {
+		authorIDs = append(authorIDs, entry.Author)
+	}
+
+	usernames := guestbookView.userResolver.GetUsernames(authorIDs)
+
+	for _, entry := range entries {
+		guestbookPage.Entries = append(guestbookPage.Entries, service.ViewGuestbookEntry{
+			ID:         entry.ID,
+			Author:     usernames[entry.Author],
+			Text:       entry.Text,
+			FormatDate: entry.DateAt.Format("2006-01-02 15:04"),
+			Likes:      entry.Likes,
+			IsLiked:    likedEntries[entry.ID],
+			IsHidden:   entry.IsHidden,
+		})
+	}
+
+	guestbookPage.Total = gDb.CountEntries(guestbookView.context, ownerID, isOwner)
+	guestbookPage.OlderPage = page + 1
+	guestbookPage.NewerPage = page - 1
+	guestbookPage.HasOlder = (page+1)*PageSize < guestbookPage.Total
+	guestbookPage.HasNewer = page > 0
+
+	return guestbookPage
+}
+
+func (guestbookView *GuestbookViewService) getLikedEntries(viewerID string, entries []service.GuestbookEntry) map[string]bool {
+	likedEntries := map[string]bool{}
+
+	if viewerID == "" || len(entries) == 0 {
+		return likedEntries
+	}
+
+	entryIDs := []string{}
+
+	for _, entry := range entries {
+		entryIDs = append(entryIDs, entry.ID)
+	}
+
+	cursor, err := guestbookView.guestbookDatabase.GetLikedEntries(guestbookView.context, viewerID, entryIDs)
+
+	if err != nil {
+		guestbookView.logger.Panic(err)
+	}
+
+	likes := []service.GuestbookLike{}
+	err = cursor.All(guestbookView.context, &likes)
+
+	if err != nil {
+		guestbookView.logger.Panic(err)
+	}
+
+	for _, like := range likes {
+		likedEntries[like.EntryID] = true
+	}
+
+	return likedEntries
+}
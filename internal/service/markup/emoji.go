@@ -0,0 +1,33 @@
+package markup
+
+// emojis maps shortcodes written as :name: to the emoji they stand for
+var emojis = map[string]string{
+	"smile":        "😄",
+	"grin":         "😁",
+	"joy":          "😂",
+	"laughing":     "😆",
+	"wink":         "😉",
+	"blush":        "😊",
+	"sunglasses":   "😎",
+	"thinking":     "🤔",
+	"cry":          "😢",
+	"sob":          "😭",
+	"angry":        "😠",
+	"scream":       "😱",
+	"heart":        "❤️",
+	"broken_heart": "💔",
+	"thumbsup":     "👍",
+	"+1":           "👍",
+	"thumbsdown":   "👎",
+	"-1":           "👎",
+	"ok_hand":      "👌",
+	"clap":         "👏",
+	"wave":         "👋",
+	"pray":         "🙏",
+	"eyes":         "👀",
+	"fire":         "🔥",
+	"tada":         "🎉",
+	"rocket":       "🚀",
+	"star":         "⭐",
+	"100":          "💯",
+}
@@ -0,0 +1,210 @@
+package markup
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	blockNone      = ""
+	blockParagraph = "p"
+	blockQuote     = "quote"
+	blockList      = "list"
+	blockCode      = "code"
+
+	codeFence = "```"
+)
+
+var (
+	codeSpanRe     = regexp.MustCompile("`([^`\n]+)`")
+	linkRe         = regexp.MustCompile(`\[([^\[\]\n]+)\]\((https?://[^\s()<>]+)\)`)
+	urlRe          = regexp.MustCompile(`https?://[^\s<>"]+`)
+	mentionRe      = regexp.MustCompile(`(^|[^\p{L}\p{N}_])@([\p{L}\p{N}_.\-]+)`)
+	shortcodeRe    = regexp.MustCompile(`:([a-z0-9_+\-]+):`)
+	strongRe       = regexp.MustCompile(`\*\*([^*\n]+)\*\*`)
+	strikeRe       = regexp.MustCompile(`~~([^~\n]+)~~`)
+	emRe           = regexp.MustCompile(`\*([^*\n]+)\*`)
+	underscoreEmRe = regexp.MustCompile(`(^|[^\p{L}\p{N}_])_([^_\n]+)_`)
+	placeholderRe  = regexp.MustCompile("\x00([0-9]+)\x00")
+)
+
+// renderSource renders the markdown subset: paragraphs, "> " quotes, "- "
+// lists and fenced code blocks, mentions are linked only when the username
+// is in the given set
+func renderSource(source string, mentions map[string]bool) string {
+	source = strings.ReplaceAll(source, "\x00", "")
+	source = strings.ReplaceAll(source, "\r\n", "\n")
+
+	writer := &blockWriter{mentions: mentions}
+
+	for _, line := range strings.Split(source, "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		if writer.kind == blockCode {
+			if strings.HasPrefix(trimmed, codeFence) {
+				writer.flush()
+			} else {
+				writer.lines = append(writer.lines, line)
+			}
+
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(trimmed, codeFence):
+			writer.start(blockCode)
+		case trimmed == "":
+			writer.flush()
+		case strings.HasPrefix(trimmed, ">"):
+			writer.add(blockQuote, strings.TrimSpace(trimmed[1:]))
+		case strings.HasPrefix(trimmed, "- "), strings.HasPrefix(trimmed, "* "):
+			writer.add(blockList, trimmed[2:])
+		default:
+			writer.add(blockParagraph, trimmed)
+		}
+	}
+
+	writer.flush()
+
+	return writer.out.String()
+}
+
+// extractMentions returns usernames mentioned in the source without
+// the punctuation which may follow them
+func extractMentions(source string) []string {
+	usernames := []string{}
+
+	for _, match := range mentionRe.FindAllStringSubmatch(source, -1) {
+		if username := trimMention(match[2]); username != "" {
+			usernames = append(usernames, username)
+		}
+	}
+
+	return usernames
+}
+
+type blockWriter struct {
+	out      strings.Builder
+	kind     string
+	lines    []string
+	mentions map[string]bool
+}
+
+func (writer *blockWriter) start(kind string) {
+	writer.flush()
+	writer.kind = kind
+}
+
+func (writer *blockWriter) add(kind string, line string) {
+	if writer.kind != kind {
+		writer.start(kind)
+	}
+
+	writer.lines = append(writer.lines, line)
+}
+
+func (writer *blockWriter) flush() {
+	switch writer.kind {
+	case blockParagraph:
+		writer.out.WriteString("<p>" + writer.renderLines("<br>") + "</p>")
+	case blockQuote:
+		writer.out.WriteString("<blockquote><p>" + writer.renderLines("<br>") + "</p></blockquote>")
+	case blockList:
+		writer.out.WriteString("<ul><li>" + writer.renderLines("</li><li>") + "</li></ul>")
+	case blockCode:
+		writer.out.WriteString("<pre><code>" + html.EscapeString(strings.Join(writer.lines, "\n")) + "</code></pre>")
+	}
+
+	writer.kind = blockNone
+	writer.lines = nil
+}
+
+func (writer *blockWriter) renderLines(separator string) string {
+	rendered := []string{}
+
+	for _, line := range writer.lines {
+		inline := &inlineRenderer{mentions: writer.mentions}
+		rendered = append(rendered, inline.render(line))
+	}
+
+	return strings.Join(rendered, separator)
+}
+
+// inlineRenderer replaces code spans, links and mentions with placeholders
+// before escaping the text, so emphasis and shortcodes never touch them
+type inlineRenderer struct {
+	mentions map[string]bool
+	tokens   []string
+}
+
+func (inline *inlineRenderer) render(text string) string {
+	text = codeSpanRe.ReplaceAllStringFunc(text, func(match string) string {
+		return inline.protect("<code>" + html.EscapeString(match[1:len(match)-1]) + "</code>")
+	})
+
+	text = linkRe.ReplaceAllStringFunc(text, func(match string) string {
+		parts := linkRe.FindStringSubmatch(match)
+
+		return inline.protect(anchor(parts[2], parts[1]))
+	})
+
+	text = urlRe.ReplaceAllStringFunc(text, func(match string) string {
+		link := strings.TrimRight(match, ".,;:!?)'")
+
+		return inline.protect(anchor(link, link)) + match[len(link):]
+	})
+
+	text = mentionRe.ReplaceAllStringFunc(text, func(match string) string {
+		parts := mentionRe.FindStringSubmatch(match)
+		username := trimMention(parts[2])
+
+		if !inline.mentions[username] {
+			return match
+		}
+
+		link := fmt.Sprintf(`<a href="%s">@%s</a>`,
+			html.EscapeString(MentionURL+url.PathEscape(username)), html.EscapeString(username))
+
+		return parts[1] + inline.protect(link) + parts[2][len(username):]
+	})
+
+	text = html.EscapeString(text)
+
+	text = shortcodeRe.ReplaceAllStringFunc(text, func(match string) string {
+		if emoji, ok := emojis[match[1:len(match)-1]]; ok {
+			return emoji
+		}
+
+		return match
+	})
+
+	text = strongRe.ReplaceAllString(text, "<strong>$1</strong>")
+	text = strikeRe.ReplaceAllString(text, "<del>$1</del>")
+	text = emRe.ReplaceAllString(text, "<em>$1</em>")
+	text = underscoreEmRe.ReplaceAllString(text, "$1<em>$2</em>")
+
+	return placeholderRe.ReplaceAllStringFunc(text, func(match string) string {
+		index, _ := strconv.Atoi(match[1 : len(match)-1])
+
+		return inline.tokens[index]
+	})
+}
+
+func (inline *inlineRenderer) protect(rendered string) string {
+	inline.tokens = append(inline.tokens, rendered)
+
+	return fmt.Sprintf("\x00%d\x00", len(inline.tokens)-1)
+}
+
+// anchor links only http and https urls, the caller's regexps guarantee it
+func anchor(link string, text string) string {
+	return fmt.Sprintf(`<a href="%s" rel="nofollow">%s</a>`, html.EscapeString(link), html.EscapeString(text))
+}
+
+func trimMention(username string) string {
+	return strings.TrimRight(username, ".-")
+}
@@ -0,0 +1,96 @@
+package markup
+
+import "testing"
+
+var renderCases = []struct {
+	name     string
+	source   string
+	mentions map[string]bool
+	want     string
+}{
+	{
+		name:   "script tag",
+		source: `<script>alert("hi")</script>`,
+		want:   `<p>&lt;script&gt;alert(&#34;hi&#34;)&lt;/script&gt;</p>`,
+	},
+	{
+		name:   "script tag in code",
+		source: "`<script>` and\n```\n<script>alert(1)</script>\n```",
+		want: `<p><code>&lt;script&gt;</code> and</p>` +
+			`<pre><code>&lt;script&gt;alert(1)&lt;/script&gt;</code></pre>`,
+	},
+	{
+		name:   "javascript link",
+		source: `[click](javascript:alert(1))`,
+		want:   `<p>[click](javascript:alert(1))</p>`,
+	},
+	{
+		name:   "javascript url",
+		source: `javascript://example.com/%0Aalert(1)`,
+		want:   `<p>javascript://example.com/%0Aalert(1)</p>`,
+	},
+	{
+		name:   "link",
+		source: `[site](https://example.com/a?b=1&c=2)`,
+		want:   `<p><a href="https://example.com/a?b=1&amp;c=2" rel="nofollow">site</a></p>`,
+	},
+	{
+		name:   "double quote in url",
+		source: `https://example.com/" onmouseover="alert(1)`,
+		want: `<p><a href="https://example.com/" rel="nofollow">https://example.com/</a>` +
+			`&#34; onmouseover=&#34;alert(1)</p>`,
+	},
+	{
+		name:   "single quote in url",
+		source: `https://example.com/'onmouseover='alert(1)`,
+		want: `<p><a href="https://example.com/&#39;onmouseover=&#39;alert(1" rel="nofollow">` +
+			`https://example.com/&#39;onmouseover=&#39;alert(1</a>)</p>`,
+	},
+	{
+		name:   "quote in link text",
+		source: `[say "hi"](https://example.com)`,
+		want:   `<p><a href="https://example.com" rel="nofollow">say &#34;hi&#34;</a></p>`,
+	},
+	{
+		name:   "emphasis inside strong",
+		source: `**bold _and em_**`,
+		want:   `<p><strong>bold <em>and em</em></strong></p>`,
+	},
+	{
+		name:   "emphasis inside quote and list",
+		source: "> **quoted** :smile:\n\n- *one*\n- ~~two~~",
+		want: `<blockquote><p><strong>quoted</strong> 😄</p></blockquote>` +
+			`<ul><li><em>one</em></li><li><del>two</del></li></ul>`,
+	},
+	{
+		name:   "emphasis never enters urls",
+		source: `https://example.com/*a*_b_`,
+		want:   `<p><a href="https://example.com/*a*_b_" rel="nofollow">https://example.com/*a*_b_</a></p>`,
+	},
+	{
+		name:     "mention",
+		source:   `hi @alice, @mallory.`,
+		mentions: map[string]bool{"alice": true},
+		want:     `<p>hi <a href="/users/alice">@alice</a>, @mallory.</p>`,
+	},
+	{
+		name:   "forged placeholder",
+		source: "\x000\x00 https://example.com",
+		want:   `<p>0 <a href="https://example.com" rel="nofollow">https://example.com</a></p>`,
+	},
+	{
+		name:   "forged placeholder without tokens",
+		source: "\x005\x00<b>",
+		want:   `<p>5&lt;b&gt;</p>`,
+	},
+}
+
+func TestRenderSource(t *testing.T) {
+	for _, testCase := range renderCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if got := renderSource(testCase.source, testCase.mentions); got != testCase.want {
+				t.Errorf("renderSource(%q) =\n%s\nwant\n%s", testCase.source, got, testCase.want)
+			}
+		})
+	}
+}
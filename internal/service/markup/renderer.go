@@ -0,0 +1,63 @@
+package markup
+
+import (
+	"html/template"
+
+	"github.com/delonce/socialnetwork/internal/service/user"
+	"github.com/delonce/socialnetwork/pkg/logging"
+)
+
+type MarkupRenderer struct {
+	userResolver user.Resolver
+	logger       *logging.Logger
+}
+
+func NewRenderer(logger *logging.Logger, resolver user.Resolver) Renderer {
+	return &MarkupRenderer{
+		userResolver: resolver,
+		logger:       logger,
+	}
+}
+
+func (renderer *MarkupRenderer) Render(source string) template.HTML {
+	return renderer.RenderAll([]string{source})[0]
+}
+
+// RenderAll validates mentions of all sources in one query, so rendering
+// a page of messages costs a single lookup
+func (renderer *MarkupRenderer) RenderAll(sources []string) []template.HTML {
+	mentions := renderer.findUsers(sources)
+	rendered := make([]template.HTML, 0, len(sources))
+
+	for _, source := range sources {
+		rendered = append(rendered, template.HTML(renderSource(source, mentions)))
+	}
+
+	return rendered
+}
+
+func (renderer *MarkupRenderer) findUsers(sources []string) map[string]bool {
+	existing := map[string]bool{}
+	usernames := []string{}
+
+	for _, source := range sources {
+		usernames = append(usernames, extractMentions(source)...)
+	}
+
+	if len(usernames) == 0 {
+		return existing
+	}
+
+	userIDs, err := renderer.userResolver.GetUserIDs(usernames)
+
+	if err != nil {
+		renderer.logger.Errorf("Can't check mentioned users, %v", err)
+		return existing
+	}
+
+	for username := range userIDs {
+		existing[username] = true
+	}
+
+	return existing
+}
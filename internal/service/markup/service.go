@@ -0,0 +1,14 @@
+package markup
+
+import "html/template"
+
+// MentionURL is the page a validated @username links to
+const MentionURL = "/users/"
+
+// Renderer turns raw message source into sanitized html. The source is
+// always escaped first, so the only tags in the result are the ones the
+// markup produces
+type Renderer interface {
+	Render(source string) template.HTML
+	RenderAll(sources []string) []template.HTML
+}
@@ -2,12 +2,14 @@ package messages
 
 import (
 	"context"
+	"time"
 
 	"github.com/delonce/socialnetwork/internal/database"
 	"github.com/delonce/socialnetwork/internal/database/mongodb"
 	"github.com/delonce/socialnetwork/internal/service"
 	"github.com/delonce/socialnetwork/pkg/logging"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -16,12 +18,52 @@ type MessageQueries interface {
 	AddNewMessage(ctx context.Context, message service.Message) error
 
 	GetAllDialogMessages(ctx context.Context, from string, to string) (*mongo.Cursor, error)
+	GetDialogMessagesPage(ctx context.Context, from string, to string, skip int64, limit int64) (*mongo.Cursor, error)
+	GetMessagesByIDs(ctx context.Context, messageIDs []string) (*mongo.Cursor, error)
 	GetLastMessage(ctx context.Context, username string, friend string) (*mongo.Cursor, error)
 
-	CountAllNewMessages(ctx context.Context, username string) int64
+	CountAllNewMessages(ctx context.Context, username string, exceptFrom []string) int64
 	CountNewMessagesBySender(ctx context.Context, from string, to string) int64
+	CountDialogMessages(ctx context.Context, from string, to string) int64
+	CountDialogMessagesAfter(ctx context.Context, from string, to string, date time.Time) int64
 
 	SetCheckMark(ctx context.Context, from string, to string) error
+
+	GetMessageByID(ctx context.Context, messageID string) (*mongo.SingleResult, error)
+
+	AddReaction(ctx context.Context, reaction service.MessageReaction) (bool, error)
+	DeleteReaction(ctx context.Context, messageID string, from string, emoji string) error
+
+	GetReactionCounts(ctx context.Context, messageIDs []string) (*mongo.Cursor, error)
+	CountNewReactionsBySender(ctx context.Context, from string, to string) int64
+	SetReactionCheckMark(ctx context.Context, from string, to string) error
+
+	SetPollVote(ctx context.Context, vote *service.PollVote) error
+	GetPollVotes(ctx context.Context, messageIDs []string) (*mongo.Cursor, error)
+	EnsurePollIndexes(ctx context.Context) error
+	EnsureReactionIndexes(ctx context.Context) error
+
+	AddScheduledMessage(ctx context.Context, message service.ScheduledMessage) (string, error)
+	GetPendingScheduledMessages(ctx context.Context, from string) (*mongo.Cursor, error)
+	GetPendingScheduledMessage(ctx context.Context, messageID string, from string) (*mongo.SingleResult, error)
+	UpdatePendingScheduledMessage(ctx context.Context, messageID string, from string, model bson.M) error
+
+	ClaimDueScheduledMessage(ctx context.Context, instanceID string, lease time.Duration) (*mongo.SingleResult, error)
+	DeliverScheduledMessage(ctx context.Context, message *service.ScheduledMessage) error
+	SetScheduledStatus(ctx context.Context, messageID string, instanceID string, status string) error
+
+	GetConversationSettings(ctx context.Context, username string) (*mongo.Cursor, error)
+	SetConversationSettings(ctx context.Context, username string, friend string, model bson.M) error
+
+	MigrateUserIDs(ctx context.Context, userIDs map[string]string) error
+}
+
+// messageUserFields lists fields holding user ids in every message collection
+var messageUserFields = map[string][]string{
+	service.MESSAGE_COLLECTION:      {"from", "to"},
+	service.REACTION_COLLECTION:     {"from", "to"},
+	service.SCHEDULED_COLLECTION:    {"from", "to"},
+	service.CONVERSATION_COLLECTION: {"username", "friend"},
 }
 
 type MessageDB struct {
@@ -32,7 +74,11 @@ type MessageDB struct {
 func NewMessageDB(logger *logging.Logger, database *mongo.Database) MessageQueries {
 	storage := mongodb.NewStorage(
 		map[string]*mongo.Collection{
-			service.MESSAGE_COLLECTION: database.Collection(service.MESSAGE_COLLECTION),
+			service.MESSAGE_COLLECTION:      database.Collection(service.MESSAGE_COLLECTION),
+			service.REACTION_COLLECTION:     database.Collection(service.REACTION_COLLECTION),
+			service.SCHEDULED_COLLECTION:    database.Collection(service.SCHEDULED_COLLECTION),
+			service.POLL_VOTE_COLLECTION:    database.Collection(service.POLL_VOTE_COLLECTION),
+			service.CONVERSATION_COLLECTION: database.Collection(service.CONVERSATION_COLLECTION),
 		},
 		logger,
 	)
@@ -73,12 +119,75 @@ func (msgDatabase *MessageDB) GetAllDialogMessages(ctx context.Context, from str
 	return st.FindObjects(ctx, query, service.MESSAGE_COLLECTION, &findOpts)
 }
 
-func (msgDatabase *MessageDB) CountAllNewMessages(ctx context.Context, username string) int64 {
+func (msgDatabase *MessageDB) GetDialogMessagesPage(ctx context.Context, from string, to string, skip int64, limit int64) (*mongo.Cursor, error) {
+	st := msgDatabase.Storage
+
+	findOpts := options.FindOptions{}
+
+	findOpts.SetSort(bson.D{{Key: "date", Value: -1}})
+	findOpts.SetSkip(skip)
+	findOpts.SetLimit(limit)
+
+	return st.FindObjects(ctx, dialogQuery(from, to), service.MESSAGE_COLLECTION, &findOpts)
+}
+
+func (msgDatabase *MessageDB) GetMessagesByIDs(ctx context.Context, messageIDs []string) (*mongo.Cursor, error) {
+	st := msgDatabase.Storage
+	objMessageIDs := []primitive.ObjectID{}
+
+	for _, messageID := range messageIDs {
+		objMessageID, err := primitive.ObjectIDFromHex(messageID)
+
+		if err != nil {
+			continue
+		}
+
+		objMessageIDs = append(objMessageIDs, objMessageID)
+	}
+
+	query := bson.M{"_id": bson.M{"$in": objMessageIDs}}
+
+	return st.FindObjects(ctx, query, service.MESSAGE_COLLECTION)
+}
+
+func (msgDatabase *MessageDB) CountDialogMessages(ctx context.Context, from string, to string) int64 {
+	st := msgDatabase.Storage
+
+	num, err := st.CountObjects(ctx, dialogQuery(from, to), service.MESSAGE_COLLECTION)
+
+	if err != nil {
+		msgDatabase.Logger.Panicf("Error when counting, %v", err)
+	}
+
+	return num
+}
+
+func (msgDatabase *MessageDB) CountDialogMessagesAfter(ctx context.Context, from string, to string, date time.Time) int64 {
+	st := msgDatabase.Storage
+
+	query := bson.M{
+		"$and": []bson.M{
+			dialogQuery(from, to),
+			{"date": bson.M{"$gt": date}},
+		},
+	}
+
+	num, err := st.CountObjects(ctx, query, service.MESSAGE_COLLECTION)
+
+	if err != nil {
+		msgDatabase.Logger.Panicf("Error when counting, %v", err)
+	}
+
+	return num
+}
+
+func (msgDatabase *MessageDB) CountAllNewMessages(ctx context.Context, username string, exceptFrom []string) int64 {
 	st := msgDatabase.Storage
 
 	query := bson.M{
 		"$and": []bson.M{
 			{"to": username},
+			{"from": bson.M{"$nin": exceptFrom}},
 			{"ischecked": false},
 		},
 	}
@@ -86,7 +195,7 @@ func (msgDatabase *MessageDB) CountAllNewMessages(ctx context.Context, username
 	num, err := st.CountObjects(ctx, query, service.MESSAGE_COLLECTION)
 
 	if err != nil {
-		msgDatabase.Logger.Panic("Error when counting, %v", err)
+		msgDatabase.Logger.Panicf("Error when counting, %v", err)
 	}
 
 	return num
@@ -106,7 +215,7 @@ func (msgDatabase *MessageDB) CountNewMessagesBySender(ctx context.Context, from
 	num, err := st.CountObjects(ctx, query, service.MESSAGE_COLLECTION)
 
 	if err != nil {
-		msgDatabase.Logger.Panic("Error when counting, %v", err)
+		msgDatabase.Logger.Panicf("Error when counting, %v", err)
 	}
 
 	return num
@@ -154,3 +263,378 @@ func (msgDatabase *MessageDB) SetCheckMark(ctx context.Context, from string, to
 
 	return st.Update(ctx, query, model, service.MESSAGE_COLLECTION)
 }
+
+func (msgDatabase *MessageDB) GetMessageByID(ctx context.Context, messageID string) (*mongo.SingleResult, error) {
+	st := msgDatabase.Storage
+	objMessageID, err := primitive.ObjectIDFromHex(messageID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	query := bson.M{"_id": objMessageID}
+
+	return st.FindOneObject(ctx, query, service.MESSAGE_COLLECTION)
+}
+
+// AddReaction reports false when the user has already reacted with the emoji
+func (msgDatabase *MessageDB) AddReaction(ctx context.Context, reaction service.MessageReaction) (bool, error) {
+	st := msgDatabase.Storage
+
+	query := bson.M{
+		"messageid": reaction.MessageID,
+		"from":      reaction.From,
+		"emoji":     reaction.Emoji,
+	}
+
+	return st.InsertIfAbsent(ctx, query, reaction, service.REACTION_COLLECTION)
+}
+
+func (msgDatabase *MessageDB) DeleteReaction(ctx context.Context, messageID string, from string, emoji string) error {
+	st := msgDatabase.Storage
+
+	query := bson.M{
+		"$and": []bson.M{
+			{"messageid": messageID},
+			{"from": from},
+			{"emoji": emoji},
+		},
+	}
+
+	return st.Delete(ctx, query, service.REACTION_COLLECTION)
+}
+
+func (msgDatabase *MessageDB) GetReactionCounts(ctx context.Context, messageIDs []string) (*mongo.Cursor, error) {
+	st := msgDatabase.Storage
+
+	pipeline := []bson.M{
+		{"$match": bson.M{"messageid": bson.M{"$in": messageIDs}}},
+		{"$group": bson.M{
+			"_id":   bson.M{"messageid": "$messageid", "emoji": "$emoji"},
+			"count": bson.M{"$sum": 1},
+			"users": bson.M{"$push": "$from"},
+		}},
+		{"$project": bson.M{
+			"_id":       0,
+			"messageid": "$_id.messageid",
+			"emoji":     "$_id.emoji",
+			"count":     1,
+			"users":     1,
+		}},
+	}
+
+	return st.Aggregate(ctx, pipeline, service.REACTION_COLLECTION)
+}
+
+func (msgDatabase *MessageDB) CountNewReactionsBySender(ctx context.Context, from string, to string) int64 {
+	st := msgDatabase.Storage
+
+	query := bson.M{
+		"$and": []bson.M{
+			{"from": from},
+			{"to": to},
+			{"ischecked": false},
+		},
+	}
+
+	num, err := st.CountObjects(ctx, query, service.REACTION_COLLECTION)
+
+	if err != nil {
+		msgDatabase.Logger.Panicf("Error when counting, %v", err)
+	}
+
+	return num
+}
+
+func (msgDatabase *MessageDB) SetReactionCheckMark(ctx context.Context, from string, to string) error {
+	st := msgDatabase.Storage
+
+	query := bson.M{
+		"$and": []bson.M{
+			{"from": from},
+			{"to": to},
+			{"ischecked": false},
+		},
+	}
+
+	model := bson.M{
+		"ischecked": true,
+	}
+
+	return st.UpdateMany(ctx, query, model, service.REACTION_COLLECTION)
+}
+
+func (msgDatabase *MessageDB) AddScheduledMessage(ctx context.Context, message service.ScheduledMessage) (string, error) {
+	st := msgDatabase.Storage
+
+	return st.CreateObject(ctx, message, service.SCHEDULED_COLLECTION)
+}
+
+func (msgDatabase *MessageDB) GetPendingScheduledMessages(ctx context.Context, from string) (*mongo.Cursor, error) {
+	st := msgDatabase.Storage
+
+	query := bson.M{
+		"$and": []bson.M{
+			{"from": from},
+			{"status": scheduledPending},
+		},
+	}
+
+	findOpts := options.FindOptions{}
+	findOpts.SetSort(bson.D{{Key: "sendat", Value: 1}})
+
+	return st.FindObjects(ctx, query, service.SCHEDULED_COLLECTION, &findOpts)
+}
+
+func (msgDatabase *MessageDB) GetPendingScheduledMessage(ctx context.Context, messageID string, from string) (*mongo.SingleResult, error) {
+	st := msgDatabase.Storage
+	query, err := pendingScheduledQuery(messageID, from)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return st.FindOneObject(ctx, query, service.SCHEDULED_COLLECTION)
+}
+
+func (msgDatabase *MessageDB) UpdatePendingScheduledMessage(ctx context.Context, messageID string, from string, model bson.M) error {
+	st := msgDatabase.Storage
+	query, err := pendingScheduledQuery(messageID, from)
+
+	if err != nil {
+		return err
+	}
+
+	return st.Update(ctx, query, model, service.SCHEDULED_COLLECTION)
+}
+
+func (msgDatabase *MessageDB) ClaimDueScheduledMessage(ctx context.Context, instanceID string, lease time.Duration) (*mongo.SingleResult, error) {
+	st := msgDatabase.Storage
+	now := time.Now()
+
+	query := bson.M{
+		"$or": []bson.M{
+			{"$and": []bson.M{
+				{"status": scheduledPending},
+				{"sendat": bson.M{"$lte": now}},
+			}},
+
+			{"$and": []bson.M{
+				{"status": scheduledSending},
+				{"lockeduntil": bson.M{"$lt": now}},
+			}},
+		},
+	}
+
+	model := bson.M{
+		"status":      scheduledSending,
+		"lockedby":    instanceID,
+		"lockeduntil": now.Add(lease),
+	}
+
+	return st.FindOneAndUpdate(ctx, query, model, service.SCHEDULED_COLLECTION)
+}
+
+// DeliverScheduledMessage stores the message under the id of the scheduled one,
+// so a repeated delivery of the same scheduled message fails on the duplicate key
+func (msgDatabase *MessageDB) DeliverScheduledMessage(ctx context.Context, message *service.ScheduledMessage) error {
+	st := msgDatabase.Storage
+	objMessageID, err := primitive.ObjectIDFromHex(message.ID)
+
+	if err != nil {
+		return err
+	}
+
+	model := bson.M{
+		"_id":       objMessageID,
+		"from":      message.From,
+		"to":        message.To,
+		"text":      message.Text,
+		"date":      time.Now(),
+		"ischecked": false,
+	}
+
+	if message.ReplyTo != "" {
+		model["replyto"] = message.ReplyTo
+	}
+
+	_, err = st.CreateObject(ctx, model, service.MESSAGE_COLLECTION)
+
+	if mongo.IsDuplicateKeyError(err) {
+		return nil
+	}
+
+	return err
+}
+
+func (msgDatabase *MessageDB) SetScheduledStatus(ctx context.Context, messageID string, instanceID string, status string) error {
+	st := msgDatabase.Storage
+	objMessageID, err := primitive.ObjectIDFromHex(messageID)
+
+	if err != nil {
+		return err
+	}
+
+	query := bson.M{
+		"$and": []bson.M{
+			{"_id": objMessageID},
+			{"lockedby": instanceID},
+		},
+	}
+
+	model := bson.M{
+		"status": status,
+	}
+
+	return st.Update(ctx, query, model, service.SCHEDULED_COLLECTION)
+}
+
+func (msgDatabase *MessageDB) GetConversationSettings(ctx context.Context, username string) (*mongo.Cursor, error) {
+	st := msgDatabase.Storage
+	query := bson.M{"username": username}
+
+	return st.FindObjects(ctx, query, service.CONVERSATION_COLLECTION)
+}
+
+func (msgDatabase *MessageDB) SetConversationSettings(ctx context.Context, username string, friend string, model bson.M) error {
+	st := msgDatabase.Storage
+
+	query := bson.M{
+		"username": username,
+		"friend":   friend,
+	}
+
+	return st.Upsert(ctx, query, model, service.CONVERSATION_COLLECTION)
+}
+
+// SetPollVote replaces the voter's choice in one update, the unique index
+// turns a race of two first votes into a duplicate key, then the vote is
+// written again over the document the other request created
+func (msgDatabase *MessageDB) SetPollVote(ctx context.Context, vote *service.PollVote) error {
+	st := msgDatabase.Storage
+
+	query := bson.M{
+		"messageid": vote.MessageID,
+		"voter":     vote.Voter,
+	}
+
+	err := st.Upsert(ctx, query, vote, service.POLL_VOTE_COLLECTION)
+
+	if mongo.IsDuplicateKeyError(err) {
+		err = st.Upsert(ctx, query, vote, service.POLL_VOTE_COLLECTION)
+	}
+
+	return err
+}
+
+func (msgDatabase *MessageDB) GetPollVotes(ctx context.Context, messageIDs []string) (*mongo.Cursor, error) {
+	st := msgDatabase.Storage
+
+	return st.FindObjects(ctx, bson.M{"messageid": bson.M{"$in": messageIDs}}, service.POLL_VOTE_COLLECTION)
+}
+
+func (msgDatabase *MessageDB) EnsurePollIndexes(ctx context.Context) error {
+	st := msgDatabase.Storage
+
+	return st.EnsureUniqueIndex(ctx, []string{"messageid", "voter"}, service.POLL_VOTE_COLLECTION)
+}
+
+// EnsureReactionIndexes allows one reaction per user and emoji, reactions
+// doubled by concurrent toggles before the index existed are removed first
+func (msgDatabase *MessageDB) EnsureReactionIndexes(ctx context.Context) error {
+	st := msgDatabase.Storage
+	fields := []string{"messageid", "from", "emoji"}
+
+	err := st.EnsureUniqueIndex(ctx, fields, service.REACTION_COLLECTION)
+
+	if !mongo.IsDuplicateKeyError(err) {
+		return err
+	}
+
+	pipeline := []bson.M{
+		{"$group": bson.M{
+			"_id":   bson.M{"messageid": "$messageid", "from": "$from", "emoji": "$emoji"},
+			"ids":   bson.M{"$push": "$_id"},
+			"count": bson.M{"$sum": 1},
+		}},
+		{"$match": bson.M{"count": bson.M{"$gt": 1}}},
+	}
+
+	cursor, err := st.Aggregate(ctx, pipeline, service.REACTION_COLLECTION)
+
+	if err != nil {
+		return err
+	}
+
+	duplicates := []struct {
+		IDs []primitive.ObjectID `bson:"ids"`
+	}{}
+
+	if err = cursor.All(ctx, &duplicates); err != nil {
+		return err
+	}
+
+	for _, duplicate := range duplicates {
+		query := bson.M{"_id": bson.M{"$in": duplicate.IDs[1:]}}
+
+		if err = st.DeleteMany(ctx, query, service.REACTION_COLLECTION); err != nil {
+			return err
+		}
+	}
+
+	return st.EnsureUniqueIndex(ctx, fields, service.REACTION_COLLECTION)
+}
+
+// MigrateUserIDs rewrites usernames stored in messages, reactions, scheduled
+// messages and conversation settings into user ids
+func (msgDatabase *MessageDB) MigrateUserIDs(ctx context.Context, userIDs map[string]string) error {
+	st := msgDatabase.Storage
+
+	for collection, fields := range messageUserFields {
+		for _, field := range fields {
+			for username, userID := range userIDs {
+				query := bson.M{field: username}
+
+				if err := st.UpdateMany(ctx, query, bson.M{field: userID}, collection); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func pendingScheduledQuery(messageID string, from string) (bson.M, error) {
+	objMessageID, err := primitive.ObjectIDFromHex(messageID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	query := bson.M{
+		"$and": []bson.M{
+			{"_id": objMessageID},
+			{"from": from},
+			{"status": scheduledPending},
+		},
+	}
+
+	return query, nil
+}
+
+func dialogQuery(from string, to string) bson.M {
+	return bson.M{
+		"$or": []bson.M{
+			{"$and": []bson.M{
+				{"from": from},
+				{"to": to},
+			}},
+
+			{"$and": []bson.M{
+				{"from": to},
+				{"to": from},
+			}},
+		},
+	}
+}
@@ -0,0 +1,433 @@
+package messages
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/delonce/socialnetwork/internal/service"
+)
+
+const (
+	EXPORT_JSON = "json"
+	EXPORT_HTML = "html"
+	EXPORT_TEXT = "txt"
+)
+
+// exportBatchSize is the number of messages whose reactions and poll votes
+// are loaded in one query while the dialog is streamed
+const exportBatchSize = 100
+
+type exportMessage struct {
+	ID      string      `json:"id"`
+	From    string      `json:"from"`
+	To      string      `json:"to"`
+	Text    string      `json:"text"`
+	Date    string      `json:"date"`
+	Checked bool        `json:"ischecked"`
+	ReplyTo string      `json:"replyto,omitempty"`
+	Kind    string      `json:"kind,omitempty"`
+	Poll    *exportPoll `json:"poll,omitempty"`
+
+	Reactions []exportReaction `json:"reactions,omitempty"`
+}
+
+type exportReaction struct {
+	Emoji string `json:"emoji"`
+	Count int64  `json:"count"`
+}
+
+type exportPoll struct {
+	Options    []exportPollOption `json:"options"`
+	IsMultiple bool               `json:"ismultiple"`
+	IsClosed   bool               `json:"isclosed"`
+	ClosesAt   string             `json:"closesat,omitempty"`
+	Voters     int64              `json:"voters"`
+}
+
+type exportPollOption struct {
+	Text  string `json:"text"`
+	Votes int64  `json:"votes"`
+}
+
+type dialogWriter interface {
+	WriteHeader(from string, to string, exportedAt time.Time) error
+	WriteMessage(message exportMessage) error
+	WriteFooter() error
+}
+
+func ExportContentType(format string) string {
+	switch format {
+	case EXPORT_JSON:
+		return "application/json; charset=utf-8"
+	case EXPORT_HTML:
+		return "text/html; charset=utf-8"
+	default:
+		return "text/plain; charset=utf-8"
+	}
+}
+
+func IsExportFormat(format string) bool {
+	return format == EXPORT_JSON || format == EXPORT_HTML || format == EXPORT_TEXT
+}
+
+// ExportDialog writes the dialog message by message straight from the cursor,
+// so the whole history is never held in memory
+func (msgView *MessageViewService) ExportDialog(w io.Writer, from string, to string, format string, location *time.Location) error {
+	writer, err := newDialogWriter(w, format)
+
+	if err != nil {
+		return err
+	}
+
+	fromID, toID, err := msgView.getPairIDs(from, to)
+
+	if err != nil {
+		return err
+	}
+
+	usernames := map[string]string{fromID: from, toID: to}
+	cursor, err := msgView.msgDatabase.GetAllDialogMessages(msgView.context, fromID, toID)
+
+	if err != nil {
+		return err
+	}
+
+	defer cursor.Close(context.TODO())
+
+	if err = writer.WriteHeader(from, to, time.Now().In(location)); err != nil {
+		return err
+	}
+
+	batch := []service.Message{}
+
+	for cursor.Next(msgView.context) {
+		message := service.Message{}
+
+		if err = cursor.Decode(&message); err != nil {
+			msgView.logger.Errorf("Error while decoding message when exporting dialog %s - %s", from, to)
+			return err
+		}
+
+		batch = append(batch, message)
+
+		if len(batch) < exportBatchSize {
+			continue
+		}
+
+		if err = msgView.writeExportBatch(writer, batch, usernames, location); err != nil {
+			return err
+		}
+
+		batch = []service.Message{}
+	}
+
+	if err = cursor.Err(); err != nil {
+		return err
+	}
+
+	if err = msgView.writeExportBatch(writer, batch, usernames, location); err != nil {
+		return err
+	}
+
+	return writer.WriteFooter()
+}
+
+func (msgView *MessageViewService) writeExportBatch(writer dialogWriter, batch []service.Message,
+	usernames map[string]string, location *time.Location) error {
+
+	pollVotes := msgView.getPollVotes(batch)
+	reactions := msgView.getMessageReactions(batch, "")
+
+	for _, message := range batch {
+		exported := exportMessage{
+			ID:      message.ID,
+			From:    usernames[message.From],
+			To:      usernames[message.To],
+			Text:    message.Text,
+			Date:    message.DateAt.In(location).Format(time.RFC3339),
+			Checked: message.IsChecked,
+			ReplyTo: message.ReplyTo,
+			Kind:    message.Kind,
+		}
+
+		if message.Kind == MESSAGE_KIND_POLL && message.Poll != nil {
+			exported.Poll = newExportPoll(message.Poll, pollVotes[message.ID], location)
+		}
+
+		for _, reaction := range reactions[message.ID] {
+			exported.Reactions = append(exported.Reactions, exportReaction{
+				Emoji: reaction.Emoji,
+				Count: reaction.Count,
+			})
+		}
+
+		if err := writer.WriteMessage(exported); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// newExportPoll uses the tally of the dialog page, the export belongs to no
+// voter, so chosen options are not marked
+func newExportPoll(poll *service.MessagePoll, votes []service.PollVote, location *time.Location) *exportPoll {
+	viewPoll := newViewPoll(poll, votes, "")
+
+	exported := &exportPoll{
+		Options:    []exportPollOption{},
+		IsMultiple: viewPoll.IsMultiple,
+		IsClosed:   viewPoll.IsClosed,
+		Voters:     viewPoll.Voters,
+	}
+
+	if poll.ClosesAt != nil {
+		exported.ClosesAt = poll.ClosesAt.In(location).Format(time.RFC3339)
+	}
+
+	for _, option := range viewPoll.Options {
+		exported.Options = append(exported.Options, exportPollOption{
+			Text:  option.Text,
+			Votes: option.Votes,
+		})
+	}
+
+	return exported
+}
+
+func newDialogWriter(w io.Writer, format string) (dialogWriter, error) {
+	switch format {
+	case EXPORT_JSON:
+		return &jsonDialogWriter{w: w, encoder: json.NewEncoder(w)}, nil
+	case EXPORT_HTML:
+		return &htmlDialogWriter{w: w}, nil
+	case EXPORT_TEXT:
+		return &textDialogWriter{w: w}, nil
+	}
+
+	return nil, fmt.Errorf("Unknown export format %s", format)
+}
+
+type jsonDialogWriter struct {
+	w       io.Writer
+	encoder *json.Encoder
+	written int
+}
+
+func (writer *jsonDialogWriter) WriteHeader(from string, to string, exportedAt time.Time) error {
+	_, err := fmt.Fprintf(writer.w, "{\"from\":%q,\"to\":%q,\"exportedAt\":%q,\"messages\":[\n",
+		from, to, exportedAt.Format(time.RFC3339))
+
+	return err
+}
+
+func (writer *jsonDialogWriter) WriteMessage(message exportMessage) error {
+	if writer.written > 0 {
+		if _, err := io.WriteString(writer.w, ","); err != nil {
+			return err
+		}
+	}
+
+	writer.written++
+
+	return writer.encoder.Encode(message)
+}
+
+func (writer *jsonDialogWriter) WriteFooter() error {
+	_, err := io.WriteString(writer.w, "]}\n")
+
+	return err
+}
+
+type htmlDialogWriter struct {
+	w io.Writer
+}
+
+func (writer *htmlDialogWriter) WriteHeader(from string, to string, exportedAt time.Time) error {
+	title := html.EscapeString(fmt.Sprintf("Диалог %s - %s", from, to))
+
+	_, err := fmt.Fprintf(writer.w, `<!DOCTYPE html>
+<html>
+<head>
+	<meta charset="utf-8">
+	<title>%s</title>
+	<style>
+		body { font-family: sans-serif; max-width: 800px; margin: auto; }
+		.message { border-bottom: solid 1px lightgray; padding: 5px; }
+		.date { color: gray; }
+		.text { white-space: pre-wrap; }
+		.poll { color: dimgray; }
+	</style>
+</head>
+<body>
+<h1>%s</h1>
+<p class="date">Экспортировано %s</p>
+`, title, title, exportedAt.Format("2006-01-02 15:04 MST"))
+
+	return err
+}
+
+func (writer *htmlDialogWriter) WriteMessage(message exportMessage) error {
+	replyTo := ""
+
+	if message.ReplyTo != "" {
+		replyTo = fmt.Sprintf(`<p class="date">Ответ на <a href="#message-%s">сообщение</a></p>`, html.EscapeString(message.ReplyTo))
+	}
+
+	_, err := fmt.Fprintf(writer.w, `<div class="message" id="message-%s">
+	<p><b>%s</b> <span class="date">%s</span></p>
+	%s
+	<p class="text">%s</p>
+%s%s</div>
+`, html.EscapeString(message.ID), html.EscapeString(message.From), html.EscapeString(message.Date),
+		replyTo, html.EscapeString(message.Text), htmlPoll(message.Poll), htmlReactions(message.Reactions))
+
+	return err
+}
+
+func htmlReactions(reactions []exportReaction) string {
+	if len(reactions) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("\t<p class=\"date\">Реакции: %s</p>\n", html.EscapeString(reactionSummary(reactions)))
+}
+
+func htmlPoll(poll *exportPoll) string {
+	if poll == nil {
+		return ""
+	}
+
+	var builder strings.Builder
+
+	builder.WriteString("\t<ul class=\"poll\">\n")
+
+	for _, option := range poll.Options {
+		fmt.Fprintf(&builder, "\t\t<li>%s: %d</li>\n", html.EscapeString(option.Text), option.Votes)
+	}
+
+	builder.WriteString("\t</ul>\n")
+	summary := fmt.Sprintf("Проголосовали: %d", poll.Voters)
+
+	if poll.IsMultiple {
+		summary += ", можно выбрать несколько вариантов"
+	}
+
+	if poll.IsClosed {
+		summary += ", опрос закрыт " + poll.ClosesAt
+	} else if poll.ClosesAt != "" {
+		summary += ", опрос закроется " + poll.ClosesAt
+	}
+
+	fmt.Fprintf(&builder, "\t<p class=\"poll\">%s</p>\n", html.EscapeString(summary))
+
+	return builder.String()
+}
+
+func (writer *htmlDialogWriter) WriteFooter() error {
+	_, err := io.WriteString(writer.w, "</body>\n</html>\n")
+
+	return err
+}
+
+// textDialogWriter produces an mbox-like transcript: every message starts with
+// a "From " separator line and body lines beginning with "From " are quoted
+type textDialogWriter struct {
+	w io.Writer
+}
+
+func (writer *textDialogWriter) WriteHeader(from string, to string, exportedAt time.Time) error {
+	_, err := fmt.Fprintf(writer.w, "Dialog: %s - %s\nExported-At: %s\n\n", from, to, exportedAt.Format(time.RFC1123Z))
+
+	return err
+}
+
+func (writer *textDialogWriter) WriteMessage(message exportMessage) error {
+	var builder strings.Builder
+
+	date, err := time.Parse(time.RFC3339, message.Date)
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(&builder, "From %s %s\n", message.From, date.Format(time.ANSIC))
+	fmt.Fprintf(&builder, "Message-Id: <%s>\n", message.ID)
+	fmt.Fprintf(&builder, "Date: %s\n", date.Format(time.RFC1123Z))
+	fmt.Fprintf(&builder, "From: %s\n", message.From)
+	fmt.Fprintf(&builder, "To: %s\n", message.To)
+
+	if message.ReplyTo != "" {
+		fmt.Fprintf(&builder, "In-Reply-To: <%s>\n", message.ReplyTo)
+	}
+
+	if len(message.Reactions) > 0 {
+		fmt.Fprintf(&builder, "Reactions: %s\n", reactionSummary(message.Reactions))
+	}
+
+	builder.WriteString("\n")
+
+	for _, line := range strings.Split(message.Text, "\n") {
+		if strings.HasPrefix(strings.TrimLeft(line, ">"), "From ") {
+			line = ">" + line
+		}
+
+		builder.WriteString(line + "\n")
+	}
+
+	if message.Poll != nil {
+		builder.WriteString("\n")
+
+		for _, option := range message.Poll.Options {
+			fmt.Fprintf(&builder, "[%d] %s\n", option.Votes, option.Text)
+		}
+
+		builder.WriteString(pollSummary(message.Poll) + "\n")
+	}
+
+	builder.WriteString("\n")
+
+	_, err = io.WriteString(writer.w, builder.String())
+
+	return err
+}
+
+func (writer *textDialogWriter) WriteFooter() error {
+	return nil
+}
+
+// pollSummary describes the poll settings in the text export
+func pollSummary(poll *exportPoll) string {
+	parts := []string{fmt.Sprintf("Voters: %d", poll.Voters)}
+
+	if poll.IsMultiple {
+		parts = append(parts, "multiple choice")
+	}
+
+	if poll.ClosesAt != "" {
+		state := "closes at"
+
+		if poll.IsClosed {
+			state = "closed at"
+		}
+
+		parts = append(parts, state+" "+poll.ClosesAt)
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+func reactionSummary(reactions []exportReaction) string {
+	parts := []string{}
+
+	for _, reaction := range reactions {
+		parts = append(parts, fmt.Sprintf("%s %d", reaction.Emoji, reaction.Count))
+	}
+
+	return strings.Join(parts, ", ")
+}
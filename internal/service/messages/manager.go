@@ -2,15 +2,24 @@ package messages
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/delonce/socialnetwork/internal/config"
+	"github.com/delonce/socialnetwork/internal/database"
 	"github.com/delonce/socialnetwork/internal/service"
+	"github.com/delonce/socialnetwork/internal/service/access"
+	"github.com/delonce/socialnetwork/internal/service/user"
 	"github.com/delonce/socialnetwork/pkg/logging"
+
+	"go.mongodb.org/mongo-driver/bson"
 )
 
 type MessageManagerService struct {
 	messageDatabase MessageQueries
+	userResolver    user.Resolver
+	policy          access.Policy
 	logger          *logging.Logger
 	context         context.Context
 }
@@ -23,24 +32,342 @@ func NewMessageManager(logger *logging.Logger, config *config.Config) (MessageMa
 		return nil, err
 	}
 
+	policy, err := access.NewPolicy(logger, config)
+
+	if err != nil {
+		return nil, err
+	}
+
 	return &MessageManagerService{
 		messageDatabase: NewMessageDB(logger, database),
+		userResolver:    user.NewResolver(logger, database),
+		policy:          policy,
 		logger:          logger,
 	}, nil
 }
 
-func (msgManager *MessageManagerService) SendMessage(from string, to string, message string) error {
+func (msgManager *MessageManagerService) SendMessage(from string, to string, message string, replyTo string) error {
+	if err := msgManager.policy.Authorize(from, access.ActionSendMessage, to); err != nil {
+		return err
+	}
+
+	fromID, toID, err := msgManager.getPairIDs(from, to)
+
+	if err != nil {
+		return err
+	}
+
+	if replyTo != "" {
+		_, err := msgManager.getDialogMessage(fromID, toID, replyTo)
+
+		if err != nil {
+			return fmt.Errorf("Can't reply to message %s, %v", replyTo, err)
+		}
+	}
+
 	newMessage := service.Message{
-		From:      from,
-		To:        to,
+		From:      fromID,
+		To:        toID,
 		Text:      message,
 		DateAt:    time.Now(),
 		IsChecked: false,
+		ReplyTo:   replyTo,
+	}
+
+	return msgManager.messageDatabase.AddNewMessage(msgManager.context, newMessage)
+}
+
+// SendPoll stores the question as the message text, so dialog lists, quotes
+// and exports show it like any other message
+func (msgManager *MessageManagerService) SendPoll(from string, to string, question string, options []string,
+	isMultiple bool, closesAt *time.Time) error {
+
+	if err := msgManager.policy.Authorize(from, access.ActionSendMessage, to); err != nil {
+		return err
+	}
+
+	question, poll, err := newMessagePoll(question, options, isMultiple, closesAt)
+
+	if err != nil {
+		return err
+	}
+
+	fromID, toID, err := msgManager.getPairIDs(from, to)
+
+	if err != nil {
+		return err
+	}
+
+	newMessage := service.Message{
+		From:      fromID,
+		To:        toID,
+		Text:      question,
+		DateAt:    time.Now(),
+		IsChecked: false,
+		Kind:      MESSAGE_KIND_POLL,
+		Poll:      poll,
 	}
 
 	return msgManager.messageDatabase.AddNewMessage(msgManager.context, newMessage)
 }
 
-func (msgManager *MessageManagerService) CheckMessage(user string, friend string) error {
-	return msgManager.messageDatabase.SetCheckMark(msgManager.context, friend, user)
+// VotePoll replaces the previous vote of the user, tallies are counted from
+// votes when the dialog is shown
+func (msgManager *MessageManagerService) VotePoll(username string, friend string, messageID string, options []int) error {
+	if err := msgManager.policy.Authorize(username, access.ActionVotePoll, friend); err != nil {
+		return err
+	}
+
+	userID, friendID, err := msgManager.getPairIDs(username, friend)
+
+	if err != nil {
+		return err
+	}
+
+	message, err := msgManager.getDialogMessage(userID, friendID, messageID)
+
+	if err != nil {
+		return err
+	}
+
+	if message.Kind != MESSAGE_KIND_POLL || message.Poll == nil {
+		return ErrNotPoll
+	}
+
+	if isPollClosed(message.Poll, time.Now()) {
+		return ErrPollClosed
+	}
+
+	chosen, err := checkVote(message.Poll, options)
+
+	if err != nil {
+		return err
+	}
+
+	return msgManager.messageDatabase.SetPollVote(msgManager.context, &service.PollVote{
+		MessageID: messageID,
+		Voter:     userID,
+		Options:   chosen,
+		DateAt:    time.Now(),
+	})
+}
+
+func (msgManager *MessageManagerService) ScheduleMessage(from string, to string, message string, replyTo string, sendAt time.Time) error {
+	if !sendAt.After(time.Now()) {
+		return fmt.Errorf("Send time %s is not in the future", sendAt)
+	}
+
+	if err := msgManager.policy.Authorize(from, access.ActionSendMessage, to); err != nil {
+		return err
+	}
+
+	fromID, toID, err := msgManager.getPairIDs(from, to)
+
+	if err != nil {
+		return err
+	}
+
+	if replyTo != "" {
+		_, err := msgManager.getDialogMessage(fromID, toID, replyTo)
+
+		if err != nil {
+			return fmt.Errorf("Can't reply to message %s, %v", replyTo, err)
+		}
+	}
+
+	newMessage := service.ScheduledMessage{
+		From:      fromID,
+		To:        toID,
+		Text:      message,
+		ReplyTo:   replyTo,
+		SendAt:    sendAt,
+		TimeZone:  sendAt.Location().String(),
+		CreatedAt: time.Now(),
+		Status:    scheduledPending,
+	}
+
+	_, err = msgManager.messageDatabase.AddScheduledMessage(msgManager.context, newMessage)
+
+	return err
+}
+
+func (msgManager *MessageManagerService) EditScheduledMessage(username string, messageID string, message string, sendAt time.Time) error {
+	if !sendAt.After(time.Now()) {
+		return fmt.Errorf("Send time %s is not in the future", sendAt)
+	}
+
+	userID, err := msgManager.authorizeScheduled(username, messageID, access.ActionSendMessage)
+
+	if err != nil {
+		return err
+	}
+
+	model := bson.M{
+		"text":     message,
+		"sendat":   sendAt,
+		"timezone": sendAt.Location().String(),
+	}
+
+	return msgManager.messageDatabase.UpdatePendingScheduledMessage(msgManager.context, messageID, userID, model)
+}
+
+func (msgManager *MessageManagerService) CancelScheduledMessage(username string, messageID string) error {
+	userID, err := msgManager.authorizeScheduled(username, messageID, access.ActionViewDialog)
+
+	if err != nil {
+		return err
+	}
+
+	model := bson.M{
+		"status": scheduledCancelled,
+	}
+
+	return msgManager.messageDatabase.UpdatePendingScheduledMessage(msgManager.context, messageID, userID, model)
+}
+
+// authorizeScheduled checks the action against the receiver of the user's
+// pending scheduled message and returns the id of the user
+func (msgManager *MessageManagerService) authorizeScheduled(username string, messageID string, action string) (string, error) {
+	userID, err := msgManager.userResolver.GetUserID(username)
+
+	if err != nil {
+		return "", err
+	}
+
+	result, err := msgManager.messageDatabase.GetPendingScheduledMessage(msgManager.context, messageID, userID)
+
+	if err != nil {
+		return "", ErrScheduledNotFound
+	}
+
+	scheduled := service.ScheduledMessage{}
+
+	if err = result.Decode(&scheduled); err != nil {
+		return "", err
+	}
+
+	receiver, ok := msgManager.userResolver.GetUsernames([]string{scheduled.To})[scheduled.To]
+
+	if !ok {
+		return "", ErrScheduledNotFound
+	}
+
+	if err = msgManager.policy.Authorize(username, action, receiver); err != nil {
+		return "", err
+	}
+
+	return userID, nil
+}
+
+func (msgManager *MessageManagerService) CheckMessage(username string, friend string) error {
+	userID, friendID, err := msgManager.getPairIDs(username, friend)
+
+	if err != nil {
+		return err
+	}
+
+	err = msgManager.messageDatabase.SetReactionCheckMark(msgManager.context, friendID, userID)
+
+	if err != nil {
+		msgManager.logger.Errorf("Error when checking reactions from %s to %s, %v", friend, username, err)
+	}
+
+	return msgManager.messageDatabase.SetCheckMark(msgManager.context, friendID, userID)
+}
+
+func (msgManager *MessageManagerService) SetDialogPinned(user string, friend string, isPinned bool) error {
+	model := bson.M{
+		"ispinned": isPinned,
+	}
+
+	return msgManager.setConversationSettings(user, friend, model)
+}
+
+// SetDialogArchived remembers the archiving time, a message newer than it
+// brings the dialog back to the main list
+func (msgManager *MessageManagerService) SetDialogArchived(user string, friend string, isArchived bool) error {
+	model := bson.M{
+		"isarchived": isArchived,
+		"archivedat": time.Now(),
+	}
+
+	return msgManager.setConversationSettings(user, friend, model)
+}
+
+func (msgManager *MessageManagerService) SetDialogMuted(user string, friend string, isMuted bool) error {
+	model := bson.M{
+		"ismuted": isMuted,
+	}
+
+	return msgManager.setConversationSettings(user, friend, model)
+}
+
+func (msgManager *MessageManagerService) ToggleReaction(username string, friend string, messageID string, emoji string) error {
+	if !IsAllowedReaction(emoji) {
+		return fmt.Errorf("Reaction %s is not allowed", emoji)
+	}
+
+	if err := msgManager.policy.Authorize(username, access.ActionReactMessage, friend); err != nil {
+		return err
+	}
+
+	userID, friendID, err := msgManager.getPairIDs(username, friend)
+
+	if err != nil {
+		return err
+	}
+
+	message, err := msgManager.getDialogMessage(userID, friendID, messageID)
+
+	if err != nil {
+		return err
+	}
+
+	mDb := msgManager.messageDatabase
+
+	newReaction := service.MessageReaction{
+		MessageID: messageID,
+		From:      userID,
+		To:        message.From,
+		Emoji:     emoji,
+		DateAt:    time.Now(),
+		IsChecked: message.From == userID,
+	}
+
+	isAdded, err := mDb.AddReaction(msgManager.context, newReaction)
+
+	if err != nil || isAdded {
+		return err
+	}
+
+	err = mDb.DeleteReaction(msgManager.context, messageID, userID, emoji)
+
+	if errors.Is(err, database.ErrNotFound) {
+		// a concurrent request has taken the reaction back already
+		return nil
+	}
+
+	if err != nil {
+		msgManager.logger.Errorf("Failed to take back reaction of %s to %s, %v", username, messageID, err)
+	}
+
+	return err
+}
+
+func (msgManager *MessageManagerService) setConversationSettings(username string, friend string, model bson.M) error {
+	userID, friendID, err := msgManager.getPairIDs(username, friend)
+
+	if err != nil {
+		return err
+	}
+
+	return msgManager.messageDatabase.SetConversationSettings(msgManager.context, userID, friendID, model)
+}
+
+func (msgManager *MessageManagerService) getDialogMessage(userID string, friendID string, messageID string) (*service.Message, error) {
+	return findDialogMessage(msgManager.context, msgManager.messageDatabase, msgManager.logger, userID, friendID, messageID)
+}
+
+func (msgManager *MessageManagerService) getPairIDs(first string, second string) (string, string, error) {
+	return resolvePairIDs(msgManager.userResolver, first, second)
 }
@@ -0,0 +1,42 @@
+package messages
+
+import (
+	"context"
+
+	"github.com/delonce/socialnetwork/internal/config"
+	"github.com/delonce/socialnetwork/internal/service"
+	"github.com/delonce/socialnetwork/pkg/logging"
+)
+
+func MigrateMessageUserIDs(logger *logging.Logger, config *config.Config, userIDs map[string]string) error {
+	database, err := service.InitNewDatabase(config)
+
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return NewMessageDB(logger, database).MigrateUserIDs(context.Background(), userIDs)
+}
+
+func MigratePollIndexes(logger *logging.Logger, config *config.Config) error {
+	database, err := service.InitNewDatabase(config)
+
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return NewMessageDB(logger, database).EnsurePollIndexes(context.Background())
+}
+
+func MigrateReactionIndexes(logger *logging.Logger, config *config.Config) error {
+	database, err := service.InitNewDatabase(config)
+
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return NewMessageDB(logger, database).EnsureReactionIndexes(context.Background())
+}
@@ -0,0 +1,154 @@
+package messages
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/delonce/socialnetwork/internal/service"
+)
+
+const (
+	MESSAGE_KIND_POLL = "poll"
+
+	MinPollOptions        = 2
+	MaxPollOptions        = 10
+	MaxPollQuestionLength = 300
+	MaxPollOptionLength   = 100
+)
+
+var (
+	ErrEmptyPollQuestion   = errors.New("Poll question can't be empty")
+	ErrLongPollQuestion    = errors.New("Poll question is too long")
+	ErrFewPollOptions      = errors.New("Poll needs at least two options")
+	ErrManyPollOptions     = errors.New("Poll has too many options")
+	ErrLongPollOption      = errors.New("Poll option is too long")
+	ErrDuplicatePollOption = errors.New("Poll options must be different")
+	ErrPollCloseTime       = errors.New("Poll closing time is not in the future")
+	ErrNotPoll             = errors.New("Message is not a poll")
+	ErrPollClosed          = errors.New("Poll is closed")
+	ErrWrongVote           = errors.New("Wrong poll options chosen")
+)
+
+// newMessagePoll checks the poll and drops empty options, so a textarea
+// with blank lines can be sent as is
+func newMessagePoll(question string, options []string, isMultiple bool, closesAt *time.Time) (string, *service.MessagePoll, error) {
+	question = strings.TrimSpace(question)
+
+	if question == "" {
+		return "", nil, ErrEmptyPollQuestion
+	}
+
+	if utf8.RuneCountInString(question) > MaxPollQuestionLength {
+		return "", nil, ErrLongPollQuestion
+	}
+
+	pollOptions := []string{}
+	isKnown := map[string]bool{}
+
+	for _, option := range options {
+		option = strings.TrimSpace(option)
+
+		if option == "" {
+			continue
+		}
+
+		if utf8.RuneCountInString(option) > MaxPollOptionLength {
+			return "", nil, ErrLongPollOption
+		}
+
+		if isKnown[option] {
+			return "", nil, ErrDuplicatePollOption
+		}
+
+		isKnown[option] = true
+		pollOptions = append(pollOptions, option)
+	}
+
+	if len(pollOptions) < MinPollOptions {
+		return "", nil, ErrFewPollOptions
+	}
+
+	if len(pollOptions) > MaxPollOptions {
+		return "", nil, ErrManyPollOptions
+	}
+
+	if closesAt != nil && !closesAt.After(time.Now()) {
+		return "", nil, ErrPollCloseTime
+	}
+
+	return question, &service.MessagePoll{
+		Options:    pollOptions,
+		IsMultiple: isMultiple,
+		ClosesAt:   closesAt,
+	}, nil
+}
+
+// checkVote returns chosen options sorted and without repeats
+func checkVote(poll *service.MessagePoll, options []int) ([]int, error) {
+	chosen := []int{}
+	isChosen := map[int]bool{}
+
+	for _, option := range options {
+		if option < 0 || option >= len(poll.Options) {
+			return nil, ErrWrongVote
+		}
+
+		if !isChosen[option] {
+			isChosen[option] = true
+			chosen = append(chosen, option)
+		}
+	}
+
+	if len(chosen) == 0 || (!poll.IsMultiple && len(chosen) > 1) {
+		return nil, ErrWrongVote
+	}
+
+	sort.Ints(chosen)
+
+	return chosen, nil
+}
+
+func isPollClosed(poll *service.MessagePoll, now time.Time) bool {
+	return poll.ClosesAt != nil && !poll.ClosesAt.After(now)
+}
+
+// newViewPoll tallies votes of the poll, every voter document is counted
+// once for each chosen option
+func newViewPoll(poll *service.MessagePoll, votes []service.PollVote, userID string) *service.ViewPoll {
+	viewPoll := &service.ViewPoll{
+		Options:    []service.ViewPollOption{},
+		IsMultiple: poll.IsMultiple,
+		IsClosed:   isPollClosed(poll, time.Now()),
+		Voters:     int64(len(votes)),
+	}
+
+	if poll.ClosesAt != nil {
+		viewPoll.FormatClosesAt = poll.ClosesAt.Format("2006-01-02 15:04")
+	}
+
+	for index, option := range poll.Options {
+		viewPoll.Options = append(viewPoll.Options, service.ViewPollOption{
+			Index: index,
+			Text:  option,
+		})
+	}
+
+	for _, vote := range votes {
+		isMine := vote.Voter == userID
+		viewPoll.HasVoted = viewPoll.HasVoted || isMine
+
+		for _, option := range vote.Options {
+			if option < 0 || option >= len(viewPoll.Options) {
+				continue
+			}
+
+			viewPoll.Options[option].Votes++
+			viewPoll.Options[option].IsChosen = viewPoll.Options[option].IsChosen || isMine
+		}
+	}
+
+	return viewPoll
+}
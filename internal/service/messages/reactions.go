@@ -0,0 +1,65 @@
+package messages
+
+import (
+	"sort"
+
+	"github.com/delonce/socialnetwork/internal/service"
+)
+
+var AllowedReactions = []string{"👍", "❤️", "😂", "😮", "😢", "😡"}
+
+type reactionCount struct {
+	MessageID string   `bson:"messageid"`
+	Emoji     string   `bson:"emoji"`
+	Count     int64    `bson:"count"`
+	Users     []string `bson:"users"`
+}
+
+func IsAllowedReaction(emoji string) bool {
+	for _, allowed := range AllowedReactions {
+		if allowed == emoji {
+			return true
+		}
+	}
+
+	return false
+}
+
+func reactionOrder(emoji string) int {
+	for i, allowed := range AllowedReactions {
+		if allowed == emoji {
+			return i
+		}
+	}
+
+	return len(AllowedReactions)
+}
+
+func groupReactions(counts []reactionCount, username string) map[string][]service.ViewReaction {
+	grouped := map[string][]service.ViewReaction{}
+
+	for _, count := range counts {
+		isMine := false
+
+		for _, user := range count.Users {
+			if user == username {
+				isMine = true
+				break
+			}
+		}
+
+		grouped[count.MessageID] = append(grouped[count.MessageID], service.ViewReaction{
+			Emoji:  count.Emoji,
+			Count:  count.Count,
+			IsMine: isMine,
+		})
+	}
+
+	for _, reactions := range grouped {
+		sort.Slice(reactions, func(i, j int) bool {
+			return reactionOrder(reactions[i].Emoji) < reactionOrder(reactions[j].Emoji)
+		})
+	}
+
+	return grouped
+}
@@ -0,0 +1,80 @@
+package messages
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/delonce/socialnetwork/internal/service"
+	"github.com/delonce/socialnetwork/internal/service/user"
+	"github.com/delonce/socialnetwork/pkg/logging"
+)
+
+const (
+	DialogPageSize    int64 = 50
+	quotePreviewRunes       = 100
+)
+
+// resolvePairIDs looks up ids of both dialog participants in one query
+func resolvePairIDs(resolver user.Resolver, first string, second string) (string, string, error) {
+	userIDs, err := resolver.GetUserIDs([]string{first, second})
+
+	if err != nil {
+		return "", "", err
+	}
+
+	firstID, isFirstFound := userIDs[first]
+	secondID, isSecondFound := userIDs[second]
+
+	if !isFirstFound || !isSecondFound {
+		return "", "", user.ErrUserNotFound
+	}
+
+	return firstID, secondID, nil
+}
+
+func findDialogMessage(ctx context.Context, db MessageQueries, logger *logging.Logger,
+	userID string, friendID string, messageID string) (*service.Message, error) {
+
+	message := service.Message{}
+	result, err := db.GetMessageByID(ctx, messageID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = result.Decode(&message); err != nil {
+		logger.Errorf("Error while decoding message %s", messageID)
+		return nil, err
+	}
+
+	isDialogMessage := (message.From == userID && message.To == friendID) ||
+		(message.From == friendID && message.To == userID)
+
+	if !isDialogMessage {
+		return nil, fmt.Errorf("Message %s doesn't belong to dialog %s - %s", messageID, userID, friendID)
+	}
+
+	return &message, nil
+}
+
+func newViewQuote(parentID string, parent *service.Message, usernames map[string]string) *service.ViewQuote {
+	if parent == nil {
+		return &service.ViewQuote{
+			ID:        parentID,
+			IsDeleted: true,
+		}
+	}
+
+	preview := []rune(parent.Text)
+
+	if len(preview) > quotePreviewRunes {
+		preview = append(preview[:quotePreviewRunes], []rune("...")...)
+	}
+
+	return &service.ViewQuote{
+		ID:         parent.ID,
+		From:       usernames[parent.From],
+		Text:       string(preview),
+		FormatDate: parent.DateAt.Format("2006-01-02 15:04"),
+	}
+}
@@ -0,0 +1,140 @@
+package messages
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/delonce/socialnetwork/internal/config"
+	"github.com/delonce/socialnetwork/internal/service"
+	"github.com/delonce/socialnetwork/internal/service/access"
+	"github.com/delonce/socialnetwork/internal/service/user"
+	"github.com/delonce/socialnetwork/pkg/logging"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	scheduledPending   = "pending"
+	scheduledSending   = "sending"
+	scheduledSent      = "sent"
+	scheduledCancelled = "cancelled"
+
+	scheduledInputLayout = "2006-01-02T15:04"
+)
+
+var ErrScheduledNotFound = errors.New("Scheduled message not found")
+
+// scheduledLocation returns the zone the message was scheduled in, messages
+// scheduled before zones were stored use the zone of the server
+func scheduledLocation(message *service.ScheduledMessage) *time.Location {
+	if message.TimeZone == "" {
+		return time.Local
+	}
+
+	location, err := time.LoadLocation(message.TimeZone)
+
+	if err != nil {
+		return time.Local
+	}
+
+	return location
+}
+
+type MessageSchedulerService struct {
+	messageDatabase MessageQueries
+	userResolver    user.Resolver
+	policy          access.Policy
+	logger          *logging.Logger
+	context         context.Context
+	instanceID      string
+	lease           time.Duration
+}
+
+func NewMessageScheduler(logger *logging.Logger, config *config.Config) (MessageScheduler, error) {
+	database, err := service.InitNewDatabase(config)
+
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	policy, err := access.NewPolicy(logger, config)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &MessageSchedulerService{
+		messageDatabase: NewMessageDB(logger, database),
+		userResolver:    user.NewResolver(logger, database),
+		policy:          policy,
+		logger:          logger,
+		instanceID:      service.NewInstanceID(),
+		lease:           config.Scheduler.Lease,
+	}, nil
+}
+
+// DeliverDueMessages claims due scheduled messages one by one, so several
+// replicas running the scheduler never deliver the same message twice
+func (scheduler *MessageSchedulerService) DeliverDueMessages() (int, error) {
+	mDb := scheduler.messageDatabase
+	delivered := 0
+
+	for {
+		result, err := mDb.ClaimDueScheduledMessage(scheduler.context, scheduler.instanceID, scheduler.lease)
+
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return delivered, nil
+		}
+
+		if err != nil {
+			return delivered, err
+		}
+
+		message := service.ScheduledMessage{}
+
+		if err = result.Decode(&message); err != nil {
+			scheduler.logger.Errorf("Error while decoding scheduled message, %v", err)
+			return delivered, err
+		}
+
+		usernames := scheduler.userResolver.GetUsernames([]string{message.From, message.To})
+
+		err = scheduler.policy.Authorize(usernames[message.From], access.ActionSendMessage, usernames[message.To])
+
+		if err != nil {
+			scheduler.logger.Infof("Scheduled message %s cancelled, %v", message.ID, err)
+
+			if err = mDb.SetScheduledStatus(scheduler.context, message.ID, scheduler.instanceID, scheduledCancelled); err != nil {
+				scheduler.logger.Errorf("Error when cancelling scheduled message %s, %v", message.ID, err)
+			}
+
+			continue
+		}
+
+		if err = mDb.DeliverScheduledMessage(scheduler.context, &message); err != nil {
+			scheduler.logger.Errorf("Error when delivering scheduled message %s, %v", message.ID, err)
+			return delivered, err
+		}
+
+		if err = mDb.SetScheduledStatus(scheduler.context, message.ID, scheduler.instanceID, scheduledSent); err != nil {
+			scheduler.logger.Errorf("Error when marking scheduled message %s as sent, %v", message.ID, err)
+		}
+
+		delivered++
+	}
+}
+
+// ParseSendTime reads the time of a datetime-local input in the zone of the
+// user who filled it in
+func ParseSendTime(value string, location *time.Location) (time.Time, error) {
+	sendAt, err := time.ParseInLocation(scheduledInputLayout, value, location)
+
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Wrong send time %s", value)
+	}
+
+	return sendAt, nil
+}
@@ -1,18 +1,43 @@
 package messages
 
 import (
+	"io"
+	"time"
+
 	"github.com/delonce/socialnetwork/internal/config"
 	"github.com/delonce/socialnetwork/internal/service"
 )
 
 type MessageManager interface {
-	SendMessage(from string, to string, message string) error
+	SendMessage(from string, to string, message string, replyTo string) error
+	SendPoll(from string, to string, question string, options []string, isMultiple bool, closesAt *time.Time) error
+	VotePoll(user string, friend string, messageID string, options []int) error
 	CheckMessage(user string, friend string) error
+
+	ToggleReaction(user string, friend string, messageID string, emoji string) error
+
+	ScheduleMessage(from string, to string, message string, replyTo string, sendAt time.Time) error
+	EditScheduledMessage(user string, messageID string, message string, sendAt time.Time) error
+	CancelScheduledMessage(user string, messageID string) error
+
+	SetDialogPinned(user string, friend string, isPinned bool) error
+	SetDialogArchived(user string, friend string, isArchived bool) error
+	SetDialogMuted(user string, friend string, isMuted bool) error
 }
 
 type MessageViewer interface {
 	GetOneDialog(from string, to string) []service.ViewMessage
-	GetAllDialogs(config *config.Config, username string) []*service.ViewDialog
+	GetDialogPage(from string, to string, page int64) *service.ViewDialogPage
+	FindMessagePage(from string, to string, messageID string) (int64, error)
+	GetAllDialogs(config *config.Config, username string, isArchived bool) []*service.ViewDialog
 
 	CountNewMessages(username string) int64
+
+	GetScheduledMessages(username string) []service.ViewScheduledMessage
+
+	ExportDialog(w io.Writer, from string, to string, format string, location *time.Location) error
+}
+
+type MessageScheduler interface {
+	DeliverDueMessages() (int, error)
 }
@@ -7,13 +7,17 @@ import (
 	"github.com/delonce/socialnetwork/internal/config"
 	"github.com/delonce/socialnetwork/internal/service"
 	"github.com/delonce/socialnetwork/internal/service/friends"
+	"github.com/delonce/socialnetwork/internal/service/markup"
+	"github.com/delonce/socialnetwork/internal/service/user"
 	"github.com/delonce/socialnetwork/pkg/logging"
 )
 
 type MessageViewService struct {
-	msgDatabase MessageQueries
-	logger      *logging.Logger
-	context     context.Context
+	msgDatabase  MessageQueries
+	userResolver user.Resolver
+	renderer     markup.Renderer
+	logger       *logging.Logger
+	context      context.Context
 }
 
 func NewMessageViewer(logger *logging.Logger, config *config.Config) (MessageViewer, error) {
@@ -24,48 +28,355 @@ func NewMessageViewer(logger *logging.Logger, config *config.Config) (MessageVie
 		return nil, err
 	}
 
+	userResolver := user.NewResolver(logger, database)
+
 	return &MessageViewService{
-		msgDatabase: NewMessageDB(logger, database),
-		logger:      logger,
+		msgDatabase:  NewMessageDB(logger, database),
+		userResolver: userResolver,
+		renderer:     markup.NewRenderer(logger, userResolver),
+		logger:       logger,
 	}, nil
 }
 
 func (msgView *MessageViewService) GetOneDialog(from string, to string) []service.ViewMessage {
-	cursor, err := msgView.msgDatabase.GetAllDialogMessages(msgView.context, from, to)
+	fromID, toID, err := msgView.getPairIDs(from, to)
+
+	if err != nil {
+		msgView.logger.Errorf("Error when resolving dialog %s - %s, %v", from, to, err)
+		return []service.ViewMessage{}
+	}
+
+	cursor, err := msgView.msgDatabase.GetAllDialogMessages(msgView.context, fromID, toID)
 
 	if err != nil {
 		msgView.logger.Panic(err)
 	}
 
 	messages := []service.Message{}
-	viewMsg := []service.ViewMessage{}
+	err = cursor.All(msgView.context, &messages)
+
+	if err != nil {
+		msgView.logger.Panic(err)
+	}
+
+	return msgView.newViewMessages(messages, fromID)
+}
 
+func (msgView *MessageViewService) GetDialogPage(from string, to string, page int64) *service.ViewDialogPage {
+	if page < 0 {
+		page = 0
+	}
+
+	fromID, toID, err := msgView.getPairIDs(from, to)
+
+	if err != nil {
+		msgView.logger.Errorf("Error when resolving dialog %s - %s, %v", from, to, err)
+		return &service.ViewDialogPage{Messages: []service.ViewMessage{}, Page: page}
+	}
+
+	cursor, err := msgView.msgDatabase.GetDialogMessagesPage(msgView.context, fromID, toID, page*DialogPageSize, DialogPageSize)
+
+	if err != nil {
+		msgView.logger.Panic(err)
+	}
+
+	messages := []service.Message{}
 	err = cursor.All(msgView.context, &messages)
 
 	if err != nil {
 		msgView.logger.Panic(err)
 	}
 
+	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+		messages[i], messages[j] = messages[j], messages[i]
+	}
+
+	total := msgView.msgDatabase.CountDialogMessages(msgView.context, fromID, toID)
+
+	return &service.ViewDialogPage{
+		Messages:  msgView.newViewMessages(messages, fromID),
+		Page:      page,
+		OlderPage: page + 1,
+		NewerPage: page - 1,
+		HasOlder:  (page+1)*DialogPageSize < total,
+		HasNewer:  page > 0,
+	}
+}
+
+func (msgView *MessageViewService) FindMessagePage(from string, to string, messageID string) (int64, error) {
+	fromID, toID, err := msgView.getPairIDs(from, to)
+
+	if err != nil {
+		return 0, err
+	}
+
+	message, err := findDialogMessage(msgView.context, msgView.msgDatabase, msgView.logger, fromID, toID, messageID)
+
+	if err != nil {
+		return 0, err
+	}
+
+	newerAmount := msgView.msgDatabase.CountDialogMessagesAfter(msgView.context, fromID, toID, message.DateAt)
+
+	return newerAmount / DialogPageSize, nil
+}
+
+func (msgView *MessageViewService) newViewMessages(messages []service.Message, userID string) []service.ViewMessage {
+	viewMsg := []service.ViewMessage{}
+	reactions := msgView.getMessageReactions(messages, userID)
+	quotes := msgView.getQuotedMessages(messages)
+	usernames := msgView.userResolver.GetUsernames(messageUserIDs(messages, quotes))
+	texts := []string{}
+
 	for _, msg := range messages {
-		viewMsg = append(viewMsg, service.ViewMessage{
-			From:       msg.From,
-			To:         msg.To,
+		texts = append(texts, msg.Text)
+	}
+
+	rendered := msgView.renderer.RenderAll(texts)
+	pollVotes := msgView.getPollVotes(messages)
+
+	for i, msg := range messages {
+		viewMessage := service.ViewMessage{
+			ID:         msg.ID,
+			From:       usernames[msg.From],
+			To:         usernames[msg.To],
 			Text:       msg.Text,
+			HTML:       rendered[i],
 			FormatDate: msg.DateAt.Format("2006-01-02 15:04"),
 			IsChecked:  msg.IsChecked,
-		})
+			Reactions:  reactions[msg.ID],
+		}
+
+		if msg.ReplyTo != "" {
+			viewMessage.ReplyTo = newViewQuote(msg.ReplyTo, quotes[msg.ReplyTo], usernames)
+		}
+
+		if msg.Kind == MESSAGE_KIND_POLL && msg.Poll != nil {
+			viewMessage.Poll = newViewPoll(msg.Poll, pollVotes[msg.ID], userID)
+		}
+
+		viewMsg = append(viewMsg, viewMessage)
 	}
 
 	return viewMsg
 }
 
+// messageUserIDs collects participants of messages and quotes, so they are
+// resolved to usernames in one query
+func messageUserIDs(messages []service.Message, quotes map[string]*service.Message) []string {
+	userIDs := []string{}
+	isAdded := map[string]bool{}
+
+	addUserID := func(userID string) {
+		if !isAdded[userID] {
+			isAdded[userID] = true
+			userIDs = append(userIDs, userID)
+		}
+	}
+
+	for _, msg := range messages {
+		addUserID(msg.From)
+		addUserID(msg.To)
+	}
+
+	for _, quote := range quotes {
+		addUserID(quote.From)
+	}
+
+	return userIDs
+}
+
+func (msgView *MessageViewService) getQuotedMessages(messages []service.Message) map[string]*service.Message {
+	quotes := map[string]*service.Message{}
+	parentIDs := []string{}
+
+	for i := range messages {
+		quotes[messages[i].ID] = &messages[i]
+	}
+
+	for _, msg := range messages {
+		if _, ok := quotes[msg.ReplyTo]; msg.ReplyTo != "" && !ok {
+			parentIDs = append(parentIDs, msg.ReplyTo)
+		}
+	}
+
+	if len(parentIDs) == 0 {
+		return quotes
+	}
+
+	cursor, err := msgView.msgDatabase.GetMessagesByIDs(msgView.context, parentIDs)
+
+	if err != nil {
+		msgView.logger.Panic(err)
+	}
+
+	parents := []service.Message{}
+	err = cursor.All(msgView.context, &parents)
+
+	if err != nil {
+		msgView.logger.Panic(err)
+	}
+
+	for i := range parents {
+		quotes[parents[i].ID] = &parents[i]
+	}
+
+	return quotes
+}
+
+// getPollVotes loads votes of all polls on the page in one query
+func (msgView *MessageViewService) getPollVotes(messages []service.Message) map[string][]service.PollVote {
+	pollVotes := map[string][]service.PollVote{}
+	pollIDs := []string{}
+
+	for _, msg := range messages {
+		if msg.Kind == MESSAGE_KIND_POLL {
+			pollIDs = append(pollIDs, msg.ID)
+		}
+	}
+
+	if len(pollIDs) == 0 {
+		return pollVotes
+	}
+
+	cursor, err := msgView.msgDatabase.GetPollVotes(msgView.context, pollIDs)
+
+	if err != nil {
+		msgView.logger.Panic(err)
+	}
+
+	votes := []service.PollVote{}
+	err = cursor.All(msgView.context, &votes)
+
+	if err != nil {
+		msgView.logger.Panic(err)
+	}
+
+	for _, vote := range votes {
+		pollVotes[vote.MessageID] = append(pollVotes[vote.MessageID], vote)
+	}
+
+	return pollVotes
+}
+
+func (msgView *MessageViewService) getMessageReactions(messages []service.Message, userID string) map[string][]service.ViewReaction {
+	if len(messages) == 0 {
+		return map[string][]service.ViewReaction{}
+	}
+
+	messageIDs := []string{}
+
+	for _, msg := range messages {
+		messageIDs = append(messageIDs, msg.ID)
+	}
+
+	cursor, err := msgView.msgDatabase.GetReactionCounts(msgView.context, messageIDs)
+
+	if err != nil {
+		msgView.logger.Panic(err)
+	}
+
+	counts := []reactionCount{}
+	err = cursor.All(msgView.context, &counts)
+
+	if err != nil {
+		msgView.logger.Panic(err)
+	}
+
+	return groupReactions(counts, userID)
+}
+
 func (msgView *MessageViewService) CountNewMessages(username string) int64 {
-	msgAmount := msgView.msgDatabase.CountAllNewMessages(msgView.context, username)
+	userID, err := msgView.userResolver.GetUserID(username)
+
+	if err != nil {
+		return 0
+	}
+
+	mutedFriends := []string{}
+
+	for friendID, settings := range msgView.getConversationSettings(userID) {
+		if settings.IsMuted {
+			mutedFriends = append(mutedFriends, friendID)
+		}
+	}
+
+	msgAmount := msgView.msgDatabase.CountAllNewMessages(msgView.context, userID, mutedFriends)
 
 	return msgAmount
 }
 
-func (msgView *MessageViewService) GetAllDialogs(config *config.Config, username string) []*service.ViewDialog {
+func (msgView *MessageViewService) getConversationSettings(userID string) map[string]service.ConversationSettings {
+	cursor, err := msgView.msgDatabase.GetConversationSettings(msgView.context, userID)
+
+	if err != nil {
+		msgView.logger.Panic(err)
+	}
+
+	settings := []service.ConversationSettings{}
+	err = cursor.All(msgView.context, &settings)
+
+	if err != nil {
+		msgView.logger.Panic(err)
+	}
+
+	friendSettings := map[string]service.ConversationSettings{}
+
+	for _, setting := range settings {
+		friendSettings[setting.Friend] = setting
+	}
+
+	return friendSettings
+}
+
+func (msgView *MessageViewService) GetScheduledMessages(username string) []service.ViewScheduledMessage {
+	viewScheduled := []service.ViewScheduledMessage{}
+	userID, err := msgView.userResolver.GetUserID(username)
+
+	if err != nil {
+		return viewScheduled
+	}
+
+	cursor, err := msgView.msgDatabase.GetPendingScheduledMessages(msgView.context, userID)
+
+	if err != nil {
+		msgView.logger.Panic(err)
+	}
+
+	scheduled := []service.ScheduledMessage{}
+	err = cursor.All(msgView.context, &scheduled)
+
+	if err != nil {
+		msgView.logger.Panic(err)
+	}
+
+	receiverIDs := []string{}
+
+	for _, msg := range scheduled {
+		receiverIDs = append(receiverIDs, msg.To)
+	}
+
+	usernames := msgView.userResolver.GetUsernames(receiverIDs)
+
+	for i := range scheduled {
+		msg := &scheduled[i]
+		location := scheduledLocation(msg)
+		sendAt := msg.SendAt.In(location)
+
+		viewScheduled = append(viewScheduled, service.ViewScheduledMessage{
+			ID:           msg.ID,
+			To:           usernames[msg.To],
+			Text:         msg.Text,
+			FormatSendAt: sendAt.Format("2006-01-02 15:04"),
+			InputSendAt:  sendAt.Format(scheduledInputLayout),
+			TimeZone:     location.String(),
+		})
+	}
+
+	return viewScheduled
+}
+
+func (msgView *MessageViewService) GetAllDialogs(config *config.Config, username string, isArchived bool) []*service.ViewDialog {
 	friendView, err := friends.NewFriendViewer(msgView.logger, config)
 
 	if err != nil {
@@ -74,10 +385,24 @@ func (msgView *MessageViewService) GetAllDialogs(config *config.Config, username
 	}
 
 	userFriends := friendView.GetUserFriends(username)
+	userIDs, err := msgView.userResolver.GetUserIDs(append(userFriends, username))
+
+	if err != nil {
+		msgView.logger.Panic(err)
+	}
+
+	userID := userIDs[username]
+	friendSettings := msgView.getConversationSettings(userID)
 	dialogs := []*service.ViewDialog{}
 
 	for _, friend := range userFriends {
-		cursor, err := msgView.msgDatabase.GetLastMessage(msgView.context, username, friend)
+		friendID, ok := userIDs[friend]
+
+		if !ok {
+			continue
+		}
+
+		cursor, err := msgView.msgDatabase.GetLastMessage(msgView.context, userID, friendID)
 
 		if err != nil {
 			msgView.logger.Panic(err)
@@ -90,24 +415,54 @@ func (msgView *MessageViewService) GetAllDialogs(config *config.Config, username
 			msgView.logger.Panic(err)
 		}
 
-		if len(someDialog) == 0 {
-			dialogs = append(dialogs, &service.ViewDialog{
-				From:         friend,
-				IsChecked:    true,
-				AmountNewMsg: 0,
-			})
+		dialog := &service.ViewDialog{
+			From:         friend,
+			IsChecked:    true,
+			AmountNewMsg: 0,
+		}
 
-			continue
+		if len(someDialog) != 0 {
+			dialog = someDialog[0]
+			dialog.AmountNewMsg = msgView.msgDatabase.CountNewMessagesBySender(msgView.context, someDialog[0].From, userID)
+			dialog.AmountNewReactions = msgView.msgDatabase.CountNewReactionsBySender(msgView.context, friendID, userID)
+			dialog.From, dialog.To = dialogUsername(dialog.From, friendID, friend, username), dialogUsername(dialog.To, friendID, friend, username)
 		}
 
-		someDialog[0].AmountNewMsg = msgView.msgDatabase.CountNewMessagesBySender(msgView.context, someDialog[0].From, username)
+		settings := friendSettings[friendID]
 
-		dialogs = append(dialogs, someDialog[0])
+		dialog.Friend = friend
+		dialog.IsPinned = settings.IsPinned
+		dialog.IsMuted = settings.IsMuted
+		dialog.IsArchived = settings.IsArchived && !dialog.DateAt.After(settings.ArchivedAt)
+
+		if dialog.IsArchived != isArchived {
+			continue
+		}
+
+		dialogs = append(dialogs, dialog)
 	}
 
 	sort.Slice(dialogs, func(i, j int) (less bool) {
+		if dialogs[i].IsPinned != dialogs[j].IsPinned {
+			return dialogs[i].IsPinned
+		}
+
 		return dialogs[i].DateAt.Unix() > dialogs[j].DateAt.Unix()
 	})
 
 	return dialogs
 }
+
+func (msgView *MessageViewService) getPairIDs(first string, second string) (string, string, error) {
+	return resolvePairIDs(msgView.userResolver, first, second)
+}
+
+// dialogUsername maps a participant id of a dialog between the user and
+// the friend back to a username without querying users again
+func dialogUsername(userID string, friendID string, friend string, username string) string {
+	if userID == friendID {
+		return friend
+	}
+
+	return username
+}
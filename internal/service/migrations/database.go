@@ -0,0 +1,53 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/delonce/socialnetwork/internal/database"
+	"github.com/delonce/socialnetwork/internal/database/mongodb"
+	"github.com/delonce/socialnetwork/internal/service"
+	"github.com/delonce/socialnetwork/pkg/logging"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type MigrationQueries interface {
+	IsApplied(ctx context.Context, name string) bool
+	AddApplied(ctx context.Context, migration *service.AppliedMigration) error
+}
+
+type MigrationDB struct {
+	Storage database.DBStorage
+	Logger  *logging.Logger
+}
+
+func NewMigrationDB(logger *logging.Logger, database *mongo.Database) MigrationQueries {
+	storage := mongodb.NewStorage(
+		map[string]*mongo.Collection{
+			service.MIGRATION_COLLECTION: database.Collection(service.MIGRATION_COLLECTION),
+		},
+		logger,
+	)
+
+	return &MigrationDB{
+		Storage: storage,
+		Logger:  logger,
+	}
+}
+
+func (migrationStorage *MigrationDB) IsApplied(ctx context.Context, name string) bool {
+	st := migrationStorage.Storage
+	_, err := st.FindOneObject(ctx, bson.M{"name": name}, service.MIGRATION_COLLECTION)
+
+	return err == nil
+}
+
+func (migrationStorage *MigrationDB) AddApplied(ctx context.Context, migration *service.AppliedMigration) error {
+	st := migrationStorage.Storage
+	query := bson.M{"name": migration.Name}
+
+	_, err := st.InsertIfAbsent(ctx, query, migration, service.MIGRATION_COLLECTION)
+
+	return err
+}
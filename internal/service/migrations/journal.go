@@ -0,0 +1,41 @@
+package migrations
+
+import (
+	"context"
+	"time"
+
+	"github.com/delonce/socialnetwork/internal/config"
+	"github.com/delonce/socialnetwork/internal/service"
+	"github.com/delonce/socialnetwork/pkg/logging"
+)
+
+type MigrationJournal struct {
+	migrationDatabase MigrationQueries
+	logger            *logging.Logger
+	context           context.Context
+}
+
+func NewJournal(logger *logging.Logger, config *config.Config) (Journal, error) {
+	database, err := service.InitNewDatabase(config)
+
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	return &MigrationJournal{
+		migrationDatabase: NewMigrationDB(logger, database),
+		logger:            logger,
+	}, nil
+}
+
+func (journal *MigrationJournal) IsApplied(name string) bool {
+	return journal.migrationDatabase.IsApplied(journal.context, name)
+}
+
+func (journal *MigrationJournal) MarkApplied(name string) error {
+	return journal.migrationDatabase.AddApplied(journal.context, &service.AppliedMigration{
+		Name:   name,
+		DateAt: time.Now(),
+	})
+}
@@ -0,0 +1,8 @@
+package migrations
+
+// Journal remembers migrations which rewrite data and must not run again
+// on the next start
+type Journal interface {
+	IsApplied(name string) bool
+	MarkApplied(name string) error
+}
@@ -1,13 +1,61 @@
 package service
 
-import "time"
+import (
+	"html/template"
+	"time"
+)
 
 type User struct {
-	ID           string    `json:"id" bson:"_id,omitempty"`
-	Username     string    `json:"username" bson:"username"`
-	PasswordHash string    `json:"-" bson:"password"`
-	Email        string    `json:"email" bson:"email"`
-	LastEnt      time.Time `json:"time" bson:"time"`
+	ID           string     `json:"id" bson:"_id,omitempty"`
+	Username     string     `json:"username" bson:"username"`
+	PasswordHash string     `json:"-" bson:"password"`
+	Email        string     `json:"email" bson:"email"`
+	EmailHash    string     `json:"-" bson:"emailhash,omitempty"`
+	LastEnt      time.Time  `json:"time" bson:"time"`
+	IsPrivate    bool       `json:"isprivate" bson:"isprivate"`
+	DisplayName  string     `json:"displayname" bson:"displayname,omitempty"`
+	Avatar       string     `json:"avatar" bson:"avatar,omitempty"`
+	Bio          string     `json:"bio" bson:"bio,omitempty"`
+	Location     string     `json:"location" bson:"location,omitempty"`
+	Birthday     *time.Time `json:"birthday" bson:"birthday,omitempty"`
+	Website      string     `json:"website" bson:"website,omitempty"`
+}
+
+// Profile is the part of the user the user fills in and edits
+type Profile struct {
+	DisplayName string
+	Avatar      string
+	Bio         string
+	Location    string
+	Birthday    *time.Time
+	Website     string
+}
+
+// ViewProfile holds the profile fields the viewer may see, hidden fields
+// are left empty
+type ViewProfile struct {
+	DisplayName    string `json:"displayname"`
+	Avatar         string `json:"avatar"`
+	Bio            string `json:"bio,omitempty"`
+	Location       string `json:"location,omitempty"`
+	FormatBirthday string `json:"birthday,omitempty"`
+	Website        string `json:"website,omitempty"`
+}
+
+// UserCard is the short representation of a user shown in lists
+type UserCard struct {
+	ID          string `json:"id"`
+	Username    string `json:"username"`
+	DisplayName string `json:"displayname"`
+	Avatar      string `json:"avatar"`
+}
+
+// ContactMatch is an account found by an email from an imported contact list
+type ContactMatch struct {
+	Username      string `json:"username"`
+	ContactName   string `json:"contactname"`
+	IsFriend      bool   `json:"isfriend"`
+	IsRequestSent bool   `json:"isrequestsent"`
 }
 
 type RefreshToken struct {
@@ -18,12 +66,71 @@ type RefreshToken struct {
 }
 
 type FriendRequest struct {
-	ID         string    `json:"id" bson:"_id,omitempty"`
-	From       string    `json:"from" bson:"from"`
-	To         string    `json:"to" bson:"to"`
-	DateAt     time.Time `json:"date" bson:"date"`
-	IsAccepted bool      `json:"isaccepted" bson:"isaccepted"`
-	IsDenied   bool      `json:"isdenied" bson:"isdenied"`
+	ID      string    `json:"id" bson:"_id,omitempty"`
+	From    string    `json:"from" bson:"from"`
+	To      string    `json:"to" bson:"to"`
+	Users   []string  `json:"users" bson:"users"`
+	Pair    string    `json:"-" bson:"pair"`
+	DateAt  time.Time `json:"date" bson:"date"`
+	State   string    `json:"state" bson:"state"`
+	Message string    `json:"message,omitempty" bson:"message,omitempty"`
+}
+
+type FriendSuggestion struct {
+	User
+	MutualFriends int64 `json:"mutualFriends"`
+}
+
+type FriendshipTransition struct {
+	ID        string    `json:"id" bson:"_id,omitempty"`
+	Pair      string    `json:"pair" bson:"pair"`
+	RequestID string    `json:"requestid" bson:"requestid"`
+	Actor     string    `json:"actor" bson:"actor"`
+	Action    string    `json:"action" bson:"action"`
+	FromState string    `json:"fromstate" bson:"fromstate"`
+	ToState   string    `json:"tostate" bson:"tostate"`
+	DateAt    time.Time `json:"date" bson:"date"`
+}
+
+type UserBlock struct {
+	ID      string    `json:"id" bson:"_id,omitempty"`
+	Blocker string    `json:"blocker" bson:"blocker"`
+	Blocked string    `json:"blocked" bson:"blocked"`
+	DateAt  time.Time `json:"date" bson:"date"`
+}
+
+type Follow struct {
+	ID       string    `json:"id" bson:"_id,omitempty"`
+	Follower string    `json:"follower" bson:"follower"`
+	Followee string    `json:"followee" bson:"followee"`
+	State    string    `json:"state" bson:"state"`
+	DateAt   time.Time `json:"date" bson:"date"`
+}
+
+type FriendList struct {
+	ID     string    `json:"id" bson:"_id,omitempty"`
+	Owner  string    `json:"owner" bson:"owner"`
+	Name   string    `json:"name" bson:"name"`
+	DateAt time.Time `json:"date" bson:"date"`
+}
+
+type FriendListMember struct {
+	ID     string    `json:"id" bson:"_id,omitempty"`
+	ListID string    `json:"listid" bson:"listid"`
+	Owner  string    `json:"owner" bson:"owner"`
+	Member string    `json:"member" bson:"member"`
+	DateAt time.Time `json:"date" bson:"date"`
+}
+
+type VisibilitySettings struct {
+	ID       string `json:"id" bson:"_id,omitempty"`
+	Owner    string `json:"owner" bson:"owner"`
+	Email    string `json:"email" bson:"email"`
+	Friends  string `json:"friends" bson:"friends"`
+	Bio      string `json:"bio" bson:"bio"`
+	Location string `json:"location" bson:"location"`
+	Birthday string `json:"birthday" bson:"birthday"`
+	Website  string `json:"website" bson:"website"`
 }
 
 type Message struct {
@@ -33,14 +140,400 @@ type Message struct {
 	Text      string    `json:"text" bson:"text"`
 	DateAt    time.Time `json:"date" bson:"date"`
 	IsChecked bool      `json:"ischecked" bson:"ischecked"`
+	ReplyTo   string    `json:"replyto,omitempty" bson:"replyto,omitempty"`
+
+	Kind string       `json:"kind,omitempty" bson:"kind,omitempty"`
+	Poll *MessagePoll `json:"poll,omitempty" bson:"poll,omitempty"`
+}
+
+// MessagePoll is stored inside a message of the poll kind, the message text
+// is the question
+type MessagePoll struct {
+	Options    []string   `json:"options" bson:"options"`
+	IsMultiple bool       `json:"ismultiple" bson:"ismultiple"`
+	ClosesAt   *time.Time `json:"closesat,omitempty" bson:"closesat,omitempty"`
+}
+
+// PollVote keeps all options chosen by one voter, so changing the vote is
+// a single document update
+type PollVote struct {
+	ID        string    `json:"id" bson:"_id,omitempty"`
+	MessageID string    `json:"messageid" bson:"messageid"`
+	Voter     string    `json:"voter" bson:"voter"`
+	Options   []int     `json:"options" bson:"options"`
+	DateAt    time.Time `json:"date" bson:"date"`
+}
+
+type ScheduledMessage struct {
+	ID          string    `json:"id" bson:"_id,omitempty"`
+	From        string    `json:"from" bson:"from"`
+	To          string    `json:"to" bson:"to"`
+	Text        string    `json:"text" bson:"text"`
+	ReplyTo     string    `json:"replyto,omitempty" bson:"replyto,omitempty"`
+	SendAt      time.Time `json:"sendat" bson:"sendat"`
+	TimeZone    string    `json:"timezone,omitempty" bson:"timezone,omitempty"`
+	CreatedAt   time.Time `json:"createdat" bson:"createdat"`
+	Status      string    `json:"status" bson:"status"`
+	LockedBy    string    `json:"-" bson:"lockedby"`
+	LockedUntil time.Time `json:"-" bson:"lockeduntil"`
+}
+
+type MessageReaction struct {
+	ID        string    `json:"id" bson:"_id,omitempty"`
+	MessageID string    `json:"messageid" bson:"messageid"`
+	From      string    `json:"from" bson:"from"`
+	To        string    `json:"to" bson:"to"`
+	Emoji     string    `json:"emoji" bson:"emoji"`
+	DateAt    time.Time `json:"date" bson:"date"`
+	IsChecked bool      `json:"ischecked" bson:"ischecked"`
+}
+
+type ConversationSettings struct {
+	ID         string    `json:"id" bson:"_id,omitempty"`
+	Username   string    `json:"username" bson:"username"`
+	Friend     string    `json:"friend" bson:"friend"`
+	IsPinned   bool      `json:"ispinned" bson:"ispinned"`
+	IsArchived bool      `json:"isarchived" bson:"isarchived"`
+	ArchivedAt time.Time `json:"archivedat" bson:"archivedat"`
+	IsMuted    bool      `json:"ismuted" bson:"ismuted"`
+}
+
+type ViewReaction struct {
+	Emoji  string `json:"emoji"`
+	Count  int64  `json:"count"`
+	IsMine bool   `json:"ismine"`
+}
+
+type ViewQuote struct {
+	ID         string
+	From       string
+	Text       string
+	FormatDate string
+	IsDeleted  bool
 }
 
+// ViewMessage keeps the raw source in Text and the sanitized markup
+// rendering of it in HTML
 type ViewMessage struct {
+	ID         string
 	From       string
 	To         string
 	Text       string
+	HTML       template.HTML
 	FormatDate string
 	IsChecked  bool
+	Reactions  []ViewReaction
+	ReplyTo    *ViewQuote
+	Poll       *ViewPoll
+}
+
+type ViewPoll struct {
+	Options        []ViewPollOption `json:"options"`
+	IsMultiple     bool             `json:"ismultiple"`
+	IsClosed       bool             `json:"isclosed"`
+	FormatClosesAt string           `json:"closesat,omitempty"`
+	Voters         int64            `json:"voters"`
+	HasVoted       bool             `json:"hasvoted"`
+}
+
+type ViewPollOption struct {
+	Index    int    `json:"index"`
+	Text     string `json:"text"`
+	Votes    int64  `json:"votes"`
+	IsChosen bool   `json:"ischosen"`
+}
+
+type ViewScheduledMessage struct {
+	ID           string
+	To           string
+	Text         string
+	FormatSendAt string
+	InputSendAt  string
+	TimeZone     string
+}
+
+type ViewFriendRequest struct {
+	To              string    `json:"to"`
+	Message         string    `json:"message,omitempty"`
+	SentAt          time.Time `json:"sentAt"`
+	ExpiresAt       time.Time `json:"expiresAt"`
+	FormatSentAt    string    `json:"-"`
+	FormatExpiresAt string    `json:"-"`
+}
+
+type ViewFriendList struct {
+	ID      string
+	Name    string
+	Members []string
+}
+
+type Post struct {
+	ID     string    `json:"id" bson:"_id,omitempty"`
+	Author string    `json:"author" bson:"author"`
+	Text   string    `json:"text" bson:"text"`
+	DateAt time.Time `json:"date" bson:"date"`
+}
+
+type ViewPost struct {
+	ID         string `json:"id"`
+	Author     string `json:"author"`
+	Text       string `json:"text"`
+	FormatDate string `json:"date"`
+}
+
+type ViewWallPage struct {
+	Posts     []ViewPost `json:"posts"`
+	Total     int64      `json:"total"`
+	Page      int64      `json:"page"`
+	OlderPage int64      `json:"-"`
+	NewerPage int64      `json:"-"`
+	HasOlder  bool       `json:"hasolder"`
+	HasNewer  bool       `json:"hasnewer"`
+}
+
+type GuestbookEntry struct {
+	ID       string    `json:"id" bson:"_id,omitempty"`
+	Owner    string    `json:"owner" bson:"owner"`
+	Author   string    `json:"author" bson:"author"`
+	Text     string    `json:"text" bson:"text"`
+	DateAt   time.Time `json:"date" bson:"date"`
+	IsHidden bool      `json:"ishidden" bson:"ishidden"`
+	Likes    int64     `json:"likes" bson:"likes"`
+}
+
+type GuestbookLike struct {
+	ID      string    `json:"id" bson:"_id,omitempty"`
+	EntryID string    `json:"entryid" bson:"entryid"`
+	User    string    `json:"user" bson:"user"`
+	DateAt  time.Time `json:"date" bson:"date"`
+}
+
+type ViewGuestbookEntry struct {
+	ID         string `json:"id"`
+	Author     string `json:"author"`
+	Text       string `json:"text"`
+	FormatDate string `json:"date"`
+	Likes      int64  `json:"likes"`
+	IsLiked    bool   `json:"isliked"`
+	IsHidden   bool   `json:"ishidden"`
+}
+
+type ViewGuestbookPage struct {
+	Entries   []ViewGuestbookEntry `json:"entries"`
+	Total     int64                `json:"total"`
+	Page      int64                `json:"page"`
+	OlderPage int64                `json:"-"`
+	NewerPage int64                `json:"-"`
+	HasOlder  bool                 `json:"hasolder"`
+	HasNewer  bool                 `json:"hasnewer"`
+	CanWrite  bool                 `json:"canwrite"`
+	IsOwner   bool                 `json:"isowner"`
+}
+
+// Story disappears after ExpiresAt, images are kept in the document itself
+// so the ttl index removes them together with the story
+type Story struct {
+	ID        string    `json:"id" bson:"_id,omitempty"`
+	Author    string    `json:"author" bson:"author"`
+	Kind      string    `json:"kind" bson:"kind"`
+	Text      string    `json:"text" bson:"text"`
+	Image     []byte    `json:"-" bson:"image,omitempty"`
+	ImageType string    `json:"-" bson:"imagetype,omitempty"`
+	DateAt    time.Time `json:"date" bson:"date"`
+	ExpiresAt time.Time `json:"expiresat" bson:"expiresat"`
+}
+
+type StoryView struct {
+	ID        string    `json:"id" bson:"_id,omitempty"`
+	StoryID   string    `json:"storyid" bson:"storyid"`
+	Viewer    string    `json:"viewer" bson:"viewer"`
+	DateAt    time.Time `json:"date" bson:"date"`
+	ExpiresAt time.Time `json:"expiresat" bson:"expiresat"`
+}
+
+type ViewStory struct {
+	ID         string `json:"id"`
+	Author     string `json:"author"`
+	Kind       string `json:"kind"`
+	Text       string `json:"text"`
+	ImageURL   string `json:"imageurl,omitempty"`
+	FormatDate string `json:"date"`
+	IsSeen     bool   `json:"isseen"`
+}
+
+// ViewStoryGroup holds active stories of one author from the oldest one
+type ViewStoryGroup struct {
+	Author    string      `json:"author"`
+	Stories   []ViewStory `json:"stories"`
+	HasUnseen bool        `json:"hasunseen"`
+}
+
+type Group struct {
+	ID          string    `json:"id" bson:"_id,omitempty"`
+	Name        string    `json:"name" bson:"name"`
+	Description string    `json:"description" bson:"description"`
+	JoinPolicy  string    `json:"joinpolicy" bson:"joinpolicy"`
+	DateAt      time.Time `json:"date" bson:"date"`
+}
+
+// GroupMember keeps the role of a user in a group, a join request waiting
+// for approval is a member with the requested role
+type GroupMember struct {
+	ID      string    `json:"id" bson:"_id,omitempty"`
+	GroupID string    `json:"groupid" bson:"groupid"`
+	User    string    `json:"user" bson:"user"`
+	Role    string    `json:"role" bson:"role"`
+	DateAt  time.Time `json:"date" bson:"date"`
+}
+
+type GroupPost struct {
+	ID      string    `json:"id" bson:"_id,omitempty"`
+	GroupID string    `json:"groupid" bson:"groupid"`
+	Author  string    `json:"author" bson:"author"`
+	Text    string    `json:"text" bson:"text"`
+	DateAt  time.Time `json:"date" bson:"date"`
+}
+
+type ViewGroup struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	JoinPolicy  string `json:"joinpolicy"`
+	Members     int64  `json:"members"`
+	Role        string `json:"role,omitempty"`
+}
+
+type ViewGroupMember struct {
+	Username   string `json:"username"`
+	Role       string `json:"role"`
+	FormatDate string `json:"date"`
+}
+
+type ViewGroupPost struct {
+	ID         string `json:"id"`
+	Author     string `json:"author"`
+	Text       string `json:"text"`
+	FormatDate string `json:"date"`
+	CanDelete  bool   `json:"candelete"`
+}
+
+// ViewGroupPage is the group as the viewer sees it, members and the board
+// are empty when the viewer may not see them
+type ViewGroupPage struct {
+	Group        ViewGroup         `json:"group"`
+	Members      []ViewGroupMember `json:"members"`
+	Requests     []ViewGroupMember `json:"requests"`
+	Posts        []ViewGroupPost   `json:"posts"`
+	Total        int64             `json:"total"`
+	Page         int64             `json:"page"`
+	OlderPage    int64             `json:"-"`
+	NewerPage    int64             `json:"-"`
+	HasOlder     bool              `json:"hasolder"`
+	HasNewer     bool              `json:"hasnewer"`
+	CanJoin      bool              `json:"canjoin"`
+	CanViewBoard bool              `json:"canviewboard"`
+	CanPost      bool              `json:"canpost"`
+	CanModerate  bool              `json:"canmoderate"`
+	CanManage    bool              `json:"canmanage"`
+}
+
+// Event is a meeting users are invited to, the reminder fields let one
+// replica at a time send its reminders
+type Event struct {
+	ID             string     `json:"id" bson:"_id,omitempty"`
+	Creator        string     `json:"creator" bson:"creator"`
+	Title          string     `json:"title" bson:"title"`
+	Description    string     `json:"description" bson:"description"`
+	Location       string     `json:"location" bson:"location"`
+	StartsAt       time.Time  `json:"startsat" bson:"startsat"`
+	EndsAt         *time.Time `json:"endsat,omitempty" bson:"endsat,omitempty"`
+	TimeZone       string     `json:"timezone,omitempty" bson:"timezone,omitempty"`
+	CreatedAt      time.Time  `json:"createdat" bson:"createdat"`
+	RemindAt       time.Time  `json:"-" bson:"remindat"`
+	ReminderStatus string     `json:"-" bson:"reminderstatus"`
+	LockedBy       string     `json:"-" bson:"lockedby"`
+	LockedUntil    time.Time  `json:"-" bson:"lockeduntil"`
+}
+
+type EventGuest struct {
+	ID        string    `json:"id" bson:"_id,omitempty"`
+	EventID   string    `json:"eventid" bson:"eventid"`
+	User      string    `json:"user" bson:"user"`
+	Status    string    `json:"status" bson:"status"`
+	InvitedBy string    `json:"invitedby" bson:"invitedby"`
+	DateAt    time.Time `json:"date" bson:"date"`
+}
+
+// EventReminder is shown to a guest until the event starts, the ttl index
+// removes it afterwards
+type EventReminder struct {
+	ID        string    `json:"id" bson:"_id,omitempty"`
+	EventID   string    `json:"eventid" bson:"eventid"`
+	User      string    `json:"user" bson:"user"`
+	DateAt    time.Time `json:"date" bson:"date"`
+	ExpiresAt time.Time `json:"expiresat" bson:"expiresat"`
+}
+
+// AppliedMigration marks a migration which must run only once
+type AppliedMigration struct {
+	ID     string    `json:"id" bson:"_id,omitempty"`
+	Name   string    `json:"name" bson:"name"`
+	DateAt time.Time `json:"date" bson:"date"`
+}
+
+type ViewEvent struct {
+	ID             string `json:"id"`
+	Creator        string `json:"creator"`
+	Title          string `json:"title"`
+	Description    string `json:"description"`
+	Location       string `json:"location"`
+	FormatStartsAt string `json:"startsat"`
+	FormatEndsAt   string `json:"endsat,omitempty"`
+	TimeZone       string `json:"timezone"`
+	Status         string `json:"status,omitempty"`
+	IsCreator      bool   `json:"iscreator"`
+}
+
+type ViewEventPage struct {
+	Event    ViewEvent `json:"event"`
+	Going    []string  `json:"going"`
+	Maybe    []string  `json:"maybe"`
+	Declined []string  `json:"declined"`
+	Invited  []string  `json:"invited"`
+}
+
+// ActivityEvent is something a user did which is shown in friends' feeds,
+// actor and subject are user ids
+type ActivityEvent struct {
+	ID      string    `json:"id" bson:"_id,omitempty"`
+	Type    string    `json:"type" bson:"type"`
+	Actor   string    `json:"actor" bson:"actor"`
+	Subject string    `json:"subject,omitempty" bson:"subject,omitempty"`
+	Data    string    `json:"data,omitempty" bson:"data,omitempty"`
+	DateAt  time.Time `json:"date" bson:"date"`
+}
+
+type ViewActivity struct {
+	ID         string `json:"id"`
+	Type       string `json:"type"`
+	Actor      string `json:"actor"`
+	Subject    string `json:"subject,omitempty"`
+	Data       string `json:"data,omitempty"`
+	FormatDate string `json:"date"`
+}
+
+type ViewFeedPage struct {
+	Events     []ViewActivity `json:"events"`
+	NextCursor string         `json:"nextcursor,omitempty"`
+}
+
+type ViewDialogPage struct {
+	Messages  []ViewMessage
+	Page      int64
+	OlderPage int64
+	NewerPage int64
+	HasOlder  bool
+	HasNewer  bool
 }
 
 type ViewDialog struct {
@@ -50,4 +543,11 @@ type ViewDialog struct {
 	DateAt       time.Time `json:"date" bson:"date"`
 	IsChecked    bool      `json:"ischecked" bson:"ischecked"`
 	AmountNewMsg int64
+
+	AmountNewReactions int64
+
+	Friend     string
+	IsPinned   bool
+	IsArchived bool
+	IsMuted    bool
 }
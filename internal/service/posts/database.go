@@ -0,0 +1,93 @@
+package posts
+
+import (
+	"context"
+
+	"github.com/delonce/socialnetwork/internal/database"
+	"github.com/delonce/socialnetwork/internal/database/mongodb"
+	"github.com/delonce/socialnetwork/internal/service"
+	"github.com/delonce/socialnetwork/pkg/logging"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type PostQueries interface {
+	AddPost(ctx context.Context, post *service.Post) (string, error)
+	DeletePost(ctx context.Context, authorID string, postID string) error
+
+	GetUserPosts(ctx context.Context, authorID string, skip int64, limit int64) (*mongo.Cursor, error)
+	CountUserPosts(ctx context.Context, authorID string) int64
+}
+
+type PostDB struct {
+	Storage database.DBStorage
+	Logger  *logging.Logger
+}
+
+func NewPostDB(logger *logging.Logger, database *mongo.Database) PostQueries {
+	storage := mongodb.NewStorage(
+		map[string]*mongo.Collection{
+			service.POST_COLLECTION: database.Collection(service.POST_COLLECTION),
+		},
+		logger,
+	)
+
+	return &PostDB{
+		Storage: storage,
+		Logger:  logger,
+	}
+}
+
+func (postStorage *PostDB) AddPost(ctx context.Context, post *service.Post) (string, error) {
+	st := postStorage.Storage
+
+	return st.CreateObject(ctx, post, service.POST_COLLECTION)
+}
+
+func (postStorage *PostDB) DeletePost(ctx context.Context, authorID string, postID string) error {
+	st := postStorage.Storage
+	objPostID, err := primitive.ObjectIDFromHex(postID)
+
+	if err != nil {
+		return err
+	}
+
+	query := bson.M{
+		"$and": []bson.M{
+			{"_id": objPostID},
+			{"author": authorID},
+		},
+	}
+
+	return st.Delete(ctx, query, service.POST_COLLECTION)
+}
+
+func (postStorage *PostDB) GetUserPosts(ctx context.Context, authorID string, skip int64, limit int64) (*mongo.Cursor, error) {
+	st := postStorage.Storage
+	query := bson.M{"author": authorID}
+
+	findOpts := options.FindOptions{}
+
+	findOpts.SetSort(bson.D{{Key: "date", Value: -1}})
+	findOpts.SetSkip(skip)
+	findOpts.SetLimit(limit)
+
+	return st.FindObjects(ctx, query, service.POST_COLLECTION, &findOpts)
+}
+
+func (postStorage *PostDB) CountUserPosts(ctx context.Context, authorID string) int64 {
+	st := postStorage.Storage
+	query := bson.M{"author": authorID}
+
+	amount, err := st.CountObjects(ctx, query, service.POST_COLLECTION)
+
+	if err != nil {
+		postStorage.Logger.Errorf("Error when counting posts of %s, %v", authorID, err)
+		return 0
+	}
+
+	return amount
+}
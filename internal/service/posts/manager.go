@@ -0,0 +1,75 @@
+package posts
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/delonce/socialnetwork/internal/config"
+	"github.com/delonce/socialnetwork/internal/service"
+	"github.com/delonce/socialnetwork/internal/service/user"
+	"github.com/delonce/socialnetwork/pkg/logging"
+)
+
+type PostManagerService struct {
+	postDatabase PostQueries
+	userResolver user.Resolver
+	logger       *logging.Logger
+	context      context.Context
+}
+
+func NewPostManager(logger *logging.Logger, config *config.Config) (PostManager, error) {
+	database, err := service.InitNewDatabase(config)
+
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	return &PostManagerService{
+		postDatabase: NewPostDB(logger, database),
+		userResolver: user.NewResolver(logger, database),
+		logger:       logger,
+	}, nil
+}
+
+func (manager *PostManagerService) CreatePost(author string, text string) (string, error) {
+	text = strings.TrimSpace(text)
+
+	if text == "" {
+		return "", ErrEmptyPost
+	}
+
+	if utf8.RuneCountInString(text) > MaxPostLength {
+		return "", ErrLongPost
+	}
+
+	authorID, err := manager.userResolver.GetUserID(author)
+
+	if err != nil {
+		return "", err
+	}
+
+	return manager.postDatabase.AddPost(manager.context, &service.Post{
+		Author: authorID,
+		Text:   text,
+		DateAt: time.Now(),
+	})
+}
+
+// DeletePost removes the post only when it belongs to the author, posts of
+// other users look the same as missing ones
+func (manager *PostManagerService) DeletePost(author string, postID string) error {
+	authorID, err := manager.userResolver.GetUserID(author)
+
+	if err != nil {
+		return err
+	}
+
+	if err = manager.postDatabase.DeletePost(manager.context, authorID, postID); err != nil {
+		return ErrPostNotFound
+	}
+
+	return nil
+}
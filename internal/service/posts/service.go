@@ -0,0 +1,27 @@
+package posts
+
+import (
+	"errors"
+
+	"github.com/delonce/socialnetwork/internal/service"
+)
+
+const (
+	MaxPostLength = 5000
+	WallPageSize  = 20
+)
+
+var (
+	ErrEmptyPost    = errors.New("Post can't be empty")
+	ErrLongPost     = errors.New("Post is too long")
+	ErrPostNotFound = errors.New("Post not found")
+)
+
+type PostManager interface {
+	CreatePost(author string, text string) (string, error)
+	DeletePost(author string, postID string) error
+}
+
+type PostViewer interface {
+	GetWall(owner string, page int64) *service.ViewWallPage
+}
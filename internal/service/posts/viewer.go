@@ -0,0 +1,80 @@
+package posts
+
+import (
+	"context"
+
+	"github.com/delonce/socialnetwork/internal/config"
+	"github.com/delonce/socialnetwork/internal/service"
+	"github.com/delonce/socialnetwork/internal/service/user"
+	"github.com/delonce/socialnetwork/pkg/logging"
+)
+
+type PostViewService struct {
+	postDatabase PostQueries
+	userResolver user.Resolver
+	logger       *logging.Logger
+	context      context.Context
+}
+
+func NewPostViewer(logger *logging.Logger, config *config.Config) (PostViewer, error) {
+	database, err := service.InitNewDatabase(config)
+
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	return &PostViewService{
+		postDatabase: NewPostDB(logger, database),
+		userResolver: user.NewResolver(logger, database),
+		logger:       logger,
+	}, nil
+}
+
+// GetWall returns one page of the owner's posts, the newest first
+func (postView *PostViewService) GetWall(owner string, page int64) *service.ViewWallPage {
+	if page < 0 {
+		page = 0
+	}
+
+	wall := &service.ViewWallPage{
+		Posts: []service.ViewPost{},
+		Page:  page,
+	}
+
+	ownerID, err := postView.userResolver.GetUserID(owner)
+
+	if err != nil {
+		return wall
+	}
+
+	cursor, err := postView.postDatabase.GetUserPosts(postView.context, ownerID, page*WallPageSize, WallPageSize)
+
+	if err != nil {
+		postView.logger.Panic(err)
+	}
+
+	userPosts := []service.Post{}
+	err = cursor.All(postView.context, &userPosts)
+
+	if err != nil {
+		postView.logger.Panic(err)
+	}
+
+	for _, post := range userPosts {
+		wall.Posts = append(wall.Posts, service.ViewPost{
+			ID:         post.ID,
+			Author:     owner,
+			Text:       post.Text,
+			FormatDate: post.DateAt.Format("2006-01-02 15:04"),
+		})
+	}
+
+	wall.Total = postView.postDatabase.CountUserPosts(postView.context, ownerID)
+	wall.OlderPage = page + 1
+	wall.NewerPage = page - 1
+	wall.HasOlder = (page+1)*WallPageSize < wall.Total
+	wall.HasNewer = page > 0
+
+	return wall
+}
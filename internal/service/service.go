@@ -2,7 +2,10 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
+	"os"
 	"strconv"
 
 	"github.com/delonce/socialnetwork/internal/config"
@@ -15,7 +18,30 @@ const (
 	USER_COLLECTION           = "users"
 	SESSION_COLLECTION        = "sessions"
 	FRIEND_REQUEST_COLLECTION = "friend_requests"
+	FRIEND_HISTORY_COLLECTION = "friendship_history"
+	BLOCK_COLLECTION          = "user_blocks"
+	FOLLOW_COLLECTION         = "follows"
+	FRIEND_LIST_COLLECTION    = "friend_lists"
+	LIST_MEMBER_COLLECTION    = "friend_list_members"
+	VISIBILITY_COLLECTION     = "visibility_settings"
 	MESSAGE_COLLECTION        = "messages"
+	REACTION_COLLECTION       = "message_reactions"
+	SCHEDULED_COLLECTION      = "scheduled_messages"
+	POLL_VOTE_COLLECTION      = "poll_votes"
+	CONVERSATION_COLLECTION   = "conversation_settings"
+	POST_COLLECTION           = "posts"
+	ACTIVITY_COLLECTION       = "activity_events"
+	GUESTBOOK_COLLECTION      = "guestbook_entries"
+	GUESTBOOK_LIKE_COLLECTION = "guestbook_likes"
+	STORY_COLLECTION          = "stories"
+	STORY_VIEW_COLLECTION     = "story_views"
+	GROUP_COLLECTION          = "groups"
+	GROUP_MEMBER_COLLECTION   = "group_members"
+	GROUP_POST_COLLECTION     = "group_posts"
+	EVENT_COLLECTION          = "events"
+	EVENT_GUEST_COLLECTION    = "event_guests"
+	EVENT_REMINDER_COLLECTION = "event_reminders"
+	MIGRATION_COLLECTION      = "migrations"
 )
 
 func InitNewDatabase(config *config.Config) (*mongo.Database, error) {
@@ -30,3 +56,30 @@ func InitNewDatabase(config *config.Config) (*mongo.Database, error) {
 
 	return database, nil
 }
+
+// MapUserID replaces a username with the user id, the second value reports
+// whether the value was a known username
+func MapUserID(userIDs map[string]string, value string) (string, bool) {
+	userID, ok := userIDs[value]
+
+	if !ok {
+		return value, false
+	}
+
+	return userID, true
+}
+
+// NewInstanceID names the running replica, background jobs lock the work
+// they took with it
+func NewInstanceID() string {
+	hostname, err := os.Hostname()
+
+	if err != nil {
+		hostname = "netapp"
+	}
+
+	suffix := make([]byte, 8)
+	rand.Read(suffix)
+
+	return fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), hex.EncodeToString(suffix))
+}
@@ -0,0 +1,160 @@
+package stories
+
+import (
+	"context"
+	"time"
+
+	"github.com/delonce/socialnetwork/internal/database"
+	"github.com/delonce/socialnetwork/internal/database/mongodb"
+	"github.com/delonce/socialnetwork/internal/service"
+	"github.com/delonce/socialnetwork/pkg/logging"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type StoryQueries interface {
+	AddStory(ctx context.Context, story *service.Story) (string, error)
+	GetStory(ctx context.Context, storyID string, now time.Time) (*mongo.SingleResult, error)
+	DeleteStory(ctx context.Context, authorID string, storyID string) error
+	GetActiveStories(ctx context.Context, authorIDs []string, now time.Time) (*mongo.Cursor, error)
+
+	AddView(ctx context.Context, view *service.StoryView) error
+	GetSeenStories(ctx context.Context, viewerID string, storyIDs []string) (*mongo.Cursor, error)
+	DeleteViews(ctx context.Context, storyID string) error
+
+	EnsureIndexes(ctx context.Context) error
+}
+
+type StoryDB struct {
+	Storage database.DBStorage
+	Logger  *logging.Logger
+}
+
+func NewStoryDB(logger *logging.Logger, database *mongo.Database) StoryQueries {
+	storage := mongodb.NewStorage(
+		map[string]*mongo.Collection{
+			service.STORY_COLLECTION:      database.Collection(service.STORY_COLLECTION),
+			service.STORY_VIEW_COLLECTION: database.Collection(service.STORY_VIEW_COLLECTION),
+		},
+		logger,
+	)
+
+	return &StoryDB{
+		Storage: storage,
+		Logger:  logger,
+	}
+}
+
+func (storyStorage *StoryDB) AddStory(ctx context.Context, story *service.Story) (string, error) {
+	st := storyStorage.Storage
+
+	return st.CreateObject(ctx, story, service.STORY_COLLECTION)
+}
+
+// GetStory skips expired stories, the ttl index removes them only about
+// once a minute
+func (storyStorage *StoryDB) GetStory(ctx context.Context, storyID string, now time.Time) (*mongo.SingleResult, error) {
+	st := storyStorage.Storage
+	objStoryID, err := primitive.ObjectIDFromHex(storyID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	query := bson.M{
+		"$and": []bson.M{
+			{"_id": objStoryID},
+			{"expiresat": bson.M{"$gt": now}},
+		},
+	}
+
+	return st.FindOneObject(ctx, query, service.STORY_COLLECTION)
+}
+
+func (storyStorage *StoryDB) DeleteStory(ctx context.Context, authorID string, storyID string) error {
+	st := storyStorage.Storage
+	objStoryID, err := primitive.ObjectIDFromHex(storyID)
+
+	if err != nil {
+		return err
+	}
+
+	query := bson.M{
+		"$and": []bson.M{
+			{"_id": objStoryID},
+			{"author": authorID},
+		},
+	}
+
+	return st.Delete(ctx, query, service.STORY_COLLECTION)
+}
+
+// GetActiveStories leaves images out, the strip only links to them
+func (storyStorage *StoryDB) GetActiveStories(ctx context.Context, authorIDs []string, now time.Time) (*mongo.Cursor, error) {
+	st := storyStorage.Storage
+
+	query := bson.M{
+		"$and": []bson.M{
+			{"author": bson.M{"$in": authorIDs}},
+			{"expiresat": bson.M{"$gt": now}},
+		},
+	}
+
+	findOpts := options.FindOptions{}
+
+	findOpts.SetSort(bson.D{{Key: "date", Value: 1}})
+	findOpts.SetProjection(bson.M{"image": 0})
+
+	return st.FindObjects(ctx, query, service.STORY_COLLECTION, &findOpts)
+}
+
+func (storyStorage *StoryDB) AddView(ctx context.Context, view *service.StoryView) error {
+	st := storyStorage.Storage
+
+	query := bson.M{
+		"storyid": view.StoryID,
+		"viewer":  view.Viewer,
+	}
+
+	_, err := st.InsertIfAbsent(ctx, query, view, service.STORY_VIEW_COLLECTION)
+
+	return err
+}
+
+func (storyStorage *StoryDB) GetSeenStories(ctx context.Context, viewerID string, storyIDs []string) (*mongo.Cursor, error) {
+	st := storyStorage.Storage
+
+	query := bson.M{
+		"$and": []bson.M{
+			{"viewer": viewerID},
+			{"storyid": bson.M{"$in": storyIDs}},
+		},
+	}
+
+	return st.FindObjects(ctx, query, service.STORY_VIEW_COLLECTION)
+}
+
+func (storyStorage *StoryDB) DeleteViews(ctx context.Context, storyID string) error {
+	st := storyStorage.Storage
+
+	return st.DeleteMany(ctx, bson.M{"storyid": storyID}, service.STORY_VIEW_COLLECTION)
+}
+
+// EnsureIndexes lets mongo remove expired stories with their views and
+// keeps one view per viewer
+func (storyStorage *StoryDB) EnsureIndexes(ctx context.Context) error {
+	st := storyStorage.Storage
+
+	if err := st.EnsureTTLIndex(ctx, "expiresat", service.STORY_COLLECTION); err != nil {
+		return err
+	}
+
+	if err := st.EnsureTTLIndex(ctx, "expiresat", service.STORY_VIEW_COLLECTION); err != nil {
+		return err
+	}
+
+	return st.EnsureUniqueIndex(ctx, []string{"storyid", "viewer"}, service.STORY_VIEW_COLLECTION)
+}
@@ -0,0 +1,159 @@
+package stories
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/delonce/socialnetwork/internal/config"
+	"github.com/delonce/socialnetwork/internal/service"
+	"github.com/delonce/socialnetwork/internal/service/access"
+	"github.com/delonce/socialnetwork/internal/service/user"
+	"github.com/delonce/socialnetwork/pkg/logging"
+)
+
+type StoryManagerService struct {
+	storyDatabase StoryQueries
+	userResolver  user.Resolver
+	policy        access.Policy
+	logger        *logging.Logger
+	context       context.Context
+}
+
+func NewStoryManager(logger *logging.Logger, config *config.Config) (StoryManager, error) {
+	database, err := service.InitNewDatabase(config)
+
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	policy, err := access.NewPolicy(logger, config)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &StoryManagerService{
+		storyDatabase: NewStoryDB(logger, database),
+		userResolver:  user.NewResolver(logger, database),
+		policy:        policy,
+		logger:        logger,
+	}, nil
+}
+
+func (manager *StoryManagerService) CreateTextStory(author string, text string) (string, error) {
+	text = strings.TrimSpace(text)
+
+	if text == "" {
+		return "", ErrEmptyStory
+	}
+
+	if utf8.RuneCountInString(text) > MaxTextLength {
+		return "", ErrLongStory
+	}
+
+	return manager.addStory(author, &service.Story{
+		Kind: KIND_TEXT,
+		Text: text,
+	})
+}
+
+// CreateImageStory checks the image by its content, the file name and the
+// type sent by the browser are not trusted
+func (manager *StoryManagerService) CreateImageStory(author string, image []byte, caption string) (string, error) {
+	caption = strings.TrimSpace(caption)
+
+	if len(image) == 0 {
+		return "", ErrEmptyStory
+	}
+
+	if len(image) > MaxImageSize {
+		return "", ErrImageTooLarge
+	}
+
+	if utf8.RuneCountInString(caption) > MaxTextLength {
+		return "", ErrLongStory
+	}
+
+	imageType := http.DetectContentType(image)
+
+	if !imageTypes[imageType] {
+		return "", ErrWrongImage
+	}
+
+	return manager.addStory(author, &service.Story{
+		Kind:      KIND_IMAGE,
+		Text:      caption,
+		Image:     image,
+		ImageType: imageType,
+	})
+}
+
+func (manager *StoryManagerService) DeleteStory(author string, storyID string) error {
+	authorID, err := manager.userResolver.GetUserID(author)
+
+	if err != nil {
+		return err
+	}
+
+	if err = manager.storyDatabase.DeleteStory(manager.context, authorID, storyID); err != nil {
+		return ErrStoryNotFound
+	}
+
+	return manager.storyDatabase.DeleteViews(manager.context, storyID)
+}
+
+// MarkSeen remembers the view until the story expires, repeated views
+// of the same story are stored once
+func (manager *StoryManagerService) MarkSeen(viewer string, storyID string) error {
+	result, err := manager.storyDatabase.GetStory(manager.context, storyID, time.Now())
+
+	if err != nil {
+		return ErrStoryNotFound
+	}
+
+	story := service.Story{}
+
+	if err = result.Decode(&story); err != nil {
+		manager.logger.Errorf("Error while decoding story %s, %v", storyID, err)
+		return err
+	}
+
+	usernames := manager.userResolver.GetUsernames([]string{story.Author})
+
+	if err = manager.policy.Authorize(viewer, access.ActionViewStory, usernames[story.Author]); err != nil {
+		return err
+	}
+
+	viewerID, err := manager.userResolver.GetUserID(viewer)
+
+	if err != nil {
+		return err
+	}
+
+	return manager.storyDatabase.AddView(manager.context, &service.StoryView{
+		StoryID:   storyID,
+		Viewer:    viewerID,
+		DateAt:    time.Now(),
+		ExpiresAt: story.ExpiresAt,
+	})
+}
+
+func (manager *StoryManagerService) addStory(author string, story *service.Story) (string, error) {
+	authorID, err := manager.userResolver.GetUserID(author)
+
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+
+	story.Author = authorID
+	story.DateAt = now
+	story.ExpiresAt = now.Add(Lifetime)
+
+	return manager.storyDatabase.AddStory(manager.context, story)
+}
@@ -0,0 +1,46 @@
+package stories
+
+import (
+	"errors"
+	"time"
+
+	"github.com/delonce/socialnetwork/internal/service"
+)
+
+const (
+	KIND_TEXT  = "text"
+	KIND_IMAGE = "image"
+
+	Lifetime       = 24 * time.Hour
+	MaxTextLength  = 500
+	MaxImageSize   = 1 << 20
+	ImageURLFormat = "/stories/%s/image"
+)
+
+var (
+	ErrEmptyStory    = errors.New("Story can't be empty")
+	ErrLongStory     = errors.New("Story text is too long")
+	ErrImageTooLarge = errors.New("Story image is too large")
+	ErrWrongImage    = errors.New("Story image must be png, jpeg or gif")
+	ErrStoryNotFound = errors.New("Story not found")
+)
+
+// imageTypes are content types accepted for image stories
+var imageTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+}
+
+type StoryManager interface {
+	CreateTextStory(author string, text string) (string, error)
+	CreateImageStory(author string, image []byte, caption string) (string, error)
+	DeleteStory(author string, storyID string) error
+	MarkSeen(viewer string, storyID string) error
+}
+
+type StoryViewer interface {
+	GetStoryStrip(username string) []service.ViewStoryGroup
+	GetStory(viewer string, storyID string) (*service.ViewStory, error)
+	GetStoryImage(viewer string, storyID string) ([]byte, string, error)
+}
@@ -0,0 +1,214 @@
+package stories
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/delonce/socialnetwork/internal/config"
+	"github.com/delonce/socialnetwork/internal/service"
+	"github.com/delonce/socialnetwork/internal/service/access"
+	"github.com/delonce/socialnetwork/internal/service/friends"
+	"github.com/delonce/socialnetwork/internal/service/user"
+	"github.com/delonce/socialnetwork/pkg/logging"
+)
+
+type StoryViewService struct {
+	storyDatabase StoryQueries
+	friendView    friends.FriendViewer
+	policy        access.Policy
+	userResolver  user.Resolver
+	logger        *logging.Logger
+	context       context.Context
+}
+
+func NewStoryViewer(logger *logging.Logger, config *config.Config) (StoryViewer, error) {
+	database, err := service.InitNewDatabase(config)
+
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	friendView, err := friends.NewFriendViewer(logger, config)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &StoryViewService{
+		storyDatabase: NewStoryDB(logger, database),
+		friendView:    friendView,
+		policy:        access.NewRelationsPolicy(friendView),
+		userResolver:  user.NewResolver(logger, database),
+		logger:        logger,
+	}, nil
+}
+
+// GetStoryStrip returns active stories of the user and the user's friends,
+// own stories go first and friends with unseen stories go before the rest
+func (storyView *StoryViewService) GetStoryStrip(username string) []service.ViewStoryGroup {
+	authors := append([]string{username}, storyView.friendView.GetUserFriends(username)...)
+	userIDs, err := storyView.userResolver.GetUserIDs(authors)
+
+	if err != nil {
+		storyView.logger.Panic(err)
+	}
+
+	authorIDs := []string{}
+
+	for _, author := range authors {
+		if userID, ok := userIDs[author]; ok {
+			authorIDs = append(authorIDs, userID)
+		}
+	}
+
+	cursor, err := storyView.storyDatabase.GetActiveStories(storyView.context, authorIDs, time.Now())
+
+	if err != nil {
+		storyView.logger.Panic(err)
+	}
+
+	stories := []service.Story{}
+	err = cursor.All(storyView.context, &stories)
+
+	if err != nil {
+		storyView.logger.Panic(err)
+	}
+
+	seenStories := storyView.getSeenStories(userIDs[username], stories)
+	usernames := storyView.userResolver.GetUsernames(authorIDs)
+	groups := map[string]*service.ViewStoryGroup{}
+	strip := []service.ViewStoryGroup{}
+
+	for _, story := range stories {
+		group, ok := groups[story.Author]
+
+		if !ok {
+			group = &service.ViewStoryGroup{Author: usernames[story.Author]}
+			groups[story.Author] = group
+		}
+
+		viewStory := newViewStory(&story, usernames)
+		viewStory.IsSeen = seenStories[story.ID]
+
+		group.Stories = append(group.Stories, viewStory)
+		group.HasUnseen = group.HasUnseen || !viewStory.IsSeen
+	}
+
+	for _, authorID := range authorIDs {
+		if group, ok := groups[authorID]; ok {
+			strip = append(strip, *group)
+		}
+	}
+
+	sort.SliceStable(strip, func(i, j int) bool {
+		if strip[i].Author == username || strip[j].Author == username {
+			return strip[i].Author == username && strip[j].Author != username
+		}
+
+		return strip[i].HasUnseen && !strip[j].HasUnseen
+	})
+
+	return strip
+}
+
+func (storyView *StoryViewService) GetStory(viewer string, storyID string) (*service.ViewStory, error) {
+	story, err := storyView.getVisibleStory(viewer, storyID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	usernames := storyView.userResolver.GetUsernames([]string{story.Author})
+	viewStory := newViewStory(story, usernames)
+
+	return &viewStory, nil
+}
+
+func (storyView *StoryViewService) GetStoryImage(viewer string, storyID string) ([]byte, string, error) {
+	story, err := storyView.getVisibleStory(viewer, storyID)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	if story.Kind != KIND_IMAGE {
+		return nil, "", ErrStoryNotFound
+	}
+
+	return story.Image, story.ImageType, nil
+}
+
+func (storyView *StoryViewService) getVisibleStory(viewer string, storyID string) (*service.Story, error) {
+	result, err := storyView.storyDatabase.GetStory(storyView.context, storyID, time.Now())
+
+	if err != nil {
+		return nil, ErrStoryNotFound
+	}
+
+	story := service.Story{}
+
+	if err = result.Decode(&story); err != nil {
+		storyView.logger.Errorf("Error while decoding story %s, %v", storyID, err)
+		return nil, err
+	}
+
+	usernames := storyView.userResolver.GetUsernames([]string{story.Author})
+
+	if err = storyView.policy.Authorize(viewer, access.ActionViewStory, usernames[story.Author]); err != nil {
+		return nil, err
+	}
+
+	return &story, nil
+}
+
+func (storyView *StoryViewService) getSeenStories(viewerID string, stories []service.Story) map[string]bool {
+	seenStories := map[string]bool{}
+
+	if viewerID == "" || len(stories) == 0 {
+		return seenStories
+	}
+
+	storyIDs := []string{}
+
+	for _, story := range stories {
+		storyIDs = append(storyIDs, story.ID)
+	}
+
+	cursor, err := storyView.storyDatabase.GetSeenStories(storyView.context, viewerID, storyIDs)
+
+	if err != nil {
+		storyView.logger.Panic(err)
+	}
+
+	views := []service.StoryView{}
+	err = cursor.All(storyView.context, &views)
+
+	if err != nil {
+		storyView.logger.Panic(err)
+	}
+
+	for _, view := range views {
+		seenStories[view.StoryID] = true
+	}
+
+	return seenStories
+}
+
+func newViewStory(story *service.Story, usernames map[string]string) service.ViewStory {
+	viewStory := service.ViewStory{
+		ID:         story.ID,
+		Author:     usernames[story.Author],
+		Kind:       story.Kind,
+		Text:       story.Text,
+		FormatDate: story.DateAt.Format("2006-01-02 15:04"),
+	}
+
+	if story.Kind == KIND_IMAGE {
+		viewStory.ImageURL = fmt.Sprintf(ImageURLFormat, story.ID)
+	}
+
+	return viewStory
+}
@@ -3,14 +3,17 @@ package user
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/delonce/socialnetwork/internal/config"
 	"github.com/delonce/socialnetwork/internal/service"
+	"github.com/delonce/socialnetwork/internal/service/activity"
 	"github.com/delonce/socialnetwork/pkg/logging"
 )
 
 type AuthService struct {
 	userDatabase UserQueries
+	recorder     activity.Recorder
 	logger       *logging.Logger
 	context      context.Context
 }
@@ -25,6 +28,7 @@ func NewAuthService(logger *logging.Logger, config *config.Config) (Authorizatio
 
 	return &AuthService{
 		userDatabase: NewUserDB(logger, database),
+		recorder:     activity.NewRecorder(logger, database),
 		logger:       logger,
 	}, nil
 }
@@ -147,3 +151,50 @@ func (auth *AuthService) GetUserByName(username string) (*service.User, error) {
 
 	return &foundedUser, nil
 }
+
+func (auth *AuthService) SetAccountPrivacy(userID string, isPrivate bool) error {
+	err := auth.userDatabase.UpdateUserPrivacy(auth.context, userID, isPrivate)
+
+	if err != nil {
+		auth.logger.Errorf("Can't change privacy of user %s, %v", userID, err)
+		return err
+	}
+
+	return nil
+}
+
+// ChangeUsername renames the user, relations reference the user id and
+// keep working without being rewritten
+func (auth *AuthService) ChangeUsername(userID string, username string) error {
+	username = strings.TrimSpace(username)
+
+	if username == "" || strings.ContainsAny(username, " /\\?#") {
+		return ErrWrongUsername
+	}
+
+	currentUser, err := auth.GetUserByID(userID)
+
+	if err != nil {
+		return ErrUserNotFound
+	}
+
+	if currentUser.Username == username {
+		return ErrSameUsername
+	}
+
+	if _, err = auth.userDatabase.FindUserByUsername(auth.context, username); err == nil {
+		return ErrUsernameTaken
+	}
+
+	if err = auth.userDatabase.UpdateUsername(auth.context, userID, username); err != nil {
+		return err
+	}
+
+	auth.recorder.Record(service.ActivityEvent{
+		Type:  activity.TypeUsername,
+		Actor: userID,
+		Data:  currentUser.Username,
+	})
+
+	return nil
+}
@@ -18,14 +18,24 @@ type UserQueries interface {
 	CreateNewUser(ctx context.Context, user *service.User) (string, error)
 	FindUserByUsername(ctx context.Context, username string) (*mongo.SingleResult, error)
 	FindUserByEmail(ctx context.Context, email string) (*mongo.SingleResult, error)
+	FindUsersByEmailHashes(ctx context.Context, emailHashes []string) (*mongo.Cursor, error)
 	FindUserByID(ctx context.Context, userID string) (*mongo.SingleResult, error)
 	FindUserByCredentials(ctx context.Context, username, passwordHash string) (*mongo.SingleResult, error)
+	FindUsersByIDs(ctx context.Context, userIDs []string) (*mongo.Cursor, error)
+	FindUsersByUsernames(ctx context.Context, usernames []string) (*mongo.Cursor, error)
+	FindAllUsers(ctx context.Context) (*mongo.Cursor, error)
 	DeleteUser(ctx context.Context, user *service.User) error
+	UpdateUserPrivacy(ctx context.Context, userID string, isPrivate bool) error
+	UpdateEmailHash(ctx context.Context, userID string, emailHash string) error
+	UpdateUsername(ctx context.Context, userID string, username string) error
+	UpdateProfile(ctx context.Context, userID string, profile *service.Profile) error
 
 	AddRefreshToken(ctx context.Context, refreshToken *service.RefreshToken) (string, error)
 	FindRefreshTokenByUUID(ctx context.Context, refreshTokenUUID string) (*mongo.SingleResult, error)
 	FindExpiredRefreshTokens(ctx context.Context, userID string) (*mongo.Cursor, error)
 	DeleteRefreshToken(ctx context.Context, refreshTokenUUID string) error
+
+	EnsureIndexes(ctx context.Context) error
 }
 
 type UserDB struct {
@@ -74,6 +84,13 @@ func (userStorage *UserDB) FindUserByEmail(ctx context.Context, email string) (*
 	return st.FindOneObject(ctx, query, service.USER_COLLECTION)
 }
 
+func (userStorage *UserDB) FindUsersByEmailHashes(ctx context.Context, emailHashes []string) (*mongo.Cursor, error) {
+	st := userStorage.Storage
+	query := bson.M{"emailhash": bson.M{"$in": emailHashes}}
+
+	return st.FindObjects(ctx, query, service.USER_COLLECTION)
+}
+
 func (userStorage *UserDB) FindUserByID(ctx context.Context, userID string) (*mongo.SingleResult, error) {
 	st := userStorage.Storage
 	objUserID, err := primitive.ObjectIDFromHex(userID)
@@ -94,6 +111,38 @@ func (userStorage *UserDB) FindUserByCredentials(ctx context.Context, username,
 	return st.FindOneObject(ctx, query, service.USER_COLLECTION)
 }
 
+func (userStorage *UserDB) FindUsersByIDs(ctx context.Context, userIDs []string) (*mongo.Cursor, error) {
+	st := userStorage.Storage
+	objUserIDs := []primitive.ObjectID{}
+
+	for _, userID := range userIDs {
+		objUserID, err := primitive.ObjectIDFromHex(userID)
+
+		if err != nil {
+			continue
+		}
+
+		objUserIDs = append(objUserIDs, objUserID)
+	}
+
+	query := bson.M{"_id": bson.M{"$in": objUserIDs}}
+
+	return st.FindObjects(ctx, query, service.USER_COLLECTION)
+}
+
+func (userStorage *UserDB) FindUsersByUsernames(ctx context.Context, usernames []string) (*mongo.Cursor, error) {
+	st := userStorage.Storage
+	query := bson.M{"username": bson.M{"$in": usernames}}
+
+	return st.FindObjects(ctx, query, service.USER_COLLECTION)
+}
+
+func (userStorage *UserDB) FindAllUsers(ctx context.Context) (*mongo.Cursor, error) {
+	st := userStorage.Storage
+
+	return st.FindObjects(ctx, bson.M{}, service.USER_COLLECTION)
+}
+
 func (userStorage *UserDB) DeleteUser(ctx context.Context, user *service.User) error {
 	st := userStorage.Storage
 	query := bson.M{"_id": user.ID}
@@ -101,6 +150,80 @@ func (userStorage *UserDB) DeleteUser(ctx context.Context, user *service.User) e
 	return st.Delete(ctx, query, service.USER_COLLECTION)
 }
 
+func (userStorage *UserDB) UpdateUserPrivacy(ctx context.Context, userID string, isPrivate bool) error {
+	st := userStorage.Storage
+	objUserID, err := primitive.ObjectIDFromHex(userID)
+
+	if err != nil {
+		return err
+	}
+
+	query := bson.M{"_id": objUserID}
+
+	return st.Update(ctx, query, bson.M{"isprivate": isPrivate}, service.USER_COLLECTION)
+}
+
+func (userStorage *UserDB) UpdateEmailHash(ctx context.Context, userID string, emailHash string) error {
+	st := userStorage.Storage
+	objUserID, err := primitive.ObjectIDFromHex(userID)
+
+	if err != nil {
+		return err
+	}
+
+	query := bson.M{"_id": objUserID}
+
+	return st.Update(ctx, query, bson.M{"emailhash": emailHash}, service.USER_COLLECTION)
+}
+
+func (userStorage *UserDB) UpdateUsername(ctx context.Context, userID string, username string) error {
+	st := userStorage.Storage
+	objUserID, err := primitive.ObjectIDFromHex(userID)
+
+	if err != nil {
+		return err
+	}
+
+	query := bson.M{"_id": objUserID}
+	err = st.Update(ctx, query, bson.M{"username": username}, service.USER_COLLECTION)
+
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrUsernameTaken
+	}
+
+	return err
+}
+
+// EnsureIndexes keeps usernames unique, they are the only way to find
+// a user by the profile url
+func (userStorage *UserDB) EnsureIndexes(ctx context.Context) error {
+	st := userStorage.Storage
+
+	return st.EnsureUniqueIndex(ctx, []string{"username"}, service.USER_COLLECTION)
+}
+
+func (userStorage *UserDB) UpdateProfile(ctx context.Context, userID string, profile *service.Profile) error {
+	st := userStorage.Storage
+	objUserID, err := primitive.ObjectIDFromHex(userID)
+
+	if err != nil {
+		return err
+	}
+
+	query := bson.M{"_id": objUserID}
+
+	model := bson.M{
+		"displayname": profile.DisplayName,
+		"avatar":      profile.Avatar,
+		"bio":         profile.Bio,
+		"location":    profile.Location,
+		"birthday":    profile.Birthday,
+		"website":     profile.Website,
+	}
+
+	return st.Update(ctx, query, model, service.USER_COLLECTION)
+}
+
 func (userStorage *UserDB) DeleteRefreshToken(ctx context.Context, refreshTokenUUID string) error {
 	st := userStorage.Storage
 	query := bson.M{"uuid": refreshTokenUUID}
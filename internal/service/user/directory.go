@@ -0,0 +1,189 @@
+package user
+
+import (
+	"context"
+	"sync"
+
+	"github.com/delonce/socialnetwork/internal/config"
+	"github.com/delonce/socialnetwork/internal/service"
+	"github.com/delonce/socialnetwork/pkg/logging"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const DefaultAvatar = "/static/img/avatar.svg"
+
+type UserDirectory struct {
+	userDatabase UserQueries
+	logger       *logging.Logger
+	context      context.Context
+
+	mu               sync.Mutex
+	byID             map[string]*service.User
+	byUsername       map[string]*service.User
+	missingIDs       map[string]bool
+	missingUsernames map[string]bool
+}
+
+func NewUserDirectory(logger *logging.Logger, config *config.Config) (Directory, error) {
+	database, err := service.InitNewDatabase(config)
+
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	return &UserDirectory{
+		userDatabase:     NewUserDB(logger, database),
+		logger:           logger,
+		byID:             map[string]*service.User{},
+		byUsername:       map[string]*service.User{},
+		missingIDs:       map[string]bool{},
+		missingUsernames: map[string]bool{},
+	}, nil
+}
+
+func (directory *UserDirectory) GetUserByID(userID string) (*service.User, error) {
+	user, ok := directory.GetUsersByIDs([]string{userID})[userID]
+
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+
+	return user, nil
+}
+
+func (directory *UserDirectory) GetUserByName(username string) (*service.User, error) {
+	user, ok := directory.GetUsersByUsernames([]string{username})[username]
+
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+
+	return user, nil
+}
+
+// GetUsersByIDs loads only users which weren't looked up before, unknown
+// ids are missing in the result
+func (directory *UserDirectory) GetUsersByIDs(userIDs []string) map[string]*service.User {
+	directory.mu.Lock()
+	defer directory.mu.Unlock()
+
+	unknown := unknownKeys(userIDs, directory.byID, directory.missingIDs)
+
+	if len(unknown) != 0 {
+		cursor, err := directory.userDatabase.FindUsersByIDs(directory.context, unknown)
+
+		if err != nil {
+			directory.logger.Errorf("Error when looking users up by ids, %v", err)
+		} else {
+			directory.remember(cursor, unknown, directory.byID, directory.missingIDs)
+		}
+	}
+
+	return pickUsers(userIDs, directory.byID)
+}
+
+// GetUsersByUsernames loads only users which weren't looked up before,
+// unknown usernames are missing in the result
+func (directory *UserDirectory) GetUsersByUsernames(usernames []string) map[string]*service.User {
+	directory.mu.Lock()
+	defer directory.mu.Unlock()
+
+	unknown := unknownKeys(usernames, directory.byUsername, directory.missingUsernames)
+
+	if len(unknown) != 0 {
+		cursor, err := directory.userDatabase.FindUsersByUsernames(directory.context, unknown)
+
+		if err != nil {
+			directory.logger.Errorf("Error when looking users up by usernames, %v", err)
+		} else {
+			directory.remember(cursor, unknown, directory.byUsername, directory.missingUsernames)
+		}
+	}
+
+	return pickUsers(usernames, directory.byUsername)
+}
+
+// GetCards returns a card for every username, deleted users get a card
+// built from the username alone
+func (directory *UserDirectory) GetCards(usernames []string) map[string]service.UserCard {
+	users := directory.GetUsersByUsernames(usernames)
+	cards := map[string]service.UserCard{}
+
+	for _, username := range usernames {
+		card := service.UserCard{
+			Username:    username,
+			DisplayName: username,
+			Avatar:      DefaultAvatar,
+		}
+
+		if user, ok := users[username]; ok {
+			card.ID = user.ID
+
+			if user.DisplayName != "" {
+				card.DisplayName = user.DisplayName
+			}
+
+			if user.Avatar != "" {
+				card.Avatar = user.Avatar
+			}
+		}
+
+		cards[username] = card
+	}
+
+	return cards
+}
+
+func unknownKeys(keys []string, known map[string]*service.User, missing map[string]bool) []string {
+	unknown := []string{}
+	isAdded := map[string]bool{}
+
+	for _, key := range keys {
+		if _, ok := known[key]; ok || missing[key] || isAdded[key] {
+			continue
+		}
+
+		isAdded[key] = true
+		unknown = append(unknown, key)
+	}
+
+	return unknown
+}
+
+// remember caches found users under both keys and marks keys of users which
+// don't exist, so they aren't queried again
+func (directory *UserDirectory) remember(cursor *mongo.Cursor, keys []string,
+	known map[string]*service.User, missing map[string]bool) {
+
+	users := []service.User{}
+
+	if err := cursor.All(directory.context, &users); err != nil {
+		directory.logger.Errorf("Error when decoding users, %v", err)
+		return
+	}
+
+	for i := range users {
+		directory.byID[users[i].ID] = &users[i]
+		directory.byUsername[users[i].Username] = &users[i]
+	}
+
+	for _, key := range keys {
+		if _, ok := known[key]; !ok {
+			missing[key] = true
+		}
+	}
+}
+
+func pickUsers(keys []string, known map[string]*service.User) map[string]*service.User {
+	users := map[string]*service.User{}
+
+	for _, key := range keys {
+		if user, ok := known[key]; ok {
+			users[key] = user
+		}
+	}
+
+	return users
+}
@@ -0,0 +1,91 @@
+package user
+
+import (
+	"context"
+
+	"github.com/delonce/socialnetwork/internal/config"
+	"github.com/delonce/socialnetwork/internal/service"
+	"github.com/delonce/socialnetwork/pkg/logging"
+)
+
+// LoadUserIDs maps every username to its id for migrations which replace
+// stored usernames with ids
+func LoadUserIDs(logger *logging.Logger, config *config.Config) (map[string]string, error) {
+	database, err := service.InitNewDatabase(config)
+
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	ctx := context.Background()
+	cursor, err := NewUserDB(logger, database).FindAllUsers(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	users := []service.User{}
+
+	if err = cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+
+	userIDs := map[string]string{}
+
+	for _, user := range users {
+		userIDs[user.Username] = user.ID
+	}
+
+	return userIDs, nil
+}
+
+// MigrateEmailHashes fills email hashes of users registered before contacts
+// import appeared
+func MigrateEmailHashes(logger *logging.Logger, config *config.Config) error {
+	database, err := service.InitNewDatabase(config)
+
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	ctx := context.Background()
+	userDatabase := NewUserDB(logger, database)
+	cursor, err := userDatabase.FindAllUsers(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	users := []service.User{}
+
+	if err = cursor.All(ctx, &users); err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		emailHash := HashEmail(user.Email)
+
+		if user.EmailHash == emailHash {
+			continue
+		}
+
+		if err = userDatabase.UpdateEmailHash(ctx, user.ID, emailHash); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func MigrateUserIndexes(logger *logging.Logger, config *config.Config) error {
+	database, err := service.InitNewDatabase(config)
+
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return NewUserDB(logger, database).EnsureIndexes(context.Background())
+}
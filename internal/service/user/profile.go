@@ -0,0 +1,124 @@
+package user
+
+import (
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/delonce/socialnetwork/internal/service"
+	"github.com/delonce/socialnetwork/internal/service/activity"
+)
+
+// UpdateProfile replaces the profile fields of the user, friends see that
+// the profile changed but never the changed fields themselves
+func (auth *AuthService) UpdateProfile(userID string, profile *service.Profile) error {
+	if err := checkProfile(profile); err != nil {
+		return err
+	}
+
+	currentUser, err := auth.GetUserByID(userID)
+
+	if err != nil {
+		return ErrUserNotFound
+	}
+
+	if isSameProfile(currentUser, profile) {
+		return nil
+	}
+
+	if err = auth.userDatabase.UpdateProfile(auth.context, userID, profile); err != nil {
+		auth.logger.Errorf("Can't change profile of user %s, %v", userID, err)
+		return err
+	}
+
+	auth.recorder.Record(service.ActivityEvent{
+		Type:  activity.TypeProfile,
+		Actor: userID,
+	})
+
+	return nil
+}
+
+// checkProfile trims the fields and checks them, links without a scheme
+// are treated as https ones
+func checkProfile(profile *service.Profile) error {
+	profile.DisplayName = strings.TrimSpace(profile.DisplayName)
+	profile.Bio = strings.TrimSpace(profile.Bio)
+	profile.Location = strings.TrimSpace(profile.Location)
+	profile.Website = strings.TrimSpace(profile.Website)
+	profile.Avatar = strings.TrimSpace(profile.Avatar)
+
+	if utf8.RuneCountInString(profile.DisplayName) > MaxDisplayNameLength {
+		return ErrLongDisplayName
+	}
+
+	if utf8.RuneCountInString(profile.Bio) > MaxBioLength {
+		return ErrLongBio
+	}
+
+	if utf8.RuneCountInString(profile.Location) > MaxLocationLength {
+		return ErrLongLocation
+	}
+
+	if profile.Birthday != nil {
+		birthday := *profile.Birthday
+
+		if birthday.After(time.Now()) || birthday.Year() < 1900 {
+			return ErrWrongBirthday
+		}
+	}
+
+	if profile.Website != "" {
+		website, ok := normalizeLink(profile.Website)
+
+		if !ok {
+			return ErrWrongWebsite
+		}
+
+		profile.Website = website
+	}
+
+	// avatars may also be images of the site itself
+	if profile.Avatar != "" && !strings.HasPrefix(profile.Avatar, "/static/") {
+		avatar, ok := normalizeLink(profile.Avatar)
+
+		if !ok {
+			return ErrWrongAvatar
+		}
+
+		profile.Avatar = avatar
+	}
+
+	return nil
+}
+
+func normalizeLink(link string) (string, bool) {
+	if len(link) > MaxLinkLength {
+		return "", false
+	}
+
+	if !strings.Contains(link, "://") {
+		link = "https://" + link
+	}
+
+	parsed, err := url.Parse(link)
+
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return "", false
+	}
+
+	return parsed.String(), true
+}
+
+func isSameProfile(user *service.User, profile *service.Profile) bool {
+	sameBirthday := user.Birthday == nil && profile.Birthday == nil ||
+		user.Birthday != nil && profile.Birthday != nil && user.Birthday.Equal(*profile.Birthday)
+
+	return sameBirthday &&
+		user.DisplayName == profile.DisplayName &&
+		user.Avatar == profile.Avatar &&
+		user.Bio == profile.Bio &&
+		user.Location == profile.Location &&
+		user.Website == profile.Website
+}
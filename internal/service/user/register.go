@@ -8,6 +8,8 @@ import (
 	"github.com/delonce/socialnetwork/internal/config"
 	"github.com/delonce/socialnetwork/internal/service"
 	"github.com/delonce/socialnetwork/pkg/logging"
+
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type RegisterService struct {
@@ -40,6 +42,7 @@ func (regServ *RegisterService) RegisterNewUser(username, password, email string
 		Username:     username,
 		PasswordHash: getPasswordHash(password),
 		Email:        email,
+		EmailHash:    HashEmail(email),
 		LastEnt:      time.Now(),
 	}
 
@@ -54,8 +57,12 @@ func (regServ *RegisterService) RegisterNewUser(username, password, email string
 	}
 
 	userID, err := regServ.userDatabase.CreateNewUser(regServ.context, &newUser)
+	if mongo.IsDuplicateKeyError(err) {
+		return "", fmt.Errorf("User with username %s already exist!", username)
+	}
+
 	if err != nil {
-		regServ.logger.Errorf("Failed to create user %s", newUser)
+		regServ.logger.Errorf("Failed to create user %s", newUser.Username)
 		return "", err
 	}
 
@@ -0,0 +1,103 @@
+package user
+
+import (
+	"context"
+
+	"github.com/delonce/socialnetwork/internal/service"
+	"github.com/delonce/socialnetwork/pkg/logging"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type UserResolver struct {
+	userDatabase UserQueries
+	logger       *logging.Logger
+	context      context.Context
+}
+
+// NewResolver shares the database of the calling service, so resolving ids
+// doesn't open a connection of its own
+func NewResolver(logger *logging.Logger, database *mongo.Database) Resolver {
+	return &UserResolver{
+		userDatabase: NewUserDB(logger, database),
+		logger:       logger,
+	}
+}
+
+func (resolver *UserResolver) GetUserID(username string) (string, error) {
+	userIDs, err := resolver.GetUserIDs([]string{username})
+
+	if err != nil {
+		return "", err
+	}
+
+	userID, ok := userIDs[username]
+
+	if !ok {
+		return "", ErrUserNotFound
+	}
+
+	return userID, nil
+}
+
+// GetUserIDs maps usernames to ids in one query, unknown usernames are
+// missing in the result
+func (resolver *UserResolver) GetUserIDs(usernames []string) (map[string]string, error) {
+	userIDs := map[string]string{}
+
+	if len(usernames) == 0 {
+		return userIDs, nil
+	}
+
+	cursor, err := resolver.userDatabase.FindUsersByUsernames(resolver.context, usernames)
+
+	if err != nil {
+		return nil, err
+	}
+
+	users := []service.User{}
+
+	if err = cursor.All(resolver.context, &users); err != nil {
+		return nil, err
+	}
+
+	for _, user := range users {
+		userIDs[user.Username] = user.ID
+	}
+
+	return userIDs, nil
+}
+
+// GetUsernames maps ids to current usernames in one query, ids of deleted
+// users are mapped to themselves
+func (resolver *UserResolver) GetUsernames(userIDs []string) map[string]string {
+	usernames := map[string]string{}
+
+	for _, userID := range userIDs {
+		usernames[userID] = userID
+	}
+
+	if len(userIDs) == 0 {
+		return usernames
+	}
+
+	cursor, err := resolver.userDatabase.FindUsersByIDs(resolver.context, userIDs)
+
+	if err != nil {
+		resolver.logger.Errorf("Error when resolving usernames, %v", err)
+		return usernames
+	}
+
+	users := []service.User{}
+
+	if err = cursor.All(resolver.context, &users); err != nil {
+		resolver.logger.Errorf("Error when decoding users, %v", err)
+		return usernames
+	}
+
+	for _, user := range users {
+		usernames[user.ID] = user.Username
+	}
+
+	return usernames
+}
@@ -1,11 +1,38 @@
 package user
 
 import (
+	"crypto/sha256"
 	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"strings"
 
 	"github.com/delonce/socialnetwork/internal/service"
 )
 
+var (
+	ErrUserNotFound  = errors.New("User not found")
+	ErrUsernameTaken = errors.New("Username is already taken")
+	ErrWrongUsername = errors.New("Username can't be empty or contain spaces and slashes")
+	ErrSameUsername  = errors.New("New username is the same as the current one")
+
+	ErrLongDisplayName = errors.New("Display name is too long")
+	ErrLongBio         = errors.New("Bio is too long")
+	ErrLongLocation    = errors.New("Location is too long")
+	ErrWrongBirthday   = errors.New("Birthday must be a past date")
+	ErrWrongWebsite    = errors.New("Website must be an http or https link")
+	ErrWrongAvatar     = errors.New("Avatar must be an http or https link to an image")
+)
+
+const (
+	MaxDisplayNameLength = 64
+	MaxBioLength         = 1000
+	MaxLocationLength    = 100
+	MaxLinkLength        = 500
+
+	BirthdayLayout = "2006-01-02"
+)
+
 type Registration interface {
 	RegisterNewUser(username, password, email string) (string, error)
 }
@@ -17,6 +44,27 @@ type Authorization interface {
 	GetUserByCredentials(username, password string) (*service.User, error)
 	GetUserByID(userID string) (*service.User, error)
 	GetUserByName(username string) (*service.User, error)
+	SetAccountPrivacy(userID string, isPrivate bool) error
+	ChangeUsername(userID string, username string) error
+	UpdateProfile(userID string, profile *service.Profile) error
+}
+
+// Resolver translates between usernames and immutable user ids, relations
+// store ids and views show the current usernames
+type Resolver interface {
+	GetUserID(username string) (string, error)
+	GetUserIDs(usernames []string) (map[string]string, error)
+	GetUsernames(userIDs []string) map[string]string
+}
+
+// Directory looks users up in batches and remembers every found user, one
+// directory lives as long as the request which created it
+type Directory interface {
+	GetUserByID(userID string) (*service.User, error)
+	GetUserByName(username string) (*service.User, error)
+	GetUsersByIDs(userIDs []string) map[string]*service.User
+	GetUsersByUsernames(usernames []string) map[string]*service.User
+	GetCards(usernames []string) map[string]service.UserCard
 }
 
 type Session interface {
@@ -37,3 +85,12 @@ func getPasswordHash(password string) string {
 
 	return string(bs)
 }
+
+// HashEmail normalizes the email before hashing, so contacts imported with
+// another letter case or extra spaces still match the account
+func HashEmail(email string) string {
+	normalized := strings.ToLower(strings.TrimSpace(email))
+	hash := sha256.Sum256([]byte(normalized))
+
+	return hex.EncodeToString(hash[:])
+}